@@ -1,7 +1,11 @@
 package cmd
 
 import (
+	"os"
+	"strings"
+
 	"github.com/frozzare/go/env"
+	"github.com/frozzare/max/internal/runner"
 	"github.com/spf13/pflag"
 )
 
@@ -13,6 +17,54 @@ func defaultTask() string {
 	return "default"
 }
 
+// targets returns all tasks to run, arguments are only used as tasks
+// when all of them are task names, unique task name prefixes or patterns
+// matching task names.
+func targets(r *runner.Runner, task string, args []string) []string {
+	if len(args) == 0 {
+		return []string{task}
+	}
+
+	for _, id := range args {
+		if _, err := r.Resolve(id); err != nil {
+			if _, err := r.Match(id); err != nil {
+				return []string{task}
+			}
+		}
+	}
+
+	return append([]string{task}, args...)
+}
+
+// flagArgs returns --key value and --key=value arguments, used as
+// data when preprocessing the config file.
+func flagArgs(args []string) map[string]interface{} {
+	res := make(map[string]interface{})
+
+	for i := 0; i < len(args); i++ {
+		if !strings.HasPrefix(args[i], "-") {
+			continue
+		}
+
+		key := strings.TrimLeft(args[i], "-")
+		if len(key) == 0 {
+			continue
+		}
+
+		if j := strings.Index(key, "="); j != -1 {
+			res[strings.Replace(key[:j], "-", "_", -1)] = key[j+1:]
+			continue
+		}
+
+		if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			res[strings.Replace(key, "-", "_", -1)] = args[i+1]
+			i++
+		}
+	}
+
+	return res
+}
+
 func taskWithArgs() (string, []string) {
 	args := pflag.Args()
 	if len(args) == 0 {
@@ -28,3 +80,92 @@ func taskWithArgs() (string, []string) {
 
 	return task, args
 }
+
+// taskArgs returns the arguments given after the task name without max's
+// own flags, e.g [--region eu --dry-run] for max deploy -q --region eu --dry-run.
+// The task's flags takes precedence over max's flags with the same name.
+func taskArgs(task string, flags []string) []string {
+	var res []string
+	found := false
+
+	own := make(map[string]bool, len(flags))
+	for _, name := range flags {
+		own[name] = true
+	}
+
+	for i := 1; i < len(os.Args); i++ {
+		arg := os.Args[i]
+
+		if found && strings.HasPrefix(arg, "--") && own[strings.SplitN(arg[2:], "=", 2)[0]] {
+			res = append(res, arg)
+			continue
+		}
+
+		if f, ok := maxFlag(arg); ok {
+			// Skip the value of flags that aren't written as --key=value.
+			if f != nil && len(f.NoOptDefVal) == 0 && !strings.Contains(arg, "=") {
+				i++
+			}
+
+			continue
+		}
+
+		if !found {
+			found = arg == task
+			continue
+		}
+
+		res = append(res, arg)
+	}
+
+	return res
+}
+
+// maxFlag reports if the argument is one of max's own flags, the flag is
+// nil for combined shorthand flags, e.g -qv.
+func maxFlag(arg string) (*pflag.Flag, bool) {
+	if strings.HasPrefix(arg, "--") {
+		name := strings.SplitN(arg[2:], "=", 2)[0]
+		f := pflag.CommandLine.Lookup(name)
+		return f, f != nil
+	}
+
+	if !strings.HasPrefix(arg, "-") || len(arg) < 2 {
+		return nil, false
+	}
+
+	shorthands := strings.SplitN(arg[1:], "=", 2)[0]
+
+	if len(shorthands) == 1 {
+		f := pflag.CommandLine.ShorthandLookup(shorthands)
+		return f, f != nil
+	}
+
+	for _, c := range shorthands {
+		if f := pflag.CommandLine.ShorthandLookup(string(c)); f == nil || len(f.NoOptDefVal) == 0 {
+			return nil, false
+		}
+	}
+
+	return nil, true
+}
+
+// resetFlags resets max's flags that are shadowed by the task's flags so
+// e.g a task's --dry-run flag don't enable max's dry run mode.
+func resetFlags(task string, flags []string) {
+	args := taskArgs(task, flags)
+
+	for _, name := range flags {
+		f := pflag.CommandLine.Lookup(name)
+		if f == nil {
+			continue
+		}
+
+		for _, arg := range args {
+			if arg == "--"+name || strings.HasPrefix(arg, "--"+name+"=") {
+				f.Value.Set(f.DefValue)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/frozzare/max/internal/cache"
+	"github.com/frozzare/max/internal/task"
+)
+
+// cacheEntry represents a cache entry printed by explain-cache.
+type cacheEntry struct {
+	Key     string     `json:"key"`
+	Kind    string     `json:"kind"`
+	Task    string     `json:"task,omitempty"`
+	Dir     string     `json:"dir,omitempty"`
+	Size    int        `json:"size"`
+	Updated *time.Time `json:"updated,omitempty"`
+}
+
+// explainCache writes the cache entries with their kind, size and update
+// time as a table or as json. Url includes are stored by url and task
+// cache keys by working directory and task id.
+func explainCache(w io.Writer, c *cache.Cache, asJSON bool) error {
+	entries, err := c.Entries()
+	if err != nil {
+		return err
+	}
+
+	res := make([]cacheEntry, 0, len(entries))
+	total := 0
+
+	for _, e := range entries {
+		ce := cacheEntry{Key: e.Key, Kind: "other", Size: e.Size}
+
+		if !e.Updated.IsZero() {
+			updated := e.Updated
+			ce.Updated = &updated
+		}
+
+		switch {
+		case strings.HasPrefix(e.Key, "http://") || strings.HasPrefix(e.Key, "https://"):
+			ce.Kind = "include"
+		case strings.HasPrefix(e.Key, "cache_key:"):
+			key := strings.TrimPrefix(e.Key, "cache_key:")
+			if i := strings.LastIndex(key, ":"); i != -1 {
+				ce.Kind, ce.Dir, ce.Task = "cache key", key[:i], key[i+1:]
+			}
+		}
+
+		total += e.Size
+		res = append(res, ce)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return enc.Encode(map[string]interface{}{"entries": res, "size": total})
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tSIZE\tUPDATED\tKEY")
+
+	for _, e := range res {
+		updated := "-"
+		if e.Updated != nil {
+			updated = e.Updated.Local().Format("2006-01-02 15:04:05")
+		}
+
+		key := e.Key
+		if len(e.Task) > 0 {
+			key = fmt.Sprintf("%s (%s)", e.Task, e.Dir)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", e.Kind, task.FormatSize(int64(e.Size)), updated, key)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "\n%d entries (%s), entries don't expire, use max cache flush to clear them\n", len(res), task.FormatSize(int64(total)))
+
+	return err
+}
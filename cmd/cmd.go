@@ -1,24 +1,48 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"runtime"
 	"strings"
+	"syscall"
 	"text/tabwriter"
+	"time"
 
+	"github.com/frozzare/go/env"
+	"github.com/frozzare/max/internal/cache"
 	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/exec"
+	"github.com/frozzare/max/internal/metrics"
+	"github.com/frozzare/max/internal/notify"
 	"github.com/frozzare/max/internal/runner"
 	"github.com/spf13/pflag"
 )
 
+// stdinTask is the id of tasks read from stdin with max run -.
+const stdinTask = "stdin"
+
 const usage = `
 Runs the specified task(s).
 
 Commands:
 
   cache flush           flush cache.
+  check-includes        check that all includes can be loaded.
+  completion [shell]    generate bash, zsh or fish completion script.
+  doctor                check the environment and config.
+  explain-cache         list cache entries, or json with --json.
+  fmt                   format the config file.
+  graph [task]          print task dependency graph in dot format, or json with --json.
   help [task]           show task help.
+  init                  create a starter max.yml.
+  prefetch              download all url includes into the cache.
+  run -                 run a task read from stdin.
   version               print max version.
 
 Options:
@@ -31,30 +55,147 @@ func Execute() {
 	log.SetOutput(os.Stderr)
 
 	var (
-		c           *config.Config
-		configFile  string
-		err         error
-		onceFlag    bool
-		quietFlag   bool
-		verboseFlag bool
+		benchFlag    bool
+		budget       time.Duration
+		budgetCancel bool
+		c            *config.Config
+		configFiles  []string
+		dryRun       string
+		dumpConfig   bool
+		dumpFormat   string
+		envFlag      string
+		err          error
+		explainFlag  bool
+		failDepFlag  bool
+		failFastFlag bool
+		forceFlag    bool
+		formatFlag   string
+		listDepsFlag bool
+		jsonFlag     bool
+		listJSONFlag bool
+		maxConc      int
+		memProfile   string
+		metricsURL   string
+		noCacheFlag  bool
+		noDepsFlag   bool
+		onceFlag     bool
+		parallelFlag bool
+		prefixFlag   bool
+		profile      string
+		quietFlag    bool
+		remoteCache  string
+		resources    int
+		since        string
+		showEnvFlag  bool
+		skipNoSrc    bool
+		strictTmpl   bool
+		templateFlag bool
+		timeout      time.Duration
+		trust        []string
+		varsJSON     string
+		verboseFlag  bool
+		watchFlag    bool
+		whyFlag      bool
 	)
 
 	pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ContinueOnError)
-	pflag.StringVarP(&configFile, "config", "c", "", "sets the config file")
+	pflag.BoolVar(&benchFlag, "bench", false, "reports the duration of each command in a task")
+	pflag.DurationVar(&budget, "budget", 0, "skips tasks not started within the time budget, e.g 5m")
+	pflag.BoolVar(&budgetCancel, "budget-cancel", false, "cancels running tasks when the budget is exceeded")
+	pflag.StringArrayVarP(&configFiles, "config", "c", nil, "sets the config file, multiple files are merged in order")
+	pflag.StringVar(&dryRun, "dry-run", "", "prints the tasks that would run without running them, use --dry-run=deps")
+	pflag.BoolVar(&dumpConfig, "dump-config", false, "prints the resolved config with secrets masked")
+	pflag.StringVar(&dumpFormat, "dump-format", "yaml", "sets the format used by --dump-config, yaml or json")
+	pflag.StringVar(&envFlag, "env", "", "uses variables from a environment")
+	pflag.BoolVar(&explainFlag, "explain", false, "prints the execution plan for a task without running it")
+	pflag.BoolVar(&failDepFlag, "fail-deprecated", false, "fails when running deprecated tasks")
+	pflag.BoolVar(&failFastFlag, "fail-fast", true, "stops running tasks when a task fails")
+	pflag.StringVar(&formatFlag, "format", "", "sets the config format, yaml or json. Default is detected from the file extension")
+	pflag.BoolVar(&forceFlag, "force", false, "overwrites existing files")
+	pflag.BoolVar(&jsonFlag, "json", false, "prints the graph and explain-cache commands as json")
+	pflag.BoolVar(&listDepsFlag, "list-deps", false, "prints the transitive dependencies of a task in execution order")
+	pflag.BoolVar(&listJSONFlag, "list-json", false, "prints tasks as json")
+	pflag.IntVar(&maxConc, "max-concurrency", 0, "sets the max number of tasks running at the same time with --parallel, overrides max_concurrency when lower")
+	pflag.StringVar(&memProfile, "mem-profile", "", "writes a memory profile to file")
+	pflag.StringVar(&metricsURL, "metrics-url", "", "pushes task metrics to a prometheus pushgateway")
+	pflag.BoolVar(&noCacheFlag, "no-cache", false, "downloads url includes without the cache, used with check-includes")
+	pflag.BoolVar(&noDepsFlag, "no-deps", false, "runs tasks without their dependencies")
 	pflag.BoolVarP(&onceFlag, "once", "o", false, "runs tasks once and ignore interval")
+	pflag.BoolVarP(&parallelFlag, "parallel", "p", false, "runs tasks in parallel within the resource budget")
+	pflag.BoolVar(&prefixFlag, "prefix", false, "prefixes task output lines with the task name")
+	pflag.StringVar(&profile, "profile", "", "writes a cpu profile to file")
+	pflag.StringVar(&remoteCache, "remote-cache", env.Get("MAX_REMOTE_CACHE"), "shares task cache keys with a http cache server")
+	pflag.IntVar(&resources, "resources", runtime.NumCPU(), "sets the resource budget for parallel tasks, task weights default to 1")
+	pflag.BoolVar(&showEnvFlag, "show-env", false, "prints the environment a task would run with without running it")
+	pflag.StringVar(&since, "since", "", "only runs tasks with sources changed since a git ref, e.g HEAD~1")
+	pflag.BoolVar(&skipNoSrc, "skip-no-sources", false, "skips tasks without sources when using --since")
 	pflag.BoolVarP(&quietFlag, "quiet", "q", false, "minimal logs")
+	pflag.BoolVar(&strictTmpl, "strict-templates", false, "fails on missing keys in task templates instead of rendering <no value>")
+	pflag.BoolVar(&templateFlag, "template", false, "preprocesses the config file with go text template using {% %} delimiters")
+	pflag.DurationVar(&timeout, "timeout", 0, "cancels all tasks when the run exceeds the timeout, e.g 10m. Exits with status 124")
+	pflag.StringArrayVar(&trust, "trust", nil, "runs tasks included from urls on the host, can be used many times")
+	pflag.StringVar(&varsJSON, "vars-json", "", "merges a json object into the variables, overrides config and environment variables")
 	pflag.BoolVarP(&verboseFlag, "verbose", "v", false, "verbose logs")
+	pflag.BoolVarP(&watchFlag, "watch", "w", false, "runs tasks again when the config file, included files or task sources changes")
+	pflag.BoolVar(&whyFlag, "why", false, "prints why tasks runs or are skipped by their status commands, cache key or sources")
 	pflag.Parse()
 
 	pflag.CommandLine.ParseErrorsWhitelist = pflag.ParseErrorsWhitelist{
 		UnknownFlags: true,
 	}
 
-	// Read config file if it exists.
-	c, err = readConfig(configFile)
+	// Profile config loading and the run.
+	stopProfile, err := startProfile(profile, memProfile)
 	if err != nil {
-		log.Printf("max: %s\n", err.Error())
-		return
+		log.Fatal(errorMessage(err))
+	}
+
+	defer stopProfile()
+
+	// Run doctor before the config is read so load errors are diagnosed.
+	if task, _ := taskWithArgs(); task == "doctor" {
+		if handled, ok := runDoctor(configFiles, formatFlag, templateFlag); handled {
+			if !ok {
+				stopProfile()
+				os.Exit(1)
+			}
+
+			return
+		}
+	}
+
+	// Check includes before the config is read so failed includes are reported.
+	if task, _ := taskWithArgs(); task == "check-includes" {
+		if handled, ok := runCheckIncludes(configFiles, noCacheFlag); handled {
+			if !ok {
+				stopProfile()
+				os.Exit(1)
+			}
+
+			return
+		}
+	}
+
+	// Prefetch url includes before the config is read so they are downloaded again.
+	if task, _ := taskWithArgs(); task == "prefetch" {
+		if noCacheFlag {
+			log.Fatal("max: prefetch can't be used with --no-cache")
+		}
+
+		if handled, ok := runPrefetch(configFiles); handled {
+			if !ok {
+				stopProfile()
+				os.Exit(1)
+			}
+
+			return
+		}
+	}
+
+	// Read config file if it exists, built in commands works with a empty config.
+	c, err = readConfig(configFiles, formatFlag, templateFlag)
+	if err != nil && err != config.ErrEmptyConfig {
+		log.Fatal(errorMessage(err))
 	}
 
 	pflag.Usage = func() {
@@ -65,12 +206,17 @@ func Execute() {
 			log.Printf("\n%s\n\n", "Tasks:")
 			l := 21
 			w := tabwriter.NewWriter(os.Stdout, 8, 01, 0, '\t', 0)
-			for k, t := range c.Tasks {
+			for _, k := range c.List() {
+				t := c.Tasks[k]
 				s := ""
 				for i := 0; i < l-len(k); i++ {
 					s += " "
 				}
-				fmt.Fprintf(w, "  %s:%s%s\n", k, s, t.Summary)
+				summary := t.Summary
+				if len(t.Deprecated) > 0 {
+					summary = strings.TrimSpace(fmt.Sprintf("%s (deprecated: %s)", summary, t.Deprecated))
+				}
+				fmt.Fprintf(w, "  %s:%s%s\n", k, s, summary)
 			}
 			w.Flush()
 		}
@@ -78,6 +224,22 @@ func Execute() {
 		log.Println("\nUse \"max help [task]\" for more information about that task.")
 	}
 
+	// Print tasks as json.
+	if listJSONFlag {
+		if c == nil {
+			log.Println(errorMessage(err))
+			return
+		}
+
+		buf, err := json.MarshalIndent(c.Info(), "", "  ")
+		if err != nil {
+			log.Fatalf("max: %s", err.Error())
+		}
+
+		fmt.Println(string(buf))
+		return
+	}
+
 	// Bail if help flag.
 	if strings.Contains(os.Args[len(os.Args)-1], "-help") {
 		return
@@ -87,31 +249,172 @@ func Execute() {
 	task, args := taskWithArgs()
 
 	// Run built in commands.
-	if runCommands(task, args) {
+	if runCommands(task, args, &options{config: c, force: forceFlag, json: jsonFlag}) {
 		return
 	}
 
 	// Try to read max config file if nil.
 	if c == nil {
-		c, err = readConfig(configFile)
+		c, err = readConfig(configFiles, formatFlag, templateFlag)
 		if err != nil {
-			log.Printf("max: %s\n", err.Error())
-			return
+			log.Fatal(errorMessage(err))
 		}
 	}
 
-	// Create a new runner.
-	runner := runner.New(
-		runner.Config(c),
-		runner.Once(onceFlag),
-		runner.Quiet(quietFlag),
-		runner.Verbose(verboseFlag),
-	)
+	// Run a task read from stdin with the config's args and variables.
+	var stdinFlags map[string]interface{}
+	if runStdin() && c.Tasks["run"] == nil {
+		t, err := config.ReadTask(os.Stdin)
+		if err != nil {
+			log.Fatal(errorMessage(err))
+		}
+
+		// The task name is not the first argument so --key flags are parsed here.
+		stdinFlags = flagArgs(taskArgs(task, nil))
+		task, args = stdinTask, args[1:]
+		c.AddTask(task, t)
+	}
+
+	// Flags overrides the MAX_QUIET and MAX_VERBOSE environment variables
+	// that overrides the config's quiet value.
+	quiet, verbose := c.Verbosity()
+	if !pflag.CommandLine.Changed("quiet") {
+		quietFlag = quiet
+	}
+
+	if !pflag.CommandLine.Changed("verbose") {
+		verboseFlag = verbose
+	}
+
+	// Use environment variables and variables given as json, they override
+	// environment variables. Used again when the config is reloaded.
+	useVariables := func(c *config.Config) error {
+		if len(envFlag) > 0 {
+			if err := c.UseEnvironment(envFlag); err != nil {
+				return err
+			}
+		}
+
+		if len(varsJSON) > 0 {
+			return c.UseVariablesJSON([]byte(varsJSON))
+		}
+
+		return nil
+	}
+
+	if err := useVariables(c); err != nil {
+		log.Fatal(errorMessage(err))
+	}
+
+	// Print resolved config.
+	if dumpConfig {
+		buf, err := c.Dump(dumpFormat)
+		if err != nil {
+			log.Fatal(errorMessage(err))
+		}
+
+		fmt.Print(string(buf))
+		return
+	}
+
+	// Print config warnings in verbose mode.
+	if verboseFlag {
+		warnings, err := c.Validate()
+		if err != nil {
+			log.Fatal(errorMessage(err))
+		}
+
+		for _, w := range warnings {
+			log.Printf("max: warning: %s\n", w)
+		}
+	}
+
+	// Warn when caching is disabled.
+	if err := c.DefaultStrict(); err != nil && !quietFlag {
+		log.Printf("max: caching disabled: %s\n", err.Error())
+	}
+
+	// Collect metrics if a pushgateway or a notify webhook is configured.
+	var m *metrics.Metrics
+	if len(metricsURL) > 0 || c.Notify != nil {
+		m = metrics.New()
+	}
+
+	// Share task cache keys with other machines.
+	var remote cache.Store
+	if len(remoteCache) > 0 {
+		remote = cache.NewRemote(remoteCache)
+	}
+
+	// Resolve unique task name prefixes, e.g dep for deploy. The task's
+	// arguments are found after the name as it was given.
+	given := task
+	if c.Tasks[task] == nil && task != "help" && task != stdinTask {
+		if name, err := runner.ResolveTask(c, task); err == nil {
+			task = name
+		} else if _, ok := err.(*runner.AmbiguousTaskError); ok {
+			log.Fatal(errorMessage(err))
+		}
+	}
+
+	// Parse the task's arguments as flags when it declares typed arguments.
+	flags := stdinFlags
+	if t := c.Tasks[task].Copy(); t != nil && task != "help" && task != stdinTask {
+		t.ID(task)
+
+		names := t.FlagNames(c.Args)
+
+		if flags, err = t.ParseFlags(c.Args, taskArgs(given, names)); err != nil {
+			log.Fatal(errorMessage(err))
+		}
+
+		resetFlags(given, names)
+	}
+
+	// Running tasks are cancelled with the signal as the cause when interrupted.
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	// Create a new runner, a new runner is created for each run when watching.
+	newRunner := func(c *config.Config) *runner.Runner {
+		return runner.New(
+			runner.Bench(benchFlag),
+			runner.Budget(budget),
+			runner.BudgetCancel(budgetCancel),
+			runner.Config(c),
+			runner.Context(ctx),
+			runner.FailDeprecated(failDepFlag),
+			runner.FailFast(failFastFlag),
+			runner.Flags(flags),
+			runner.MaxConcurrency(maxConc),
+			runner.Metrics(m),
+			runner.NoDeps(noDepsFlag),
+			runner.Once(onceFlag),
+			runner.Parallel(parallelFlag),
+			runner.Prefix(prefixFlag),
+			runner.Resources(resources),
+			runner.Quiet(quietFlag),
+			runner.RemoteCache(remote),
+			runner.Since(since),
+			runner.SkipNoSources(skipNoSrc),
+			runner.StrictTemplates(strictTmpl),
+			runner.Timeout(timeout),
+			runner.Trust(trust),
+			runner.Verbose(verboseFlag),
+			runner.Why(whyFlag),
+		)
+	}
+
+	r := newRunner(c)
 
 	// Output help usage if requested.
 	if task == "help" && len(args) == 1 {
 		id := args[0]
-		t := runner.Task(id)
+		if name, err := r.Resolve(id); err == nil {
+			id = name
+		}
+
+		t := r.Task(id)
 
 		if t == nil {
 			log.Fatalf("Task missing: %s", id)
@@ -122,8 +425,186 @@ func Execute() {
 		return
 	}
 
+	// Print execution plan.
+	if explainFlag {
+		if err := r.Explain(os.Stdout, task); err != nil {
+			log.Fatal(errorMessage(err))
+		}
+
+		return
+	}
+
+	// Print the tasks that would run.
+	if len(dryRun) > 0 {
+		if dryRun != "deps" {
+			log.Fatalf("max: unknown dry run mode %s, use --dry-run=deps\n", dryRun)
+		}
+
+		if err := r.DryRun(os.Stdout, targets(r, task, args)...); err != nil {
+			log.Fatal(errorMessage(err))
+		}
+
+		return
+	}
+
+	// Print task dependencies.
+	if listDepsFlag {
+		if err := r.ListDeps(os.Stdout, task); err != nil {
+			log.Fatal(errorMessage(err))
+		}
+
+		return
+	}
+
+	// Print task environment.
+	if showEnvFlag {
+		if err := r.ShowEnv(os.Stdout, task); err != nil {
+			log.Fatal(errorMessage(err))
+		}
+
+		return
+	}
+
+	// Run tasks again when the config file, included files or sources changes.
+	var w *watcher
+
+	if watchFlag {
+		if task == stdinTask {
+			log.Fatal("max: --watch can't be used with run -")
+		}
+
+		w = &watcher{
+			args:      args,
+			config:    c,
+			ctx:       ctx,
+			newRunner: newRunner,
+			quiet:     quietFlag,
+			task:      task,
+			reload: func() (*config.Config, error) {
+				c, err := readConfig(configFiles, formatFlag, templateFlag)
+				if err != nil {
+					return nil, err
+				}
+
+				if err := useVariables(c); err != nil {
+					c.Close()
+					return nil, err
+				}
+
+				return c, nil
+			},
+		}
+	}
+
+	// Cancel running tasks when interrupted, post commands are run when the
+	// tasks are done. A second signal exits without waiting.
+	sigs := make(chan os.Signal, 2)
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		s := <-sigs
+		interrupted <- s
+		cancel(fmt.Errorf("max: interrupted by %s", s))
+
+		<-sigs
+		stopProfile()
+		os.Exit(signalStatus(s))
+	}()
+
+	// Exit with the signal's status when interrupted.
+	exitInterrupted := func() {
+		select {
+		case s := <-interrupted:
+			log.Printf("max: interrupted by %s\n", s)
+			stopProfile()
+			os.Exit(signalStatus(s))
+		default:
+		}
+	}
+
+	if w != nil {
+		w.run()
+		exitInterrupted()
+		return
+	}
+
 	// Run and log error.
-	if err := runner.Run(task); err != nil {
-		log.Fatalf("max: %s", err.Error())
+	start := time.Now()
+	err = r.RunAll(targets(r, task, args)...)
+
+	// Notify webhook, failures should not fail the run.
+	if c.Notify != nil {
+		p := notify.New(m.Results(), time.Since(start), err)
+
+		if err := notify.Send(c.Notify.WebhookURL(), c.Notify.TimeoutDuration(), p); err != nil {
+			log.Printf("max: warning: can't notify webhook: %s\n", err.Error())
+		}
+	}
+
+	// Push metrics, failures should not fail the run.
+	if len(metricsURL) > 0 {
+		if err := m.Push(metricsURL, "max"); err != nil {
+			log.Printf("max: warning: can't push metrics: %s\n", err.Error())
+		}
+	}
+
+	exitInterrupted()
+
+	if err != nil {
+		printRunError(err)
+
+		stopProfile()
+		os.Exit(runner.ExitStatus(err))
 	}
 }
+
+// signalStatus returns the exit status for a signal, 128 plus the signal number.
+func signalStatus(s os.Signal) int {
+	if n, ok := s.(syscall.Signal); ok {
+		return 128 + int(n)
+	}
+
+	return 130
+}
+
+// printRunError prints a run error, command exit errors are printed by the
+// runner unless the failed command's details are printed after it.
+func printRunError(err error) {
+	var ce *exec.CommandError
+	if _, ok := err.(runner.Errors); ok || !runner.IsExitError(err) || errors.As(err, &ce) {
+		log.Println(errorMessage(err))
+	}
+
+	printCommandErrors(err)
+}
+
+// printCommandErrors prints the command, directory and shell of failed
+// commands so they can be reproduced manually, the error messages are
+// already printed.
+func printCommandErrors(err error) {
+	errs := []error{err}
+
+	if e, ok := err.(runner.Errors); ok {
+		errs = errs[:0]
+		for _, err := range e {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, err := range errs {
+		var ce *exec.CommandError
+		if errors.As(err, &ce) {
+			log.Println(ce.Details())
+		}
+	}
+}
+
+// errorMessage returns the error message prefixed with max if not already prefixed.
+func errorMessage(err error) string {
+	if msg := err.Error(); strings.HasPrefix(msg, "max: ") {
+		return msg
+	}
+
+	return "max: " + err.Error()
+}
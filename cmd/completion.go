@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// taskNames extracts task names from the indented --list-json output.
+const taskNames = `max --list-json 2>/dev/null | sed -n 's/^    "name": "\(.*\)",$/\1/p'`
+
+const commands = "cache check-includes completion doctor explain-cache fmt graph help init prefetch run version"
+
+const bashCompletion = `_max_completion() {
+  local cur="${COMP_WORDS[COMP_CWORD]}"
+
+  if [[ "$cur" == -* ]]; then
+    COMPREPLY=($(compgen -W "%s" -- "$cur"))
+    return
+  fi
+
+  COMPREPLY=($(compgen -W "%s $(%s)" -- "$cur"))
+}
+
+complete -F _max_completion max
+`
+
+const zshCompletion = `#compdef max
+
+_max() {
+  if [[ $PREFIX == -* ]]; then
+    compadd -- %s
+  else
+    compadd -- %s ${(f)"$(%s)"}
+  fi
+}
+
+compdef _max max
+`
+
+const fishCompletion = `function __max_tasks
+  %s
+end
+
+complete -c max -f -a '%s (__max_tasks)'
+%s`
+
+func completion(shell string) (string, error) {
+	var flags []string
+	var fish string
+
+	pflag.CommandLine.VisitAll(func(f *pflag.Flag) {
+		flags = append(flags, "--"+f.Name)
+
+		if len(f.Shorthand) > 0 {
+			flags = append(flags, "-"+f.Shorthand)
+			fish += fmt.Sprintf("complete -c max -l %s -s %s -d '%s'\n", f.Name, f.Shorthand, f.Usage)
+		} else {
+			fish += fmt.Sprintf("complete -c max -l %s -d '%s'\n", f.Name, f.Usage)
+		}
+	})
+
+	switch shell {
+	case "bash":
+		return fmt.Sprintf(bashCompletion, strings.Join(flags, " "), commands, taskNames), nil
+	case "zsh":
+		return fmt.Sprintf(zshCompletion, strings.Join(flags, " "), commands, taskNames), nil
+	case "fish":
+		return fmt.Sprintf(fishCompletion, taskNames, commands, fish), nil
+	default:
+		return "", fmt.Errorf("max: unsupported shell %s, use bash, zsh or fish", shell)
+	}
+}
@@ -8,27 +8,43 @@ import (
 	"github.com/pkg/errors"
 )
 
-func readConfig(path string) (*config.Config, error) {
+// readConfig reads the config from stdin or the config files, multiple
+// files are merged in order. Stdin is not read when running a task from
+// stdin with max run -.
+func readConfig(paths []string, format string, preprocess bool) (*config.Config, error) {
 	var c *config.Config
 	var err error
+	var data map[string]interface{}
 
-	fi, err := os.Stdin.Stat()
-	if fi.Mode()&os.ModeNamedPipe != 0 {
-		buf, err := ioutil.ReadAll(os.Stdin)
+	if preprocess {
+		data = config.PreprocessData(flagArgs(os.Args[1:]))
+	}
 
-		if err == nil {
-			c, err = config.ReadContent(string(buf))
+	path := ""
+	if len(paths) > 0 {
+		path = paths[0]
+	}
 
-			if err != nil {
-				return nil, errors.Wrap(err, "max")
+	fi, err := os.Stdin.Stat()
+	if fi.Mode()&os.ModeNamedPipe != 0 && !runStdin() {
+		var buf []byte
+		if buf, err = ioutil.ReadAll(os.Stdin); err == nil {
+			if preprocess {
+				c, err = config.ReadContentTemplate(string(buf), format, data)
+			} else {
+				c, err = config.ReadContentFormat(string(buf), format)
 			}
 		}
+	} else if len(paths) > 1 {
+		c, err = config.ReadFiles(format, data, paths...)
+	} else if preprocess {
+		c, err = config.ReadFileTemplate(format, data, path)
 	} else {
-		c, err = config.ReadFile(path)
+		c, err = config.ReadFileFormat(format, path)
 	}
 
 	if err != nil {
-		return nil, errors.Wrap(err, "max")
+		return nil, err
 	}
 
 	if c == nil {
@@ -37,3 +53,9 @@ func readConfig(path string) (*config.Config, error) {
 
 	return c, nil
 }
+
+// runStdin reports whether a task should be read from stdin, e.g max run -.
+func runStdin() bool {
+	task, args := taskWithArgs()
+	return task == "run" && len(args) > 0 && args[0] == "-"
+}
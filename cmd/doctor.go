@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/doctor"
+	"github.com/mitchellh/go-homedir"
+)
+
+// runDoctor prints a checklist of the environment and config and returns
+// false when the config has a doctor task that should run instead.
+func runDoctor(paths []string, format string, preprocess bool) (handled bool, ok bool) {
+	var c *config.Config
+	var err error
+
+	// A single config file is read leniently so each failed include is reported.
+	if len(paths) > 1 || preprocess {
+		c, err = readConfig(paths, format, preprocess)
+	} else {
+		var path string
+		if len(paths) > 0 {
+			path = paths[0]
+		}
+
+		c, err = config.ReadFileOptions(path, config.Lenient(), config.WithFormat(format))
+	}
+
+	if c != nil && c.Tasks["doctor"] != nil {
+		// Release the cache so the config can be read again.
+		if cache := c.Cache(); cache != nil {
+			cache.Close()
+		}
+
+		return false, true
+	}
+
+	var checks []doctor.Check
+
+	if c != nil && len(c.CacheDir()) > 0 {
+		checks = append(checks, doctor.CacheDir(c.CacheDir()))
+	} else if dir, err := homedir.Dir(); err == nil {
+		checks = append(checks, doctor.CacheDir(filepath.Join(dir, ".max")))
+	} else {
+		checks = append(checks, doctor.Check{Name: "home directory is found", Err: err, Hint: "set HOME to a writable directory"})
+	}
+
+	checks = append(checks, doctor.Config(c, err)...)
+	checks = append(checks, doctor.Binaries(c)...)
+	checks = append(checks, doctor.Docker(c)...)
+
+	return true, doctor.Print(os.Stdout, checks)
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/task"
+)
+
+// runCheckIncludes loads the config files and prints the status of every
+// include and returns false when the config has a check-includes task that
+// should run instead.
+func runCheckIncludes(paths []string, noCache bool) (handled bool, ok bool) {
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+
+	opts := []config.ReadOption{config.Lenient()}
+	if noCache {
+		opts = append(opts, config.NoCache())
+	}
+
+	var includes []*config.Include
+
+	for _, path := range paths {
+		c, err := config.ReadFileOptions(path, opts...)
+
+		if c != nil && c.Tasks["check-includes"] != nil {
+			// Release the cache so the config can be read again.
+			if cache := c.Cache(); cache != nil {
+				cache.Close()
+			}
+
+			return false, true
+		}
+
+		if c == nil {
+			fmt.Printf("[fail] %s: %s\n", path, errorMessage(err))
+			return true, false
+		}
+
+		if cache := c.Cache(); cache != nil {
+			cache.Close()
+		}
+
+		includes = append(includes, c.Includes()...)
+	}
+
+	failed := 0
+
+	for _, inc := range includes {
+		if inc.Err != nil {
+			failed++
+			fmt.Printf("[fail] %s: %s: %s\n", inc.Key, inc.Ref, inc.Err)
+		} else {
+			fmt.Printf("[ok]   %s: %s\n", inc.Key, inc.Ref)
+		}
+	}
+
+	fmt.Printf("\n%d includes, %d failed\n", len(includes), failed)
+
+	return true, failed == 0
+}
+
+// runPrefetch loads the config files, downloads every url include into the
+// cache and prints the fetched urls and the cache size. It returns false
+// when the config has a prefetch task that should run instead.
+func runPrefetch(paths []string) (handled bool, ok bool) {
+	if len(paths) == 0 {
+		paths = []string{""}
+	}
+
+	var fetched []string
+	var failed []*config.Include
+	var values int
+	var size int64
+
+	for _, path := range paths {
+		c, err := config.ReadFileOptions(path, config.Lenient(), config.Refresh())
+
+		if c != nil && c.Tasks["prefetch"] != nil {
+			// Release the cache so the config can be read again.
+			if cache := c.Cache(); cache != nil {
+				cache.Close()
+			}
+
+			return false, true
+		}
+
+		if c == nil {
+			fmt.Printf("[fail] %s: %s\n", path, errorMessage(err))
+			return true, false
+		}
+
+		if c.Cache() == nil {
+			fmt.Println("[fail] max: can't prefetch includes without a cache")
+			return true, false
+		}
+
+		values, size, err = c.Cache().Size()
+		c.Cache().Close()
+
+		if err != nil {
+			fmt.Printf("[fail] max: can't read cache size: %s\n", err)
+			return true, false
+		}
+
+		fetched = append(fetched, c.Fetched()...)
+
+		for _, inc := range c.Includes() {
+			if inc.Err != nil {
+				failed = append(failed, inc)
+			}
+		}
+	}
+
+	for _, url := range fetched {
+		fmt.Printf("[ok]   %s\n", url)
+	}
+
+	for _, inc := range failed {
+		fmt.Printf("[fail] %s: %s: %s\n", inc.Key, inc.Ref, inc.Err)
+	}
+
+	fmt.Printf("\n%d urls fetched, %d failed, cache contains %d values (%s)\n", len(fetched), len(failed), values, task.FormatSize(size))
+
+	return true, len(failed) == 0
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+)
+
+const initFile = "max.yml"
+
+const initTemplate = `# Max task file, run "max help" to list tasks.
+
+# Global arguments that all tasks can use, e.g "max hello --name max".
+args:
+  name: world
+
+# Environment variables that all tasks can use.
+variables:
+  GREETING: Hello
+
+tasks:
+  # The default task runs when no task is given.
+  default:
+    summary: Default task
+    tasks:
+      - hello
+
+  hello:
+    summary: Say hello
+    usage: "[--name]"
+    commands:
+      - echo $GREETING {{ .name }}
+
+  build:
+    summary: Build the project
+    deps: [hello]
+    commands:
+      - echo Building
+`
+
+func initConfig(force bool) error {
+	if _, err := os.Stat(initFile); err == nil && !force {
+		return errors.New("max: max.yml already exists, use --force to overwrite")
+	}
+
+	return ioutil.WriteFile(initFile, []byte(initTemplate), 0644)
+}
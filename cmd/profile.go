@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"log"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+)
+
+// startProfile starts cpu profiling when a cpu profile file is given and returns
+// a function that stops it and writes the memory profile if a file is given.
+func startProfile(cpu, mem string) (func(), error) {
+	var f *os.File
+
+	if len(cpu) > 0 {
+		var err error
+
+		f, err = os.Create(cpu)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			if f != nil {
+				pprof.StopCPUProfile()
+				f.Close()
+			}
+
+			if len(mem) > 0 {
+				writeMemProfile(mem)
+			}
+		})
+	}, nil
+}
+
+func writeMemProfile(path string) {
+	f, err := os.Create(path)
+	if err != nil {
+		log.Printf("max: warning: can't write memory profile: %s\n", err.Error())
+		return
+	}
+
+	defer f.Close()
+
+	runtime.GC()
+
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		log.Printf("max: warning: can't write memory profile: %s\n", err.Error())
+	}
+}
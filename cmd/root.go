@@ -1,9 +1,13 @@
 package cmd
 
 import (
+	"fmt"
 	"log"
+	"os"
 
+	"github.com/frozzare/max/internal/cache"
 	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/graph"
 	"github.com/spf13/pflag"
 )
 
@@ -12,19 +16,126 @@ var (
 	Version = "master"
 )
 
-func runCommands(cmd string, args []string) bool {
+// options represents options for built in commands.
+type options struct {
+	config *config.Config
+	force  bool
+	json   bool
+}
+
+func runCommands(cmd string, args []string, opts *options) bool {
+	// Tasks takes precedence over commands other than cache, help and version.
+	if opts.config != nil && opts.config.Tasks[cmd] != nil {
+		switch cmd {
+		case "cache", "help", "version":
+		default:
+			return false
+		}
+	}
+
 	switch cmd {
 	case "cache":
 		if len(args) == 0 || args[0] != "flush" {
 			return false
 		}
 
-		if cache, err := config.CreateCache(); err == nil {
+		// Flush the config's cache so project scoped caches are flushed.
+		if opts.config != nil && opts.config.Cache() != nil {
+			opts.config.Cache().Flush()
+		} else if cache, err := config.CreateCache(); err == nil {
 			cache.Flush()
 		}
 
 		log.Println("max: cache flushed")
 
+		return true
+	case "completion":
+		if len(args) == 0 {
+			return false
+		}
+
+		script, err := completion(args[0])
+		if err != nil {
+			log.Fatal(errorMessage(err))
+		}
+
+		fmt.Print(script)
+
+		return true
+	case "graph":
+		if opts.config == nil {
+			return false
+		}
+
+		g := graph.New(opts.config)
+
+		if len(args) > 0 {
+			sub, err := g.Subgraph(args[0])
+			if err != nil {
+				log.Fatal(errorMessage(err))
+			}
+			g = sub
+		}
+
+		if opts.json {
+			buf, err := g.JSON()
+			if err != nil {
+				log.Fatal(errorMessage(err))
+			}
+
+			fmt.Println(string(buf))
+
+			return true
+		}
+
+		dot, err := g.DOT()
+		if err != nil {
+			log.Fatal(errorMessage(err))
+		}
+
+		fmt.Print(dot)
+
+		return true
+	case "explain-cache":
+		// Explain the config's cache so project scoped caches are explained.
+		var c *cache.Cache
+		if opts.config != nil {
+			c = opts.config.Cache()
+		}
+
+		if c == nil {
+			var err error
+			if c, err = config.CreateCache(); err != nil {
+				log.Fatal(errorMessage(err))
+			}
+
+			defer c.Close()
+		}
+
+		if err := explainCache(os.Stdout, c, opts.json); err != nil {
+			log.Fatal(errorMessage(err))
+		}
+
+		return true
+	case "fmt":
+		if opts.config == nil {
+			return false
+		}
+
+		path := opts.config.SourcePath()
+		if len(path) == 0 {
+			log.Fatal("max: no config file to format")
+		}
+
+		changed, err := config.FormatFile(path)
+		if err != nil {
+			log.Fatal(errorMessage(err))
+		}
+
+		if changed {
+			log.Printf("max: formatted %s\n", path)
+		}
+
 		return true
 	case "help":
 		if len(args) > 0 {
@@ -32,6 +143,14 @@ func runCommands(cmd string, args []string) bool {
 		}
 
 		pflag.Usage()
+		return true
+	case "init":
+		if err := initConfig(opts.force); err != nil {
+			log.Fatal(errorMessage(err))
+		}
+
+		log.Printf("max: created %s\n", initFile)
+
 		return true
 	case "version":
 		log.Printf("max version %s\n", Version)
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/runner"
+)
+
+// watchInterval is how often watched files are checked for changes.
+const watchInterval = 500 * time.Millisecond
+
+// fileStamp is the modification time and size of a watched file, missing
+// files have a zero stamp so they are watched until they are created.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// watcher runs tasks again when the config file, the local files tasks are
+// included from or the sources of the tasks changes. The config is read again
+// when the config file or a included file changes and the last good config is
+// kept when the config can't be read.
+type watcher struct {
+	args      []string
+	config    *config.Config
+	ctx       context.Context
+	newRunner func(*config.Config) *runner.Runner
+	quiet     bool
+	reload    func() (*config.Config, error)
+	task      string
+}
+
+// run runs the tasks and runs them again when the watched files changes
+// until the context is done.
+func (w *watcher) run() {
+	for {
+		r := w.newRunner(w.config)
+
+		ids := targets(r, w.task, w.args)
+		err := r.RunAll(ids...)
+
+		if w.ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			printRunError(err)
+		}
+
+		stamps := w.stamps(r, ids)
+		if !w.quiet {
+			log.Printf("Watching %d files for changes\n", len(stamps))
+		}
+
+		changed := w.wait(r, ids, stamps)
+		if changed == nil {
+			return
+		}
+
+		if !w.quiet {
+			log.Printf("Files changed: %s\n", strings.Join(changed, ", "))
+		}
+
+		if w.configChanged(changed) {
+			w.reloadConfig()
+		}
+	}
+}
+
+// wait waits until the watched files are changed, created or removed and
+// returns the changed files, nil when the context is done.
+func (w *watcher) wait(r *runner.Runner, ids []string, old map[string]fileStamp) []string {
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil
+		case <-time.After(watchInterval):
+		}
+
+		if changed := changedStamps(old, w.stamps(r, ids)); len(changed) > 0 {
+			return changed
+		}
+	}
+}
+
+// stamps returns the stamps of the files to watch. The config files are
+// watched even if the sources can't be found.
+func (w *watcher) stamps(r *runner.Runner, ids []string) map[string]fileStamp {
+	files, err := r.WatchFiles(ids...)
+	if err != nil {
+		log.Printf("max: warning: can't watch sources: %s\n", strings.TrimPrefix(err.Error(), "max: "))
+		files = w.config.Files()
+	}
+
+	res := make(map[string]fileStamp, len(files))
+
+	for _, path := range files {
+		var s fileStamp
+		if fi, err := os.Stat(path); err == nil {
+			s = fileStamp{modTime: fi.ModTime(), size: fi.Size()}
+		}
+
+		res[path] = s
+	}
+
+	return res
+}
+
+// configChanged reports whether any of the changed files is the config file
+// or a file tasks are included from.
+func (w *watcher) configChanged(changed []string) bool {
+	for _, path := range w.config.Files() {
+		for _, c := range changed {
+			if c == path {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// reloadConfig reads the config again, the last good config is kept and the
+// error is logged when the config can't be read. The cache of the last good
+// config is opened again and tasks runs without cache when it can't be.
+func (w *watcher) reloadConfig() {
+	// The cache can only be opened once so it's closed before it's read.
+	w.config.Close()
+
+	c, err := w.reload()
+	if err != nil {
+		log.Printf("max: can't reload config, using the last good config: %s\n", strings.TrimPrefix(err.Error(), "max: "))
+
+		if err := w.config.DefaultStrict(); err != nil {
+			log.Printf("max: warning: can't open the cache again, tasks runs without cache: %s\n", strings.TrimPrefix(err.Error(), "max: "))
+		}
+
+		return
+	}
+
+	w.config = c
+
+	if !w.quiet {
+		log.Printf("Reloaded config %s\n", color.GreenString(c.SourcePath()))
+	}
+}
+
+// changedStamps returns the files that are changed, created or removed in
+// sorted order.
+func changedStamps(old, stamps map[string]fileStamp) []string {
+	var res []string
+
+	for path, s := range stamps {
+		if o, ok := old[path]; !ok || !o.modTime.Equal(s.modTime) || o.size != s.size {
+			res = append(res, path)
+		}
+	}
+
+	for path := range old {
+		if _, ok := stamps[path]; !ok {
+			res = append(res, path)
+		}
+	}
+
+	sort.Strings(res)
+
+	return res
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"io"
 	"log"
+	"time"
 
 	"github.com/frozzare/go/yaml2"
 )
@@ -20,6 +21,7 @@ type Backend struct {
 	Stdin   io.Reader
 	Stdout  io.Writer
 	Stderr  io.Writer
+	Timing  func(command string, d time.Duration)
 	Verbose bool
 }
 
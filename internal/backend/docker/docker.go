@@ -100,6 +100,14 @@ func (e *engine) Exec(ctx context.Context, t *task.Task) error {
 
 	var cmds []string
 
+	if t.Strict {
+		cmds = append(cmds, "set -e -u -o pipefail")
+	}
+
+	if len(t.Script) > 0 {
+		cmds = append(cmds, t.Script)
+	}
+
 	for _, c := range t.Commands.Values {
 		if e.config.Verbose {
 			cmds = append(cmds, fmt.Sprintf("echo $ %s && %s", c, c))
@@ -114,7 +122,7 @@ func (e *engine) Exec(ctx context.Context, t *task.Task) error {
 		Tty:          true,
 		AttachStdout: true,
 		AttachStderr: true,
-		Env:          toEnv(t.Variables),
+		Env:          toEnv(t.Env()),
 		Volumes:      toVolumes(t.Docker.Volumes.Values),
 		WorkingDir:   t.Docker.WorkingDir,
 		Image:        t.Docker.Image,
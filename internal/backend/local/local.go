@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/frozzare/max/internal/backend"
 	"github.com/frozzare/max/internal/backend/config"
@@ -33,29 +36,141 @@ func (e *engine) Setup(ctx context.Context, t *task.Task) error {
 
 // Exec executes a task.
 func (e *engine) Exec(ctx context.Context, t *task.Task) error {
+	stdout, stderr := e.config.Stdout, e.config.Stderr
+
+	// Run commands in a pseudo-terminal so they behave like in a interactive shell.
+	if t.TTY {
+		p, err := exec.NewPTY(e.config.Stdout)
+		if err != nil {
+			log.Printf("max: warning: can't allocate a pseudo-terminal for task %s: %s\n", t.ID(), err)
+		} else {
+			defer p.Close()
+			stdout, stderr = p.File(), p.File()
+		}
+	}
+
+	// Lower or raise the priority of the commands where supported.
+	if t.Nice != 0 && !exec.SupportsNice() {
+		log.Printf("max: warning: can't set nice for task %s, process priorities are not supported on this platform\n", t.ID())
+	}
+
+	script := t.Script
+
+	// Run commands in a shared shell session as a script.
+	if t.Session && len(t.Commands.Values) > 0 {
+		script = strings.Join(t.Commands.Values, "\n")
+	}
+
+	// Chained commands runs in a single shell and stops at the first failure.
+	if t.Mode == task.ModeChain && len(t.Commands.Values) > 0 {
+		script = strings.Join(t.Commands.Values, " && ")
+	}
+
+	// Run script in a single shell so state persists between lines.
+	if len(script) > 0 {
+		if e.config.Verbose {
+			log.Print(fmt.Sprintf("$ %s", script))
+		}
+
+		command := script
+		if t.Strict {
+			command = exec.Strict(t.Shell, command)
+		} else if exec.IsPOSIX(t.Shell) {
+			command = "set -e\n" + command
+		}
+
+		start := time.Now()
+		err := exec.Exec(&exec.Options{
+			Context:  ctx,
+			CleanEnv: t.CleanEnvEnabled(),
+			Dir:      t.Dir,
+			Env:      toEnv(t.Env()),
+			Command:  command,
+			Nice:     t.Nice,
+			Shell:    t.Shell,
+			Stdin:    e.config.Stdin,
+			Stdout:   stdout,
+			Stderr:   stderr,
+		})
+		e.timing(script, start)
+
+		if err != nil {
+			return commandError(t, script, err)
+		}
+
+		return nil
+	}
+
+	var failed error
+
 	for _, c := range t.Commands.Values {
 		if e.config.Verbose {
 			log.Print(fmt.Sprintf("$ %s", c))
 		}
 
+		command := c
+		if t.Strict {
+			command = exec.Strict(t.Shell, command)
+		}
+
 		opts := &exec.Options{
-			Context: ctx,
-			Dir:     t.Dir,
-			Env:     toEnv(t.Variables),
-			Command: c,
-			Stdin:   e.config.Stdin,
-			Stdout:  e.config.Stdout,
-			Stderr:  e.config.Stderr,
+			Context:  ctx,
+			CleanEnv: t.CleanEnvEnabled(),
+			Dir:      t.Dir,
+			Env:      toEnv(t.Env()),
+			Command:  command,
+			Nice:     t.Nice,
+			Shell:    t.Shell,
+			Stdin:    e.config.Stdin,
+			Stdout:   stdout,
+			Stderr:   stderr,
 		}
 
 		// Execute command.
-		if err := exec.Exec(opts); err != nil {
-			log.Print(c)
-			return err
+		start := time.Now()
+		err := exec.Exec(opts)
+		e.timing(c, start)
+
+		if err == nil {
+			continue
+		}
+
+		// Separate commands runs even if earlier commands fails.
+		if t.Mode != task.ModeSeparate {
+			return commandError(t, c, err)
+		}
+
+		if failed == nil {
+			failed = commandError(t, c, err)
+		} else {
+			log.Printf("max: %s\n", commandError(t, c, err))
 		}
 	}
 
-	return nil
+	return failed
+}
+
+// commandError returns a error with the failed command, with secrets
+// masked, and the directory and shell it ran in.
+func commandError(t *task.Task, command string, err error) error {
+	dir := t.Dir
+	if len(dir) == 0 {
+		dir, _ = os.Getwd()
+	}
+
+	return &exec.CommandError{
+		Command: t.MaskSecrets(command),
+		Dir:     dir,
+		Shell:   t.Shell,
+		Err:     err,
+	}
+}
+
+// timing reports the duration of a command when timing is configured.
+func (e *engine) timing(command string, start time.Time) {
+	if e.config.Timing != nil {
+		e.config.Timing(command, time.Since(start))
+	}
 }
 
 // Logs returns logs from the local engine.
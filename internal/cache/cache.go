@@ -1,9 +1,11 @@
 package cache
 
 import (
+	"encoding/binary"
 	"errors"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -18,10 +20,19 @@ const (
 type Cache struct {
 	bucket []byte
 	db     *bolt.DB
+	meta   []byte
 
 	sync.Mutex
 }
 
+// Entry represents a cached value, Updated is zero for values stored
+// before update times were recorded.
+type Entry struct {
+	Key     string
+	Size    int
+	Updated time.Time
+}
+
 // New creates a new cache.
 func New(dir string) (*Cache, error) {
 	if err := os.MkdirAll(dir, 0750); err != nil {
@@ -37,6 +48,7 @@ func New(dir string) (*Cache, error) {
 	return &Cache{
 		bucket: []byte("cache"),
 		db:     db,
+		meta:   []byte("meta"),
 	}, nil
 }
 
@@ -57,16 +69,66 @@ func (c *Cache) Delete(key string) error {
 			return bolt.ErrBucketNotFound
 		}
 
+		if m := tx.Bucket(c.meta); m != nil {
+			if err := m.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+
 		return b.Delete([]byte(key))
 	})
 }
 
+// Entries returns the cached values sorted by key.
+func (c *Cache) Entries() ([]Entry, error) {
+	var entries []Entry
+
+	c.Lock()
+	defer c.Unlock()
+
+	err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(c.bucket)
+
+		if b == nil {
+			return nil
+		}
+
+		m := tx.Bucket(c.meta)
+
+		return b.ForEach(func(k, v []byte) error {
+			e := Entry{Key: string(k), Size: len(v)}
+
+			if m != nil {
+				if t := m.Get(k); len(t) == 8 {
+					e.Updated = time.Unix(0, int64(binary.BigEndian.Uint64(t)))
+				}
+			}
+
+			entries = append(entries, e)
+
+			return nil
+		})
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	return entries, err
+}
+
 // Flush flushes the cache.
 func (c *Cache) Flush() error {
 	c.Lock()
 	defer c.Unlock()
 
 	return c.db.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket(c.meta) != nil {
+			if err := tx.DeleteBucket(c.meta); err != nil {
+				return err
+			}
+		}
+
 		return tx.DeleteBucket([]byte(c.bucket))
 	})
 }
@@ -103,6 +165,31 @@ func (c *Cache) Get(key string) ([]byte, error) {
 	return val, nil
 }
 
+// Size returns the number of cached values and their size in bytes.
+func (c *Cache) Size() (int, int64, error) {
+	var n int
+	var size int64
+
+	c.Lock()
+	defer c.Unlock()
+
+	err := c.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(c.bucket)
+
+		if b == nil {
+			return nil
+		}
+
+		return b.ForEach(func(k, v []byte) error {
+			n++
+			size += int64(len(k) + len(v))
+			return nil
+		})
+	})
+
+	return n, size, err
+}
+
 // Set sets a value and return a error if any.
 func (c *Cache) Set(key string, value []byte) error {
 	c.Lock()
@@ -114,6 +201,19 @@ func (c *Cache) Set(key string, value []byte) error {
 			return err
 		}
 
+		// Record when the value was stored.
+		m, err := tx.CreateBucketIfNotExists(c.meta)
+		if err != nil {
+			return err
+		}
+
+		t := make([]byte, 8)
+		binary.BigEndian.PutUint64(t, uint64(time.Now().UnixNano()))
+
+		if err := m.Put([]byte(key), t); err != nil {
+			return err
+		}
+
 		return b.Put([]byte(key), value)
 	})
 }
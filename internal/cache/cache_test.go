@@ -2,6 +2,7 @@ package cache
 
 import (
 	"testing"
+	"time"
 )
 
 func TestCache(t *testing.T) {
@@ -23,6 +24,26 @@ func TestCache(t *testing.T) {
 		t.Fatal("Expected test value to be test")
 	}
 
+	entries, err := c.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, e := range entries {
+		if e.Key == "test" {
+			found = e.Size == 4 && time.Since(e.Updated) < time.Minute
+		}
+	}
+
+	if !found {
+		t.Fatalf("Expected: test entry with size and update time, got: %v", entries)
+	}
+
+	if n, size, err := c.Size(); err != nil || n < 1 || size < 8 {
+		t.Fatalf("Expected: at least 1 value of 8 bytes, got: %d values of %d bytes, %v", n, size, err)
+	}
+
 	if err := c.Delete("test"); err != nil {
 		t.Fatal(err)
 	}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Remote is a cache stored on a http cache server. Values are read with
+// GET, written with PUT and deleted with DELETE requests to <url>/<key>.
+type Remote struct {
+	client *http.Client
+	url    string
+}
+
+// NewRemote creates a new remote cache.
+func NewRemote(url string) *Remote {
+	return &Remote{
+		client: &http.Client{Timeout: 10 * time.Second},
+		url:    strings.TrimRight(url, "/"),
+	}
+}
+
+func (r *Remote) do(method, key string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, r.url+"/"+url.PathEscape(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("max: bad status code %d from remote cache", res.StatusCode)
+	}
+
+	return ioutil.ReadAll(res.Body)
+}
+
+// Delete deletes a cache value.
+func (r *Remote) Delete(key string) error {
+	_, err := r.do(http.MethodDelete, key, nil)
+	return err
+}
+
+// Get gets a value from the cache and returns it or a error.
+func (r *Remote) Get(key string) ([]byte, error) {
+	buf, err := r.do(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(buf) == 0 {
+		return nil, fmt.Errorf("empty cache value")
+	}
+
+	return buf, nil
+}
+
+// Set sets a value and return a error if any.
+func (r *Remote) Set(key string, value []byte) error {
+	_, err := r.do(http.MethodPut, key, value)
+	return err
+}
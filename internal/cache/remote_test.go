@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestRemote(t *testing.T) {
+	var mu sync.Mutex
+	values := make(map[string][]byte)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch r.Method {
+		case http.MethodGet:
+			v, ok := values[r.URL.EscapedPath()]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Write(v)
+		case http.MethodPut:
+			values[r.URL.EscapedPath()], _ = ioutil.ReadAll(r.Body)
+		case http.MethodDelete:
+			delete(values, r.URL.EscapedPath())
+		}
+	}))
+
+	defer server.Close()
+
+	var c Store = NewRemote(server.URL + "/")
+
+	if err := c.Set("test/key", []byte("test")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := values["/test%2Fkey"]; !ok {
+		t.Errorf("Expected: escaped key, got: %v", values)
+	}
+
+	v, err := c.Get("test/key")
+	if err != nil || string(v) != "test" {
+		t.Fatalf("Expected: test, got: %s, %v", v, err)
+	}
+
+	if err := c.Delete("test/key"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.Get("test/key"); err == nil {
+		t.Error("Expected: error, got: nil")
+	}
+}
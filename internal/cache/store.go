@@ -0,0 +1,11 @@
+package cache
+
+// Store represents a cache backend, e.g the local cache or a remote cache.
+type Store interface {
+	Delete(key string) error
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte) error
+}
+
+// Cache is a Store.
+var _ Store = (*Cache)(nil)
@@ -0,0 +1,245 @@
+package config
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// bundleFile is the task file loaded from a bundle.
+const bundleFile = "max.yml"
+
+// isBundle reports whether a include reference is a tar.gz or zip bundle.
+func isBundle(ref string) bool {
+	ref, _ = splitChecksum(ref)
+	ref = strings.ToLower(ref)
+
+	for _, ext := range []string{".tar.gz", ".tgz", ".zip"} {
+		if strings.HasSuffix(ref, ext) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitChecksum splits a bundle reference into the reference and
+// the optional sha256 checksum, e.g tasks.tar.gz#sha256=<hex>.
+func splitChecksum(ref string) (string, string) {
+	i := strings.LastIndex(ref, "#sha256=")
+	if i == -1 {
+		return ref, ""
+	}
+
+	return ref[:i], strings.ToLower(ref[i+len("#sha256="):])
+}
+
+// bundleDir returns the directory bundles are extracted to.
+func bundleDir() string {
+	if dir, err := homedir.Dir(); err == nil {
+		return filepath.Join(dir, ".max", "bundles")
+	}
+
+	return filepath.Join(os.TempDir(), "max-bundles")
+}
+
+// includeBundle downloads or reads a bundle, extracts it and returns the
+// path to the bundle's task file. Bundles are extracted once per content checksum.
+func (l *loader) includeBundle(ref string) (string, error) {
+	ref, sum := splitChecksum(ref)
+
+	verify := func(buf []byte) error {
+		return verifyChecksum(ref, sum, buf)
+	}
+
+	var buf []byte
+	var err error
+
+	if isHTTP(ref) {
+		buf, err = l.download(ref, verify)
+
+		// Download the bundle again if the cached bundle has changed.
+		if err == nil && verify(buf) != nil && l.cache != nil {
+			l.cache.Delete(ref)
+			buf, err = l.download(ref, verify)
+		}
+	} else {
+		buf, err = ioutil.ReadFile(ref)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := verify(buf); err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(bundleDir(), checksum(buf))
+	file := filepath.Join(dir, bundleFile)
+
+	if _, err := os.Stat(file); err == nil {
+		return file, nil
+	}
+
+	tmp := dir + ".tmp"
+	os.RemoveAll(tmp)
+
+	if strings.HasSuffix(strings.ToLower(ref), ".zip") {
+		err = extractZip(buf, tmp)
+	} else {
+		err = extractTarGz(buf, tmp)
+	}
+
+	if err != nil {
+		os.RemoveAll(tmp)
+		return "", fmt.Errorf("max: can't extract bundle %s: %s", ref, err)
+	}
+
+	if err := os.Rename(tmp, dir); err != nil && !os.IsExist(err) {
+		os.RemoveAll(tmp)
+
+		// Another process may have extracted the same bundle.
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		}
+
+		return "", err
+	}
+
+	return file, nil
+}
+
+// verifyChecksum verifies the content of a reference against the sha256
+// checksum, empty checksums are not verified.
+func verifyChecksum(ref, sum string, buf []byte) error {
+	if len(sum) == 0 {
+		return nil
+	}
+
+	if got := checksum(buf); got != sum {
+		return fmt.Errorf("max: checksum mismatch for %s, expected %s, got %s", ref, sum, got)
+	}
+
+	return nil
+}
+
+func checksum(buf []byte) string {
+	sum := sha256.Sum256(buf)
+	return hex.EncodeToString(sum[:])
+}
+
+// extractPath returns the path to extract a archive entry to and errors
+// for entries outside the directory.
+func extractPath(dir, name string) (string, error) {
+	path := filepath.Join(dir, name)
+
+	if path != dir && !strings.HasPrefix(path, dir+string(os.PathSeparator)) {
+		return "", fmt.Errorf("bad path %s", name)
+	}
+
+	return path, nil
+}
+
+func extractFile(path string, mode os.FileMode, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode.Perm()|0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func extractTarGz(buf []byte, dir string) error {
+	gr, err := gzip.NewReader(bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		path, err := extractPath(dir, h.Name)
+		if err != nil {
+			return err
+		}
+
+		switch h.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := extractFile(path, os.FileMode(h.Mode), tr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func extractZip(buf []byte, dir string) error {
+	zr, err := zip.NewReader(bytes.NewReader(buf), int64(len(buf)))
+	if err != nil {
+		return err
+	}
+
+	for _, f := range zr.File {
+		path, err := extractPath(dir, f.Name)
+		if err != nil {
+			return err
+		}
+
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+
+		err = extractFile(path, f.Mode(), rc)
+		rc.Close()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package config
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+var bundleFiles = map[string]string{
+	"max.yml":           "tasks/deploy.yml",
+	"tasks/deploy.yml":  "summary: Deploy task\ndir: ../scripts\ncommands:\n  - ./deploy.sh\n",
+	"scripts/deploy.sh": "#!/bin/sh\necho deploy\n",
+}
+
+func tarGzBundle(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+
+		tw.Write([]byte(content))
+	}
+
+	tw.Close()
+	gw.Close()
+
+	return buf.Bytes()
+}
+
+func zipBundle(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+
+	zw := zip.NewWriter(&buf)
+
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		w.Write([]byte(content))
+	}
+
+	zw.Close()
+
+	return buf.Bytes()
+}
+
+func bundleHome(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	home := os.Getenv("HOME")
+	homedir.DisableCache = true
+	os.Setenv("HOME", dir)
+
+	return func() {
+		os.Setenv("HOME", home)
+		homedir.DisableCache = false
+		os.RemoveAll(dir)
+	}
+}
+
+func TestIncludeBundle(t *testing.T) {
+	defer bundleHome(t)()
+
+	tgz := tarGzBundle(t, bundleFiles)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(tgz)
+	}))
+
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	zipFile := filepath.Join(dir, "tasks.zip")
+	if err := ioutil.WriteFile(zipFile, zipBundle(t, bundleFiles), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l := &loader{}
+
+	for _, ref := range []string{server.URL + "/tasks.tar.gz#sha256=" + checksum(tgz), zipFile} {
+		task, err := l.includeTask(ref, "")
+		if err != nil {
+			t.Fatalf("Expected: nil, got: %s", err)
+		}
+
+		if task.Summary != "Deploy task" {
+			t.Errorf("Expected: 'Deploy task', got: %s", task.Summary)
+		}
+
+		script := filepath.Join(task.Dir, "deploy.sh")
+		if buf, err := ioutil.ReadFile(script); err != nil || !strings.Contains(string(buf), "echo deploy") {
+			t.Errorf("Expected: extracted script, got: %v", err)
+		}
+	}
+
+	if _, err := l.includeTask(server.URL+"/tasks.tar.gz#sha256=bad", ""); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Expected: checksum mismatch error, got: %v", err)
+	}
+}
+
+func TestIncludeBundleBadPath(t *testing.T) {
+	defer bundleHome(t)()
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "tasks.tgz")
+	if err := ioutil.WriteFile(file, tarGzBundle(t, map[string]string{"../max.yml": "summary: Bad"}), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := (&loader{}).includeTask(file, ""); err == nil {
+		t.Error("Expected: error, got: nil")
+	}
+}
@@ -1,14 +1,18 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
 
+	"github.com/frozzare/go/yaml2"
 	"github.com/frozzare/max/internal/cache"
 	"github.com/frozzare/max/internal/task"
 	"github.com/mitchellh/go-homedir"
@@ -20,40 +24,210 @@ var (
 	ErrUnmarshal = errors.New("max: can't unmarshal config value")
 	// ErrCreateCache is returned when cache can't be created.
 	ErrCreateCache = errors.New("max: can't create cache")
+	// ErrEmptyConfig is returned when the config is empty or has no tasks.
+	ErrEmptyConfig = errors.New("max: config is empty, add tasks to the config file")
 )
 
 // Config represents a config file.
 type Config struct {
-	cache     *cache.Cache
-	Args      map[string]interface{}
-	Tasks     map[string]*task.Task
-	Variables map[string]string
-	Version   string
+	cache          *cache.Cache
+	cacheDir       string
+	client         *http.Client
+	errs           IncludeErrors
+	fetched        []string
+	files          []string
+	includes       []*Include
+	lenient        bool
+	order          []string
+	path           string
+	refresh        bool
+	set            map[string]bool
+	Args           map[string]interface{}
+	CacheScope     string
+	CleanEnv       bool
+	Environments   map[string]*Environment
+	MaxConcurrency int
+	MaxOutput      string
+	Notify         *Notify
+	OnFailure      yaml2.List
+	Quiet          bool
+	Tasks          map[string]*task.Task
+	Variables      map[string]interface{}
+	Version        string
 }
 
 type base struct {
-	Args      map[string]interface{}
-	Tasks     map[string]interface{}
-	Quiet     bool
-	Variables map[string]string
-	Version   string
+	Args           map[string]interface{}
+	CacheScope     string            `yaml:"cache_scope"`
+	CleanEnv       *bool             `yaml:"clean_env"`
+	DefaultDir     string            `yaml:"default_dir"`
+	DefaultEnv     map[string]string `yaml:"default_env"`
+	DefaultShell   string            `yaml:"default_shell"`
+	DefaultTimeout string            `yaml:"default_timeout"`
+	Environments   map[string]*Environment
+	HTTPHeaders    map[string]interface{} `yaml:"http_headers"`
+	MaxConcurrency int                    `yaml:"max_concurrency"`
+	MaxOutput      string                 `yaml:"max_output"`
+	Notify         *Notify
+	OnFailure      yaml2.List `yaml:"on_failure"`
+	Snippets       map[string]yaml2.List
+	Tasks          yaml.MapSlice
+	Quiet          *bool
+	Variables      map[string]interface{}
+	Version        string
 }
 
-// CreateCache creates a new cache.
+// CacheError is returned when the cache can't be created in a directory.
+type CacheError struct {
+	Dir string
+	Err error
+}
+
+// Error returns the error message with the attempted directory.
+func (e *CacheError) Error() string {
+	return fmt.Sprintf("%s in %s: %s", ErrCreateCache, e.Dir, e.Err)
+}
+
+// Unwrap returns the error the cache couldn't be created with.
+func (e *CacheError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the target is ErrCreateCache so cache errors can be
+// matched with errors.Is.
+func (e *CacheError) Is(target error) bool {
+	return target == ErrCreateCache
+}
+
+// IncludeError is returned when a task can't be included.
+type IncludeError struct {
+	Key string
+	Ref string
+	Err error
+}
+
+// Error returns the error message with the task key and include reference.
+func (e *IncludeError) Error() string {
+	return fmt.Sprintf("%s: can't include %s: %s", e.Key, e.Ref, e.Err)
+}
+
+// Include represents a attempted include of a task, Err is nil when
+// the task was included.
+type Include struct {
+	Key string
+	Ref string
+	Err error
+}
+
+// Fetched returns the urls that were downloaded when the config was read,
+// urls read from the cache are not included.
+func (c *Config) Fetched() []string {
+	return c.fetched
+}
+
+// Files returns the absolute paths of the config file and the local files
+// tasks were included from, including missing files that were skipped.
+func (c *Config) Files() []string {
+	return c.files
+}
+
+// Includes returns all attempted includes in declaration order, missing
+// local files that are skipped when loading are included with their error.
+func (c *Config) Includes() []*Include {
+	return c.includes
+}
+
+// IncludeErrors contains all include errors collected in lenient mode.
+type IncludeErrors []*IncludeError
+
+// Error returns all include error messages.
+func (e IncludeErrors) Error() string {
+	msgs := make([]string, len(e))
+
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+
+	return fmt.Sprintf("max: failed includes (%s)", strings.Join(msgs, "; "))
+}
+
+// CreateCache creates a new cache in the global ~/.max directory.
 func CreateCache() (*cache.Cache, error) {
 	dir, err := homedir.Dir()
 	if err != nil {
-		return nil, err
+		return nil, &CacheError{Dir: "~/.max", Err: err}
+	}
+
+	return CreateCacheDir(filepath.Join(dir, ".max"))
+}
+
+// CreateCacheDir creates a new cache in the given directory.
+func CreateCacheDir(dir string) (*cache.Cache, error) {
+	c, err := cache.New(dir)
+	if err != nil {
+		return nil, &CacheError{Dir: dir, Err: err}
+	}
+
+	return c, nil
+}
+
+// SourcePath returns the absolute path of the file the config was read
+// from, empty when the config was read from content or no file was found.
+func (c *Config) SourcePath() string {
+	return c.path
+}
+
+// CacheDir returns the project cache directory, empty when the global
+// cache is used.
+func (c *Config) CacheDir() string {
+	return c.cacheDir
+}
+
+// Cache returns the config cache, nil if the cache can't be created.
+func (c *Config) Cache() *cache.Cache {
+	return c.cache
+}
+
+// Close closes the config cache, e.g before the config file is read again
+// since the cache can only be opened once. DefaultStrict opens it again.
+func (c *Config) Close() error {
+	if c.cache == nil {
+		return nil
 	}
 
-	return cache.New(filepath.Join(dir, ".max"))
+	err := c.cache.Close()
+	c.cache = nil
+
+	return err
 }
 
 // Default set default values to config struct.
 func (c *Config) Default() {
-	if cache, err := CreateCache(); err == nil {
-		c.cache = cache
+	c.DefaultStrict()
+}
+
+// DefaultStrict set default values to config struct and returns
+// a CacheError if the cache can't be created.
+func (c *Config) DefaultStrict() error {
+	if c.cache != nil {
+		return nil
+	}
+
+	create := CreateCache
+	if len(c.cacheDir) > 0 {
+		create = func() (*cache.Cache, error) {
+			return CreateCacheDir(c.cacheDir)
+		}
+	}
+
+	cache, err := create()
+	if err != nil {
+		return err
 	}
+
+	c.cache = cache
+
+	return nil
 }
 
 // UnmarshalYAML implements yaml packages interface to unmarshal custom values.
@@ -62,39 +236,126 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 	if err := unmarshal(&b); err == nil {
 		c.Args = b.Args
+		c.CacheScope = b.CacheScope
+		c.CleanEnv = b.CleanEnv != nil && *b.CleanEnv
+		c.Environments = b.Environments
+		c.MaxConcurrency = b.MaxConcurrency
+		c.MaxOutput = b.MaxOutput
+		c.Notify = b.Notify
+		c.OnFailure = b.OnFailure
+		c.Quiet = b.Quiet != nil && *b.Quiet
 		c.Tasks = make(map[string]*task.Task)
 		c.Variables = b.Variables
 		c.Version = b.Version
 
+		// Keep track of bools that are set so merged configs can set them to false.
+		c.set = map[string]bool{"clean_env": b.CleanEnv != nil, "quiet": b.Quiet != nil}
+
 		if c.Variables == nil {
-			c.Variables = make(map[string]string)
+			c.Variables = make(map[string]interface{})
+		}
+
+		if err := c.validateUnits(); err != nil {
+			return err
+		}
+
+		if err := task.ValidateDuration("default_timeout", b.DefaultTimeout); err != nil {
+			return err
+		}
+
+		data, err := task.TemplateData(c.Args, c.Variables)
+		if err != nil {
+			return err
+		}
+
+		headers, err := renderHeaders(b.HTTPHeaders, data)
+		if err != nil {
+			return err
+		}
+
+		l := &loader{cache: c.cache, client: c.client, headers: headers, refresh: c.refresh, snippets: b.Snippets}
+		l.fetch = func(url string) {
+			for _, u := range c.fetched {
+				if u == url {
+					return
+				}
+			}
+
+			c.fetched = append(c.fetched, url)
+		}
+
+		l.read = func(path string) {
+			for _, f := range c.files {
+				if f == path {
+					return
+				}
+			}
+
+			c.files = append(c.files, path)
 		}
 
 		// Loop over tasks to include and convert existing maps to tasks.
-		for k, v := range b.Tasks {
-			switch r := v.(type) {
-			case string:
-				if strings.Contains(r, "http") {
-					t, err := includeHTTPTask(r, c.cache)
-					if err != nil {
-						return ErrUnmarshal
+		for _, item := range b.Tasks {
+			k := fmt.Sprintf("%v", item.Key)
+			c.order = append(c.order, k)
+
+			// Include tasks from string references and !include or !http tags.
+			if kind, ref, args, ok := tagRef(item.Value); ok {
+				// Refs are templates, e.g !include "deploy-{{ .env }}.yml".
+				rendered, err := renderRef(ref, c.Args, c.Variables, args)
+				if err != nil {
+					if c.lenient {
+						c.errs = append(c.errs, &IncludeError{Key: k, Ref: ref, Err: err})
+						continue
 					}
 
-					c.Tasks[k] = t
-				} else if content, err := ioutil.ReadFile(r); err == nil {
-					var t *task.Task
-					if err := yaml.Unmarshal([]byte(content), &t); err == nil {
-						c.Tasks[k] = t
-					} else {
-						return ErrUnmarshal
+					return &IncludeError{Key: k, Ref: ref, Err: err}
+				}
+
+				ref = rendered
+
+				// Relative files are included relative to the config file.
+				t, err := l.include(kind, ref, c.path, args)
+				c.includes = append(c.includes, &Include{Key: k, Ref: ref, Err: err})
+
+				if err != nil {
+					// Missing local files are skipped.
+					if kind != refHTTP && (kind == refFile || !isHTTP(ref)) && os.IsNotExist(err) {
+						continue
+					}
+
+					// Collect include errors and keep loading in lenient mode.
+					if c.lenient {
+						c.errs = append(c.errs, &IncludeError{Key: k, Ref: ref, Err: err})
+						continue
 					}
+
+					// Include cycles are reported with the include chain.
+					if _, ok := err.(*IncludeCycleError); ok {
+						return &IncludeError{Key: k, Ref: ref, Err: err}
+					}
+
+					return ErrUnmarshal
 				}
-			case map[interface{}]interface{}:
+
+				c.Tasks[k] = t
+				continue
+			}
+
+			switch r := item.Value.(type) {
+			case yaml.MapSlice, map[interface{}]interface{}:
 				var t *task.Task
 
+				if err := expandSnippets(k, r, b.Snippets); err != nil {
+					return err
+				}
+
 				if buf, err := yaml.Marshal(r); err == nil {
 					if err := yaml.Unmarshal(buf, &t); err == nil {
 						c.Tasks[k] = t
+					} else if e, ok := err.(*task.UnitError); ok {
+						e.Task = k
+						return e
 					} else {
 						return ErrUnmarshal
 					}
@@ -104,74 +365,240 @@ func (c *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 			}
 		}
 
+		if err := c.resolveExtends(); err != nil {
+			return err
+		}
+
+		b.applyDefaults(c.Tasks)
+
 		return nil
 	}
 
 	return ErrUnmarshal
 }
 
-// ReadContent creates a new config struct from a string.
-func ReadContent(content string) (*Config, error) {
-	config := &Config{}
-	config.Default()
+// validateUnits validates the config's durations and sizes.
+func (c *Config) validateUnits() error {
+	if err := task.ValidateSize("max_output", c.MaxOutput); err != nil {
+		return err
+	}
+
+	if c.MaxConcurrency < 0 {
+		return fmt.Errorf("max: max_concurrency can't be negative, got %d", c.MaxConcurrency)
+	}
+
+	if c.Notify != nil {
+		return task.ValidateDuration("notify timeout", c.Notify.Timeout)
+	}
+
+	return nil
+}
+
+// ReadContent creates a new config struct from a string configured with read options.
+func ReadContent(content string, opts ...ReadOption) (*Config, error) {
+	return readContent([]byte(content), newReadOptions(opts))
+}
+
+// ReadContentLenient creates a new config struct from a string. All includes
+// are attempted and the tasks that could be loaded are returned together
+// with IncludeErrors for the includes that failed.
+func ReadContentLenient(content string) (*Config, error) {
+	return readContent([]byte(content), readOptions{lenient: true})
+}
+
+func readContent(content []byte, opts readOptions) (*Config, error) {
+	if err := checkEmpty(content); err != nil {
+		return nil, err
+	}
+
+	config := &Config{cache: opts.cache, client: opts.client, lenient: opts.lenient, path: opts.path, refresh: opts.refresh}
+
+	dir, err := cacheDir(content, opts.path)
+	if err != nil {
+		return nil, err
+	}
+
+	config.cacheDir = dir
+
+	if len(opts.path) > 0 {
+		config.files = []string{opts.path}
+	}
+
+	if !opts.noCache {
+		config.Default()
+	}
+
+	if err := yaml.Unmarshal(rewriteTags(content), &config); err != nil {
+		// Close the cache opened for the config so it can be opened again.
+		if opts.cache == nil {
+			config.Close()
+		}
 
-	if err := yaml.Unmarshal([]byte(content), &config); err != nil {
 		return nil, err
 	}
 
+	if len(config.errs) > 0 {
+		return config, config.errs
+	}
+
 	return config, nil
 }
 
-// ReadFile creates a new config struct from a yaml file.
-func ReadFile(args ...string) (*Config, error) {
-	var file string
-	var path string
-	var err error
+// cacheDir returns the cache directory for the config's cache scope. The
+// global scope returns a empty directory so the default ~/.max is used and
+// the project scope uses a .max directory next to the config file, or in
+// the working directory when the config wasn't read from a file.
+func cacheDir(content []byte, path string) (string, error) {
+	var v struct {
+		CacheScope string `yaml:"cache_scope"`
+	}
 
-	if len(args) > 0 && args[0] != "" {
-		path = args[0]
+	if err := yaml.Unmarshal(rewriteTags(content), &v); err != nil {
+		return "", nil
+	}
+
+	switch v.CacheScope {
+	case "", "global":
+		return "", nil
+	case "project":
+		if len(path) > 0 {
+			return filepath.Join(filepath.Dir(path), ".max"), nil
+		}
+
+		dir, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+
+		return filepath.Join(dir, ".max"), nil
+	default:
+		return "", fmt.Errorf("max: unknown cache_scope %s, use global or project", v.CacheScope)
+	}
+}
+
+// checkEmpty returns ErrEmptyConfig when the content is a empty document
+// or when the tasks key has no tasks.
+func checkEmpty(content []byte) error {
+	if len(bytes.TrimSpace(content)) == 0 {
+		return ErrEmptyConfig
 	}
 
-	var dat []byte
+	var v interface{}
 
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		path, err = os.Getwd()
+	if err := yaml.Unmarshal(content, &v); err != nil {
+		return nil
+	}
+
+	if v == nil {
+		return ErrEmptyConfig
+	}
 
-		files := []string{fmt.Sprintf("max_%s.yml", runtime.GOOS), "max.yml"}
-		if len(file) > 0 {
-			files = append([]string{file}, files...)
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil
+	}
+
+	if tasks, ok := m["tasks"]; ok {
+		if t, ok := tasks.(map[interface{}]interface{}); tasks == nil || (ok && len(t) == 0) {
+			return ErrEmptyConfig
 		}
+	}
 
-		for _, name := range files {
-			if len(dat) > 0 {
-				break
-			}
+	return nil
+}
 
-			file := filepath.Join(path, name)
+// ReadReader creates a new config struct from a reader, e.g a embedded config file.
+func ReadReader(r io.Reader) (*Config, error) {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
 
-			if _, err := os.Stat(file); err == nil {
-				dat, err = ioutil.ReadFile(file)
+	return ReadContent(string(buf))
+}
 
-				if err == nil {
-					break
-				}
-			}
+// ReadFile creates a new config struct from a yaml file. When no path
+// is given and the MAX_CONFIG environment variable is set its content is used instead.
+// The config can be read from a key in a larger file, e.g project.yml#max.
+func ReadFile(args ...string) (*Config, error) {
+	return readFile(readOptions{}, args...)
+}
+
+// ReadFileLenient creates a new config struct from a yaml file like ReadFile
+// but collects include errors like ReadContentLenient.
+func ReadFileLenient(args ...string) (*Config, error) {
+	return readFile(readOptions{lenient: true}, args...)
+}
+
+func readFile(opts readOptions, args ...string) (*Config, error) {
+	var path string
+	var err error
+
+	if len(args) > 0 && args[0] != "" {
+		path, opts.key = splitKey(args[0])
+
+		if path, err = task.ExpandPath(path); err != nil {
+			return nil, err
 		}
+	} else if content := os.Getenv("MAX_CONFIG"); len(content) > 0 {
+		return readFormat([]byte(content), opts)
+	}
+
+	// Built in commands works without a config file.
+	if path = findFile(path); len(path) == 0 {
+		config := &Config{cache: opts.cache, client: opts.client, lenient: opts.lenient}
 
-	} else {
-		dat, err = ioutil.ReadFile(path)
+		if !opts.noCache {
+			config.Default()
+		}
+
+		return config, nil
 	}
 
+	dat, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	config := &Config{}
-	config.Default()
-
-	if err := yaml.Unmarshal(dat, &config); err != nil {
+	if opts.path, err = filepath.Abs(path); err != nil {
 		return nil, err
 	}
 
-	return config, nil
+	if len(opts.format) == 0 {
+		opts.format = detectFormat(path)
+	}
+
+	return readFormat(dat, opts)
+}
+
+// findFile returns the config file to read. When the path don't exists max
+// looks for default config files in the working directory and its parents.
+func findFile(path string) string {
+	if _, err := os.Stat(path); err == nil || !os.IsNotExist(err) {
+		return path
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	files := []string{fmt.Sprintf("max_%s.yml", runtime.GOOS), "max.yml"}
+
+	for {
+		for _, name := range files {
+			file := filepath.Join(dir, name)
+
+			if _, err := os.Stat(file); err == nil {
+				return file
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+
+		dir = parent
+	}
 }
@@ -1,8 +1,14 @@
 package config
 
 import (
+	"errors"
 	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
+
+	"github.com/mitchellh/go-homedir"
 )
 
 func TestReadContent(t *testing.T) {
@@ -50,3 +56,282 @@ func TestReadFile(t *testing.T) {
 		t.Errorf("Expected: 'Hello task', got: %v", c.Tasks["Hello"])
 	}
 }
+
+func TestDefaultStrict(t *testing.T) {
+	home := os.Getenv("HOME")
+	homedir.DisableCache = true
+
+	defer func() {
+		os.Setenv("HOME", home)
+		homedir.DisableCache = false
+	}()
+
+	os.Setenv("HOME", "/dev/null")
+
+	c := &Config{}
+	err := c.DefaultStrict()
+
+	if err == nil {
+		t.Fatal("Expected: error, got: nil")
+	}
+
+	e, ok := err.(*CacheError)
+	if !ok || e.Dir != filepath.Join("/dev/null", ".max") {
+		t.Fatalf("Expected: cache error with dir, got: %v", err)
+	}
+
+	if !errors.Is(err, e.Err) || errors.Unwrap(err) != e.Err {
+		t.Errorf("Expected: unwrapped error %v, got: %v", e.Err, errors.Unwrap(err))
+	}
+
+	if !errors.Is(err, ErrCreateCache) {
+		t.Errorf("Expected: %v, got: %v", ErrCreateCache, err)
+	}
+}
+
+func TestReadFileParentDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "max.yml"), []byte("tasks:\n  hello:\n    summary: Hello task\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := filepath.Join(dir, "a", "b")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+
+	os.Chdir(sub)
+
+	c, err := ReadFile()
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %v", err)
+	}
+
+	if c.Tasks["hello"] == nil {
+		t.Errorf("Expected: task, got: %v", c.Tasks["hello"])
+	}
+}
+
+func TestReadFileParentDirEmpty(t *testing.T) {
+	defer disableCache()()
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "max.yml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+
+	os.Chdir(dir)
+
+	if _, err := ReadFile(); err != ErrEmptyConfig {
+		t.Errorf("Expected: ErrEmptyConfig, got: %v", err)
+	}
+}
+
+func TestReadFileEnv(t *testing.T) {
+	os.Setenv("MAX_CONFIG", "tasks:\n  env:\n    summary: Env task\n")
+	defer os.Unsetenv("MAX_CONFIG")
+
+	c, err := ReadFile()
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %v", err)
+	}
+
+	if c.Tasks["env"] == nil || c.Tasks["env"].Summary != "Env task" {
+		t.Errorf("Expected: 'Env task', got: %v", c.Tasks["env"])
+	}
+
+	c, err = ReadFile("./config.yml")
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %v", err)
+	}
+
+	if c.Tasks["env"] != nil {
+		t.Errorf("Expected: nil, got: %v", c.Tasks["env"])
+	}
+}
+
+func TestReadContentEmpty(t *testing.T) {
+	defer disableCache()()
+
+	for _, content := range []string{"", "  \n\t\n", "# comment\n", "tasks:\n", "tasks: {}\n", "args:\n  name: max\ntasks:\n"} {
+		if _, err := ReadContent(content); err != ErrEmptyConfig {
+			t.Errorf("Expected: ErrEmptyConfig for %q, got: %v", content, err)
+		}
+	}
+
+	if _, err := ReadContent("variables:\n  ENV: prod\n"); err != nil {
+		t.Errorf("Expected: nil, got: %v", err)
+	}
+}
+
+func TestReadFileEmpty(t *testing.T) {
+	defer disableCache()()
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "max.yml")
+	ioutil.WriteFile(path, []byte("\n  \n"), 0644)
+
+	if _, err := ReadFile(path); err != ErrEmptyConfig {
+		t.Errorf("Expected: ErrEmptyConfig, got: %v", err)
+	}
+}
+
+func TestReadFileSourcePath(t *testing.T) {
+	defer disableCache()()
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "max.yml")
+	ioutil.WriteFile(path, []byte("tasks:\n  hello: tasks/hello.yml\n"), 0644)
+	os.Mkdir(filepath.Join(dir, "tasks"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "tasks", "hello.yml"), []byte("summary: Hello task\n"), 0644)
+
+	c, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.SourcePath() != path {
+		t.Errorf("Expected: %s, got: %s", path, c.SourcePath())
+	}
+
+	if c.Tasks["hello"] == nil || c.Tasks["hello"].Summary != "Hello task" {
+		t.Errorf("Expected: task included relative to the config file, got: %v", c.Tasks["hello"])
+	}
+
+	c, err = ReadContent("tasks:\n  hello:\n    summary: Hello task\n")
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.SourcePath() != "" {
+		t.Errorf("Expected: empty path, got: %s", c.SourcePath())
+	}
+}
+
+func TestReadFileCacheScope(t *testing.T) {
+	defer disableCache()()
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "max.yml")
+	ioutil.WriteFile(path, []byte("cache_scope: project\ntasks:\n  hello:\n    summary: Hello task\n"), 0644)
+
+	c, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.Cache() == nil {
+		t.Fatal("Expected: project cache, got: nil")
+	}
+
+	defer c.Cache().Close()
+
+	if c.CacheScope != "project" {
+		t.Errorf("Expected: project, got: %s", c.CacheScope)
+	}
+
+	if c.CacheDir() != filepath.Join(dir, ".max") {
+		t.Errorf("Expected: %s, got: %s", filepath.Join(dir, ".max"), c.CacheDir())
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".max", "cache.db")); err != nil {
+		t.Errorf("Expected: cache next to the config file, got: %s", err)
+	}
+
+	if err := c.Cache().Set("hello", []byte("world")); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if v, err := c.Cache().Get("hello"); err != nil || string(v) != "world" {
+		t.Errorf("Expected: world, got: %s", v)
+	}
+
+	if _, err := ReadContent("cache_scope: machine\ntasks:\n  hello:\n    summary: Hello task\n"); err == nil {
+		t.Error("Expected: error for unknown cache scope, got: nil")
+	}
+}
+
+func TestReadContentUnits(t *testing.T) {
+	defer disableCache()()
+
+	_, err := ReadContent("tasks:\n  build:\n    retry_delay: 5 bananas\n    commands:\n      - echo build\n")
+	if err == nil || err.Error() != `max: task build: bad retry_delay "5 bananas", use a duration like 30s, 5m, 1h or 2d` {
+		t.Errorf("Expected: unit error, got: %v", err)
+	}
+
+	_, err = ReadContent("max_output: 10 bananas\ntasks:\n  build:\n    commands:\n      - echo build\n")
+	if err == nil || err.Error() != `max: bad max_output "10 bananas", use a size like 512KB or 10MB` {
+		t.Errorf("Expected: unit error, got: %v", err)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "max.yml")
+	ioutil.WriteFile(path, []byte("cache_scope: project\ntasks:\n  build: build.yml\n  test: !include missing.yml\n"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "build.yml"), []byte("commands:\n  - echo build\n"), 0644)
+
+	c, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	exp := []string{path, filepath.Join(dir, "build.yml"), filepath.Join(dir, "missing.yml")}
+
+	if !reflect.DeepEqual(c.Files(), exp) {
+		t.Errorf("Expected: %v, got: %v", exp, c.Files())
+	}
+
+	// The cache can be opened again after it's closed.
+	if err := c.Close(); err != nil || c.Cache() != nil {
+		t.Fatalf("Expected: closed cache, got: %v", err)
+	}
+
+	if err := c.DefaultStrict(); err != nil || c.Cache() == nil {
+		t.Fatalf("Expected: cache, got: %v", err)
+	}
+
+	c.Close()
+}
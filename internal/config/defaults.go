@@ -0,0 +1,37 @@
+package config
+
+import "github.com/frozzare/max/internal/task"
+
+// applyDefaults sets the config's default dir, shell and timeout on tasks
+// without their own value and adds the default env variables that the
+// tasks don't set. Defaults are applied after extends so tasks inherits
+// values from the tasks they extend first.
+func (b *base) applyDefaults(tasks map[string]*task.Task) {
+	for _, t := range tasks {
+		if len(t.Dir) == 0 {
+			t.Dir = b.DefaultDir
+		}
+
+		if len(t.Shell) == 0 {
+			t.Shell = b.DefaultShell
+		}
+
+		if len(t.Timeout) == 0 {
+			t.Timeout = b.DefaultTimeout
+		}
+
+		if len(b.DefaultEnv) == 0 {
+			continue
+		}
+
+		if t.Variables == nil {
+			t.Variables = make(map[string]interface{}, len(b.DefaultEnv))
+		}
+
+		for k, v := range b.DefaultEnv {
+			if _, ok := t.Variables[k]; !ok {
+				t.Variables[k] = v
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package config
+
+import "testing"
+
+func TestDefaults(t *testing.T) {
+	defer disableCache()()
+
+	c, err := ReadContent(`
+default_dir: /tmp
+default_env:
+  REGION: eu
+  STAGE: dev
+default_shell: bash
+default_timeout: 10m
+tasks:
+  build:
+    commands:
+      - go build
+  deploy:
+    dir: /srv
+    shell: sh
+    timeout: 1h
+    variables:
+      STAGE: prod
+  deploy-staging:
+    extends: deploy
+`, NoCache())
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	build := c.Tasks["build"]
+	if build.Dir != "/tmp" || build.Shell != "bash" || build.Timeout != "10m" || build.Variables["REGION"] != "eu" {
+		t.Errorf("Expected: default dir, shell, timeout and env, got: %+v", build)
+	}
+
+	for _, id := range []string{"deploy", "deploy-staging"} {
+		deploy := c.Tasks[id]
+		if deploy.Dir != "/srv" || deploy.Shell != "sh" || deploy.Timeout != "1h" {
+			t.Errorf("Expected: %s's own dir, shell and timeout, got: %+v", id, deploy)
+		}
+
+		if deploy.Variables["STAGE"] != "prod" || deploy.Variables["REGION"] != "eu" {
+			t.Errorf("Expected: %s's own variables with default env, got: %v", id, deploy.Variables)
+		}
+	}
+
+	if _, err := ReadContent("default_timeout: soon\ntasks:\n  build:\n    summary: Build\n", NoCache()); err == nil {
+		t.Error("Expected: bad default_timeout error, got: nil")
+	}
+}
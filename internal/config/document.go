@@ -0,0 +1,336 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// markerRegexp matches document start and end markers, e.g "---" or "...".
+var markerRegexp = regexp.MustCompile(`^(---|\.\.\.)(\s+#.*)?\s*$`)
+
+// keyRegexp matches block mapping lines, e.g "  summary: Build # comment".
+var keyRegexp = regexp.MustCompile(`^(\s*)("[^"]*"|'[^']*'|[^\s"'#:-][^:#]*?|-[^\s:#][^:#]*?)\s*:(\s.*)?$`)
+
+// Document represents a config file that can be modified while comments
+// and formatting are preserved, e.g by tools that tweaks configs. The
+// document is parsed with the same parser as Format so only block mappings
+// can be read and modified, lists, block scalars and flow mappings, e.g
+// {dir: app}, are values that are kept as is. Tags are kept as part of the
+// value, comments are kept with the key they are written above or after.
+// Document markers, e.g "---", are kept but only single documents can be
+// read. Values that continues on the next lines are read as one value.
+type Document struct {
+	lines []string
+}
+
+// docLine represents a mapping line in a document, raw is the key as it is
+// written, e.g with quotes.
+type docLine struct {
+	index  int
+	indent int
+	key    string
+	raw    string
+	value  string
+}
+
+// ParseDocument parses a yaml config document.
+func ParseDocument(content []byte) (*Document, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(rewriteTags(content), &v); err != nil {
+		return nil, err
+	}
+
+	if _, ok := v.(map[interface{}]interface{}); !ok && v != nil {
+		return nil, errors.New("max: can't parse config, the config is not a mapping")
+	}
+
+	d := &Document{lines: strings.Split(string(content), "\n")}
+	if _, err := d.nodes(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// ReadDocument reads a yaml config document from a file.
+func ReadDocument(path string) (*Document, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseDocument(buf)
+}
+
+// Bytes returns the document content.
+func (d *Document) Bytes() []byte {
+	return []byte(strings.Join(d.lines, "\n"))
+}
+
+// WriteFile writes the document to a file.
+func (d *Document) WriteFile(path string) error {
+	return ioutil.WriteFile(path, d.Bytes(), 0644)
+}
+
+// Get returns the scalar value of a dotted key, e.g tasks.build.summary,
+// false if the key don't exist.
+func (d *Document) Get(key string) (string, bool) {
+	l, _, ok, err := d.find(strings.Split(key, "."))
+	if err != nil || !ok {
+		return "", false
+	}
+
+	v, err := d.decode(l)
+	if err != nil || v == nil {
+		value, _ := splitComment(l.value)
+		return value, true
+	}
+
+	return fmt.Sprintf("%v", v), true
+}
+
+// Set sets the scalar value of a dotted key, e.g tasks.build.summary. The
+// value of a existing key is replaced and its comment is kept, missing
+// keys are added at the end of their parent mapping.
+func (d *Document) Set(key string, value string) error {
+	lines := append([]string{}, d.lines...)
+
+	if err := d.set(key, value); err != nil {
+		return err
+	}
+
+	// Keep the document unchanged if the modification is not valid yaml.
+	var v interface{}
+	if err := yaml.Unmarshal(rewriteTags(d.Bytes()), &v); err != nil {
+		d.lines = lines
+		return fmt.Errorf("max: can't set %s: %s", key, err)
+	}
+
+	return nil
+}
+
+func (d *Document) set(key string, value string) error {
+	buf, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	scalar := strings.TrimSuffix(string(buf), "\n")
+	if strings.Contains(scalar, "\n") {
+		return fmt.Errorf("max: can't set %s to a multi-line value", key)
+	}
+
+	segments := strings.Split(key, ".")
+	l, n, ok, err := d.find(segments)
+	if err != nil {
+		return err
+	}
+
+	if ok {
+		if !d.scalar(l) {
+			return fmt.Errorf("max: config key %s is not a scalar", key)
+		}
+
+		// Values that continues on the next lines are replaced with one line.
+		_, comment := splitComment(l.value)
+		line := fmt.Sprintf("%s%s: %s%s", strings.Repeat(" ", l.indent), quoteKey(l.key), scalar, comment)
+		d.lines = append(d.lines[:l.index], append([]string{line}, d.lines[l.end:]...)...)
+
+		return nil
+	}
+
+	// Add the missing keys after the last line of the deepest existing mapping.
+	indent, at := 0, len(d.lines)
+	if n > 0 {
+		if v, _ := splitComment(l.value); len(l.body) > 0 || (len(l.children) == 0 && len(v) > 0) {
+			return fmt.Errorf("max: config key %s is not a mapping", strings.Join(segments[:n], "."))
+		}
+
+		indent, at = l.indent+2, l.end
+		if len(l.children) > 0 {
+			indent = l.children[0].indent
+		}
+	}
+
+	var lines []string
+
+	for i, segment := range segments[n:] {
+		line := fmt.Sprintf("%s%s:", strings.Repeat(" ", indent), quoteKey(segment))
+
+		if i == len(segments[n:])-1 {
+			line += " " + scalar
+		}
+
+		lines = append(lines, line)
+		indent += 2
+	}
+
+	// Keys are added before trailing comments but after the start marker.
+	for at > 0 && isBlank(d.lines[at-1]) && !strings.HasPrefix(d.lines[at-1], "---") {
+		at--
+	}
+
+	d.lines = append(d.lines[:at], append(lines, d.lines[at:]...)...)
+
+	return nil
+}
+
+// nodes parses the mapping keys of the document.
+func (d *Document) nodes() ([]*fmtNode, error) {
+	nodes, _, err := parseDocument(d.lines)
+	return nodes, err
+}
+
+// decode decodes the value of a mapping key. The key's block is decoded so
+// values that continues on the next lines are read as one value.
+func (d *Document) decode(n *fmtNode) (interface{}, error) {
+	prefix := strings.Repeat(" ", n.indent)
+
+	var lines []string
+	for _, l := range d.lines[n.index:n.end] {
+		lines = append(lines, strings.TrimPrefix(l, prefix))
+	}
+
+	var v map[interface{}]interface{}
+	if err := yaml.Unmarshal([]byte(strings.Join(lines, "\n")), &v); err != nil {
+		return nil, err
+	}
+
+	for _, value := range v {
+		return value, nil
+	}
+
+	return nil, nil
+}
+
+// scalar reports whether a mapping key has a scalar value. Keys that can't
+// be decoded on their own, e.g aliases, are scalars when they have no block.
+func (d *Document) scalar(n *fmtNode) bool {
+	v, err := d.decode(n)
+	if err != nil {
+		return len(n.children) == 0 && len(n.body) == 0
+	}
+
+	switch v.(type) {
+	case map[interface{}]interface{}, []interface{}:
+		return false
+	}
+
+	return true
+}
+
+// find finds the mapping key of a key path. When the key don't exist the
+// deepest existing mapping key and the number of found segments are
+// returned.
+func (d *Document) find(segments []string) (*fmtNode, int, bool, error) {
+	nodes, err := d.nodes()
+	if err != nil {
+		return nil, 0, false, err
+	}
+
+	var parent *fmtNode
+
+	for n, segment := range segments {
+		var found *fmtNode
+
+		for _, c := range nodes {
+			if c.key == segment {
+				found = c
+				break
+			}
+		}
+
+		if found == nil {
+			return parent, n, false, nil
+		}
+
+		parent, nodes = found, found.children
+	}
+
+	return parent, len(segments), true, nil
+}
+
+// blockEnd returns the index after the last line of a mapping line's block,
+// blank lines and comments after the block are not part of it.
+func blockEnd(lines []string, l docLine, end int) int {
+	last := l.index
+
+	for i := l.index + 1; i < end; i++ {
+		if isBlank(lines[i]) {
+			continue
+		}
+
+		if !blockLine(lines[i], l.indent) {
+			break
+		}
+
+		last = i
+	}
+
+	return last + 1
+}
+
+// parseLine parses a block mapping line, it's used by both documents and
+// Format.
+func parseLine(i int, line string) (docLine, bool) {
+	m := keyRegexp.FindStringSubmatch(line)
+	if m == nil {
+		return docLine{}, false
+	}
+
+	return docLine{
+		index:  i,
+		indent: len(m[1]),
+		key:    strings.Trim(m[2], `"'`),
+		raw:    m[2],
+		value:  strings.TrimSpace(m[3]),
+	}, true
+}
+
+// splitComment splits a value and its trailing comment, quoted values can contain #.
+func splitComment(value string) (string, string) {
+	start := 0
+
+	if len(value) > 0 && (value[0] == '"' || value[0] == '\'') {
+		if i := strings.IndexByte(value[1:], value[0]); i >= 0 {
+			start = i + 2
+		}
+	}
+
+	if strings.HasPrefix(value[start:], "#") && start == 0 {
+		return "", " " + value
+	}
+
+	if i := strings.Index(value[start:], " #"); i >= 0 {
+		return strings.TrimSpace(value[:start+i]), " " + value[start+i+1:]
+	}
+
+	return value, ""
+}
+
+// quoteKey quotes keys that can't be written as plain yaml keys.
+func quoteKey(key string) string {
+	if strings.ContainsAny(key, ":#'\" ") || len(key) == 0 {
+		return fmt.Sprintf("%q", key)
+	}
+
+	return key
+}
+
+// isBlank reports whether a line is blank, a comment or a document marker.
+func isBlank(line string) bool {
+	s := strings.TrimSpace(line)
+	return len(s) == 0 || strings.HasPrefix(s, "#") || markerRegexp.MatchString(line)
+}
+
+// blockLine reports whether a line is part of the block of a mapping line
+// at the given indentation, lists can be written at the same indentation.
+func blockLine(line string, indent int) bool {
+	n := len(line) - len(strings.TrimLeft(line, " "))
+	return n > indent || (n == indent && strings.HasPrefix(strings.TrimSpace(line), "- "))
+}
@@ -0,0 +1,182 @@
+package config
+
+import (
+	"testing"
+)
+
+const documentContent = `# Project tasks
+version: "1"
+
+tasks:
+  # Build the binary
+  build:
+    summary: Build binary # shown in max help
+    commands:
+    - go build
+    script: |
+      name: not a key
+  test: tests.yml
+`
+
+func TestDocument(t *testing.T) {
+	d, err := ParseDocument([]byte(documentContent))
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if v, ok := d.Get("tasks.build.summary"); !ok || v != "Build binary" {
+		t.Errorf("Expected: Build binary, got: %s", v)
+	}
+
+	if _, ok := d.Get("tasks.build.name"); ok {
+		t.Error("Expected: block scalar content to not be read as keys")
+	}
+
+	for k, v := range map[string]string{
+		"tasks.build.summary": "Build: app",
+		"tasks.build.dir":     "cmd/app",
+		"tasks.lint.summary":  "Lint",
+		"version":             "2",
+	} {
+		if err := d.Set(k, v); err != nil {
+			t.Errorf("Expected: nil for %s, got: %s", k, err)
+		}
+	}
+
+	exp := `# Project tasks
+version: "2"
+
+tasks:
+  # Build the binary
+  build:
+    summary: 'Build: app' # shown in max help
+    commands:
+    - go build
+    script: |
+      name: not a key
+    dir: cmd/app
+  test: tests.yml
+  lint:
+    summary: Lint
+`
+
+	if got := string(d.Bytes()); got != exp {
+		t.Errorf("Expected: %s, got: %s", exp, got)
+	}
+
+	for _, k := range []string{"tasks.build", "tasks.build.commands", "tasks.test.summary"} {
+		if err := d.Set(k, "x"); err == nil {
+			t.Errorf("Expected: error for %s, got: nil", k)
+		}
+	}
+
+	if got := string(d.Bytes()); got != exp {
+		t.Errorf("Expected: unchanged document, got: %s", got)
+	}
+
+	c, err := ReadContent(string(d.Bytes()), NoCache())
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.Tasks["lint"] == nil || c.Tasks["build"].Dir != "cmd/app" {
+		t.Errorf("Expected: modified tasks, got: %v", c.Tasks)
+	}
+}
+
+func TestDocumentLimits(t *testing.T) {
+	if _, err := ParseDocument([]byte("- build\n- test\n")); err == nil {
+		t.Error("Expected: error for a list document, got: nil")
+	}
+
+	d, err := ParseDocument([]byte(`tasks:
+  build:
+    env: {GOOS: linux}
+  test: !include tests.yml # shared
+`))
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if v, ok := d.Get("tasks.build.env"); !ok || v != "map[GOOS:linux]" {
+		t.Errorf("Expected: flow mapping value, got: %s", v)
+	}
+
+	if _, ok := d.Get("tasks.build.env.GOOS"); ok {
+		t.Error("Expected: flow mapping content to not be read as keys")
+	}
+
+	if err := d.Set("tasks.build.env.GOARCH", "amd64"); err == nil {
+		t.Error("Expected: error for a flow mapping, got: nil")
+	}
+
+	if err := d.Set("tasks.test", "!http https://example.com/tests.yml"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	exp := `tasks:
+  build:
+    env: {GOOS: linux}
+  test: '!http https://example.com/tests.yml' # shared
+`
+
+	if got := string(d.Bytes()); got != exp {
+		t.Errorf("Expected: %s, got: %s", exp, got)
+	}
+}
+
+func TestDocumentMarkers(t *testing.T) {
+	d, err := ParseDocument([]byte(`---
+version: "1"
+tasks:
+  a:
+    summary: Build the
+      app binary
+    dir: app
+...
+`))
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if v, ok := d.Get("tasks.a.summary"); !ok || v != "Build the app binary" {
+		t.Errorf("Expected: Build the app binary, got: %s", v)
+	}
+
+	for k, v := range map[string]string{
+		"tasks.a.summary": "Build",
+		"tasks.b.summary": "Test",
+	} {
+		if err := d.Set(k, v); err != nil {
+			t.Errorf("Expected: nil for %s, got: %s", k, err)
+		}
+	}
+
+	exp := `---
+version: "1"
+tasks:
+  a:
+    summary: Build
+    dir: app
+  b:
+    summary: Test
+...
+`
+
+	if got := string(d.Bytes()); got != exp {
+		t.Errorf("Expected: %s, got: %s", exp, got)
+	}
+
+	d, err = ParseDocument([]byte("---\n"))
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if err := d.Set("version", "1"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if got := string(d.Bytes()); got != "---\nversion: \"1\"\n" {
+		t.Errorf("Expected: version after the start marker, got: %s", got)
+	}
+}
@@ -0,0 +1,222 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/frozzare/go/yaml2"
+	"github.com/frozzare/max/internal/task"
+	"gopkg.in/yaml.v2"
+)
+
+// Dump returns the resolved config as yaml or json. Tasks are dumped in
+// declaration order, empty fields are left out and values of args and
+// variables that looks like secrets are masked.
+func (c *Config) Dump(format string) ([]byte, error) {
+	var doc yaml.MapSlice
+
+	add := func(k string, v interface{}) {
+		if v := dumpValue(reflect.ValueOf(v), k == "args" || k == "variables"); v != nil {
+			doc = append(doc, yaml.MapItem{Key: k, Value: v})
+		}
+	}
+
+	add("version", c.Version)
+	add("args", c.Args)
+	add("cache_scope", c.CacheScope)
+	add("clean_env", c.CleanEnv)
+	add("environments", c.Environments)
+	add("max_concurrency", c.MaxConcurrency)
+	add("max_output", c.MaxOutput)
+	add("notify", c.Notify)
+	add("on_failure", c.OnFailure)
+	add("quiet", c.Quiet)
+	add("variables", c.Variables)
+
+	var tasks yaml.MapSlice
+	for _, k := range c.List() {
+		if v := dumpValue(reflect.ValueOf(c.Tasks[k]), false); v != nil {
+			tasks = append(tasks, yaml.MapItem{Key: k, Value: v})
+		}
+	}
+
+	if len(tasks) > 0 {
+		doc = append(doc, yaml.MapItem{Key: "tasks", Value: tasks})
+	}
+
+	switch strings.ToLower(format) {
+	case "", "yaml", "yml":
+		return yaml.Marshal(doc)
+	case "json":
+		buf, err := json.MarshalIndent(jsonValue(doc), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		return append(buf, '\n'), nil
+	default:
+		return nil, fmt.Errorf("max: unknown dump format %s, use yaml or json", format)
+	}
+}
+
+// dumpValue converts a config value to ordered yaml values, nil is
+// returned for empty values. Secret values are masked when mask is true.
+func dumpValue(v reflect.Value, mask bool) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+
+		// Pointers to bools are set when false, e.g quiet: false.
+		if v.Elem().Kind() == reflect.Bool {
+			return v.Elem().Interface()
+		}
+
+		return dumpValue(v.Elem(), mask)
+	case reflect.Struct:
+		if l, ok := v.Interface().(yaml2.List); ok {
+			if len(l.Values) == 0 {
+				return nil
+			}
+
+			return l.Values
+		}
+
+		var m yaml.MapSlice
+
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if len(f.PkgPath) > 0 {
+				continue
+			}
+
+			name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+			if len(name) == 0 {
+				name = strings.ToLower(f.Name)
+			}
+
+			if fv := dumpValue(v.Field(i), mask || f.Name == "Args" || f.Name == "Variables"); fv != nil {
+				m = append(m, yaml.MapItem{Key: name, Value: fv})
+			}
+		}
+
+		if len(m) == 0 {
+			return nil
+		}
+
+		return m
+	case reflect.Map:
+		if v.Len() == 0 {
+			return nil
+		}
+
+		keys := make([]string, 0, v.Len())
+		values := make(map[string]reflect.Value, v.Len())
+
+		for _, k := range v.MapKeys() {
+			s := fmt.Sprintf("%v", k.Interface())
+			keys = append(keys, s)
+			values[s] = v.MapIndex(k)
+		}
+
+		sort.Strings(keys)
+
+		var m yaml.MapSlice
+
+		for _, k := range keys {
+			fv := dumpValue(values[k], mask)
+
+			if s, ok := fv.(string); ok && mask {
+				fv = task.Mask(k, s)
+			}
+
+			if fv != nil {
+				m = append(m, yaml.MapItem{Key: k, Value: fv})
+			}
+		}
+
+		return m
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return nil
+		}
+
+		l := make([]interface{}, v.Len())
+		for i := range l {
+			l[i] = dumpValue(v.Index(i), mask)
+		}
+
+		return l
+	default:
+		if v.IsZero() {
+			return nil
+		}
+
+		return v.Interface()
+	}
+}
+
+// jsonMap is a ordered map that keeps the key order when marshaled to json.
+type jsonMap yaml.MapSlice
+
+// MarshalJSON implements json packages interface to marshal custom values.
+func (m jsonMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+
+	for i, item := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		k, err := json.Marshal(fmt.Sprintf("%v", item.Key))
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := json.Marshal(item.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// jsonValue converts ordered yaml values to values that can be marshaled to json.
+func jsonValue(v interface{}) interface{} {
+	switch x := v.(type) {
+	case yaml.MapSlice:
+		m := make(jsonMap, len(x))
+		for i, item := range x {
+			m[i] = yaml.MapItem{Key: item.Key, Value: jsonValue(item.Value)}
+		}
+
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(x))
+		for i, item := range x {
+			l[i] = jsonValue(item)
+		}
+
+		return l
+	default:
+		return v
+	}
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	defer disableCache()()
+
+	c, err := ReadContent(`
+variables:
+  API_TOKEN: secret
+  REGION: eu
+tasks:
+  test:
+    commands: go test ./...
+  build:
+    deps: [test]
+    quiet: false
+    commands:
+      - go build
+`)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	buf, err := c.Dump("yaml")
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	got := string(buf)
+
+	for _, exp := range []string{"API_TOKEN: '****'", "REGION: eu", "quiet: false", "- go build"} {
+		if !strings.Contains(got, exp) {
+			t.Errorf("Expected: %s in yaml, got: %s", exp, got)
+		}
+	}
+
+	if strings.Index(got, "test:") > strings.Index(got, "build:") {
+		t.Errorf("Expected: tasks in declaration order, got: %s", got)
+	}
+
+	buf, err = c.Dump("json")
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	got = string(buf)
+
+	for _, exp := range []string{`"API_TOKEN": "****"`, `"quiet": false`, `"commands": [`} {
+		if !strings.Contains(got, exp) {
+			t.Errorf("Expected: %s in json, got: %s", exp, got)
+		}
+	}
+
+	if strings.Index(got, `"test"`) > strings.Index(got, `"build"`) {
+		t.Errorf("Expected: tasks in declaration order, got: %s", got)
+	}
+
+	if _, err := c.Dump("toml"); err == nil {
+		t.Error("Expected: unknown format error, got: nil")
+	}
+}
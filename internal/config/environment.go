@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Environment represents variables for a environment, e.g prod or dev.
+type Environment struct {
+	Variables map[string]interface{}
+}
+
+// UseEnvironment merges the environment's variables into the config variables.
+func (c *Config) UseEnvironment(name string) error {
+	env, ok := c.Environments[name]
+	if !ok || env == nil {
+		var names []string
+		for k := range c.Environments {
+			names = append(names, k)
+		}
+
+		sort.Strings(names)
+
+		if len(names) == 0 {
+			return fmt.Errorf("max: unknown environment %s, no environments are configured", name)
+		}
+
+		return fmt.Errorf("max: unknown environment %s, available environments: %s", name, strings.Join(names, ", "))
+	}
+
+	if c.Variables == nil {
+		c.Variables = make(map[string]interface{})
+	}
+
+	for k, v := range env.Variables {
+		c.Variables[k] = v
+	}
+
+	return nil
+}
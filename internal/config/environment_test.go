@@ -0,0 +1,38 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+const environmentConfig = `variables:
+  HOST: localhost
+  PORT: 8080
+environments:
+  prod:
+    variables:
+      HOST: example.com
+  dev:
+    variables:
+      DEBUG: true
+`
+
+func TestUseEnvironment(t *testing.T) {
+	c, err := ReadContent(environmentConfig)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if err := c.UseEnvironment("prod"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.Variables["HOST"] != "example.com" || c.Variables["PORT"] != 8080 {
+		t.Errorf("Expected: prod variables, got: %v", c.Variables)
+	}
+
+	err = c.UseEnvironment("staging")
+	if err == nil || !strings.Contains(err.Error(), "dev, prod") {
+		t.Errorf("Expected: error listing environments, got: %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package config_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/frozzare/max/internal/config"
+)
+
+// embedded is a config that would be embedded in a binary, e.g using go:embed.
+const embedded = `tasks:
+  hello:
+    summary: Hello task
+    commands:
+      - echo Hello
+`
+
+func ExampleReadReader() {
+	c, err := config.ReadReader(strings.NewReader(embedded))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	fmt.Println(c.Tasks["hello"].Summary)
+	// Output: Hello task
+}
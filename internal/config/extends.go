@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// resolveExtends replaces tasks that extends other tasks with the
+// extended task merged with the task's own fields.
+func (c *Config) resolveExtends() error {
+	resolved := make(map[string]bool)
+
+	var resolve func(id string, chain []string) error
+
+	resolve = func(id string, chain []string) error {
+		t := c.Tasks[id]
+		if t == nil || len(t.Extends) == 0 || resolved[id] {
+			return nil
+		}
+
+		for _, k := range chain {
+			if k == id {
+				return fmt.Errorf("max: extends cycle: %s", strings.Join(append(chain, id), " -> "))
+			}
+		}
+
+		parent := c.Tasks[t.Extends]
+		if parent == nil {
+			return fmt.Errorf("max: task %s extends missing task %s", id, t.Extends)
+		}
+
+		if err := resolve(t.Extends, append(chain, id)); err != nil {
+			return err
+		}
+
+		c.Tasks[id] = t.Extend(c.Tasks[t.Extends])
+		resolved[id] = true
+
+		return nil
+	}
+
+	for _, id := range c.List() {
+		if err := resolve(id, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
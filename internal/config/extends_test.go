@@ -0,0 +1,66 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtends(t *testing.T) {
+	defer disableCache()()
+
+	c, err := ReadContent(`
+tasks:
+  deploy:
+    summary: Deploy
+    commands:
+      - deploy $ENV
+    variables:
+      ENV: production
+      REGION: eu
+  deploy-staging:
+    extends: deploy
+    variables:
+      ENV: staging
+  deploy-staging-us:
+    extends: deploy-staging
+    variables:
+      REGION: us
+`)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	tk := c.Tasks["deploy-staging-us"]
+
+	if tk.Summary != "Deploy" || tk.Variables["ENV"] != "staging" || tk.Variables["REGION"] != "us" {
+		t.Errorf("Expected: extended task, got: %+v", tk)
+	}
+
+	if c.Tasks["deploy"].Variables["ENV"] != "production" {
+		t.Errorf("Expected: 'production', got: %v", c.Tasks["deploy"].Variables["ENV"])
+	}
+}
+
+func TestExtendsErrors(t *testing.T) {
+	defer disableCache()()
+
+	_, err := ReadContent(`
+tasks:
+  a:
+    extends: b
+  b:
+    extends: a
+`)
+	if err == nil || !strings.Contains(err.Error(), "max: extends cycle: a -> b -> a") {
+		t.Errorf("Expected: cycle error, got: %v", err)
+	}
+
+	_, err = ReadContent(`
+tasks:
+  a:
+    extends: missing
+`)
+	if err == nil || err.Error() != "max: task a extends missing task missing" {
+		t.Errorf("Expected: missing task error, got: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/frozzare/max/internal/cache"
+	"gopkg.in/yaml.v2"
+)
+
+// Supported config formats.
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+)
+
+// detectFormat returns the config format based on the file extension.
+func detectFormat(path string) string {
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		return FormatJSON
+	}
+
+	return FormatYAML
+}
+
+// checkFormat checks that the content can be parsed as the format.
+func checkFormat(content []byte, format string) error {
+	var v interface{}
+	var err error
+
+	switch format {
+	case "", FormatYAML:
+		err = yaml.Unmarshal(content, &v)
+	case FormatJSON:
+		err = json.Unmarshal(content, &v)
+	default:
+		return fmt.Errorf("max: unknown config format %s, use %s or %s", format, FormatYAML, FormatJSON)
+	}
+
+	if err != nil {
+		return fmt.Errorf("max: config is not valid %s: %s", format, err)
+	}
+
+	return nil
+}
+
+// readOptions represents options used when reading config files.
+type readOptions struct {
+	cache    *cache.Cache
+	client   *http.Client
+	format   string
+	key      string
+	lenient  bool
+	noCache  bool
+	path     string
+	refresh  bool
+	template map[string]interface{}
+}
+
+// readFormat preprocesses the content if enabled and checks the content
+// format before the config is read. JSON is a subset of YAML so both
+// formats is read with the yaml parser.
+func readFormat(content []byte, opts readOptions) (*Config, error) {
+	if opts.template != nil {
+		var err error
+		if content, err = Preprocess(content, opts.template); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkFormat(content, opts.format); err != nil {
+		return nil, err
+	}
+
+	if len(opts.key) > 0 {
+		var err error
+		if content, err = extractKey(content, opts.key); err != nil {
+			return nil, err
+		}
+	}
+
+	return readContent(content, opts)
+}
+
+// ReadContentFormat creates a new config struct from a string in the given format.
+func ReadContentFormat(content, format string) (*Config, error) {
+	return readFormat([]byte(content), readOptions{format: format})
+}
+
+// ReadFileFormat creates a new config struct from a file like ReadFile but
+// uses the given format instead of detecting it from the file extension.
+func ReadFileFormat(format string, args ...string) (*Config, error) {
+	return readFile(readOptions{format: format}, args...)
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadContentFormat(t *testing.T) {
+	defer disableCache()()
+
+	c, err := ReadContentFormat(`{"tasks": {"hello": {"summary": "Hello task"}}}`, FormatJSON)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.Tasks["hello"] == nil || c.Tasks["hello"].Summary != "Hello task" {
+		t.Errorf("Expected: 'Hello task', got: %v", c.Tasks["hello"])
+	}
+
+	if _, err := ReadContentFormat("tasks:\n  hello:\n    summary: Hello task\n", FormatJSON); err == nil || !strings.Contains(err.Error(), "not valid json") {
+		t.Errorf("Expected: json error, got: %v", err)
+	}
+
+	if _, err := ReadContentFormat("tasks: {}", "toml"); err == nil || !strings.Contains(err.Error(), "unknown config format") {
+		t.Errorf("Expected: unknown format error, got: %v", err)
+	}
+}
+
+func TestReadFileFormat(t *testing.T) {
+	defer disableCache()()
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "max.txt")
+	if err := ioutil.WriteFile(file, []byte("tasks:\n  hello:\n    summary: Hello task\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if c, err := ReadFileFormat(FormatYAML, file); err != nil || c.Tasks["hello"] == nil {
+		t.Errorf("Expected: task, got: %v", err)
+	}
+
+	json := filepath.Join(dir, "max.json")
+	if err := ioutil.WriteFile(json, []byte("tasks:\n  hello: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadFile(json); err == nil {
+		t.Error("Expected: error, got: nil")
+	}
+
+	if _, err := ReadFileFormat(FormatYAML, json); err != nil {
+		t.Errorf("Expected: nil, got: %s", err)
+	}
+
+	if _, err := ReadFileOptions(json, WithFormat(FormatYAML), Lenient()); err != nil {
+		t.Errorf("Expected: nil, got: %s", err)
+	}
+}
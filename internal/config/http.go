@@ -1,7 +1,13 @@
 package config
 
 import (
+	"bytes"
+	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"text/template"
 
 	"github.com/frozzare/go/http2"
 	"github.com/frozzare/max/internal/cache"
@@ -9,43 +15,145 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
-func includeHTTPTask(url string, cache *cache.Cache) (*task.Task, error) {
-	client := http2.NewClient(nil)
-
-	if cache != nil {
-		if buf, err := cache.Get(url); len(buf) > 0 && err == nil {
-			var t *task.Task
+func (l *loader) readHTTP(url string) ([]byte, error) {
+	return l.download(url, func(body []byte) error {
+		// Don't cache invalid yaml.
+		var v interface{}
+		return yaml.Unmarshal(body, &v)
+	})
+}
 
-			if err := yaml.Unmarshal(buf, &t); err != nil {
-				return nil, err
-			}
+// download downloads a url or returns the cached body. Bodies that aren't valid
+// according to the valid function are not cached.
+func (l *loader) download(url string, valid func([]byte) error) ([]byte, error) {
+	client := http2.NewClient(l.client)
 
-			return t, nil
+	if l.cache != nil && !l.refresh {
+		if buf, err := l.cache.Get(url); len(buf) > 0 && err == nil {
+			return buf, nil
 		}
 	}
 
-	res, err := client.Get(url)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, v := range l.headersFor(req.URL) {
+		req.Header[k] = v
+	}
+
+	res, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 
 	defer res.Body.Close()
 
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, fmt.Errorf("max: bad status code %d from %s", res.StatusCode, url)
+	}
+
 	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return nil, err
 	}
 
-	var t *task.Task
-	if err := yaml.Unmarshal(body, &t); err != nil {
-		return nil, err
+	if valid != nil {
+		if err := valid(body); err != nil {
+			return nil, err
+		}
+	}
+
+	// Failing to cache the body should not fail the include.
+	if l.cache != nil {
+		l.cache.Set(url, body)
+	}
+
+	if l.fetch != nil {
+		l.fetch(url)
+	}
+
+	return body, nil
+}
+
+// headersFor returns the headers to send for a url, host specific
+// headers takes precedence over headers for all hosts.
+func (l *loader) headersFor(u *url.URL) http.Header {
+	h := make(http.Header)
+
+	for _, host := range []string{"", u.Hostname(), u.Host} {
+		for k, v := range l.headers[host] {
+			h[k] = v
+		}
+	}
+
+	return h
+}
+
+// renderHeaders renders header values with variables and environment variables.
+// String values are sent to all hosts and map values only to the host in the key.
+func renderHeaders(raw map[string]interface{}, data map[string]interface{}) (map[string]http.Header, error) {
+	headers := make(map[string]http.Header)
+
+	add := func(host, key string, value interface{}) error {
+		v, err := renderValue(fmt.Sprintf("%v", value), data)
+		if err != nil {
+			return err
+		}
+
+		if headers[host] == nil {
+			headers[host] = make(http.Header)
+		}
+
+		headers[host].Set(key, v)
+
+		return nil
 	}
 
-	if cache != nil {
-		if err := cache.Set(url, body); err != nil {
+	for k, v := range raw {
+		if m, ok := v.(map[interface{}]interface{}); ok {
+			for hk, hv := range m {
+				if err := add(k, fmt.Sprintf("%v", hk), hv); err != nil {
+					return nil, err
+				}
+			}
+
+			continue
+		}
+
+		if err := add("", k, v); err != nil {
 			return nil, err
 		}
 	}
 
-	return t, nil
+	return headers, nil
+}
+
+// renderValue renders a go template string and expands environment
+// variables, environment variables takes precedence over data values.
+func renderValue(s string, data map[string]interface{}) (string, error) {
+	tmpl, err := template.New("main").Funcs(task.TemplateFuncs()).Parse(s)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	vars := task.StringVariables(data)
+
+	return os.Expand(buf.String(), func(k string) string {
+		if v := os.Getenv(k); len(v) > 0 {
+			return v
+		}
+
+		return vars[k]
+	}), nil
+}
+
+func includeHTTPTask(url string, cache *cache.Cache) (*task.Task, error) {
+	return (&loader{cache: cache}).includeTask(url, "")
 }
@@ -3,7 +3,11 @@ package config
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
 	"testing"
+
+	"gopkg.in/yaml.v2"
 )
 
 const httpTask = `args:
@@ -30,3 +34,66 @@ func TestIncludeHTTPTask(t *testing.T) {
 		t.Errorf("Expected: 'Hello task', got: %s", task.Summary)
 	}
 }
+
+func TestIncludeHTTPTaskNoCache(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(httpTask))
+	}))
+
+	defer server.Close()
+
+	// A config without a cache, e.g when there's no writable home directory.
+	c := &Config{}
+	content := "tasks:\n  hello: " + server.URL + "\n  missing: missing.yml\n"
+
+	if err := yaml.Unmarshal([]byte(content), &c); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.Tasks["hello"] == nil || c.Tasks["hello"].Summary != "Hello task" {
+		t.Errorf("Expected: 'Hello task', got: %v", c.Tasks["hello"])
+	}
+
+	if c.Tasks["missing"] != nil {
+		t.Errorf("Expected: nil, got: %v", c.Tasks["missing"])
+	}
+}
+
+func TestIncludeHTTPTaskHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Host") != "local" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(httpTask))
+	}))
+
+	defer server.Close()
+
+	os.Setenv("MAX_TEST_TOKEN", "secret")
+	defer os.Unsetenv("MAX_TEST_TOKEN")
+
+	u, _ := url.Parse(server.URL)
+
+	content := `variables:
+  prefix: Bearer
+http_headers:
+  Authorization: "{{ .prefix }} $MAX_TEST_TOKEN"
+  ` + u.Hostname() + `:
+    X-Host: local
+  example.com:
+    X-Host: remote
+tasks:
+  hello: ` + server.URL + "\n"
+
+	c := &Config{}
+
+	if err := yaml.Unmarshal([]byte(content), &c); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.Tasks["hello"] == nil || c.Tasks["hello"].Summary != "Hello task" {
+		t.Errorf("Expected: 'Hello task', got: %v", c.Tasks["hello"])
+	}
+}
@@ -0,0 +1,301 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/frozzare/go/yaml2"
+	"github.com/frozzare/max/internal/cache"
+	"github.com/frozzare/max/internal/task"
+	"gopkg.in/yaml.v2"
+)
+
+const maxIncludeDepth = 10
+
+// ErrIncludeDepth is returned when includes are nested too deep.
+var ErrIncludeDepth = errors.New("max: includes are nested too deep")
+
+// IncludeCycleError is returned when a include resolves to a file or url
+// that is already included, e.g a task file that includes itself.
+type IncludeCycleError struct {
+	Chain []string
+}
+
+// Error returns the error message with the resolved include chain.
+func (e *IncludeCycleError) Error() string {
+	return fmt.Sprintf("max: include cycle %s", strings.Join(e.Chain, " -> "))
+}
+
+// canonicalRef returns the absolute path of a file reference with symlinks
+// resolved, urls are returned as is.
+func canonicalRef(ref string) string {
+	if isHTTP(ref) {
+		return ref
+	}
+
+	path, err := filepath.Abs(ref)
+	if err != nil {
+		return ref
+	}
+
+	if p, err := filepath.EvalSymlinks(path); err == nil {
+		path = p
+	}
+
+	return path
+}
+
+// isHTTP reports whether the reference is a http or https url.
+func isHTTP(ref string) bool {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return false
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+
+	return (scheme == "http" || scheme == "https") && len(u.Host) > 0
+}
+
+// resolveRef resolves a include reference relative to the base it was included from.
+func resolveRef(base, ref string) string {
+	// Paths starting with ~ are relative to the home directory.
+	if path, err := task.ExpandPath(ref); err == nil && path != ref && !isHTTP(base) {
+		return path
+	}
+
+	if len(base) == 0 {
+		return ref
+	}
+
+	if isHTTP(base) {
+		b, err := url.Parse(base)
+		if err != nil {
+			return ref
+		}
+
+		r, err := url.Parse(ref)
+		if err != nil {
+			return ref
+		}
+
+		return b.ResolveReference(r).String()
+	}
+
+	if isHTTP(ref) || filepath.IsAbs(ref) {
+		return ref
+	}
+
+	return filepath.Join(filepath.Dir(base), ref)
+}
+
+// loader loads included tasks.
+type loader struct {
+	cache    *cache.Cache
+	client   *http.Client
+	fetch    func(url string)
+	headers  map[string]http.Header
+	read     func(path string)
+	refresh  bool
+	snippets map[string]yaml2.List
+}
+
+// renderRef renders a include reference with the config's args and
+// variables and the include's args. The include's args shadows config args
+// and variables with the same name, e.g {{ .env }} is the include's env arg.
+func renderRef(ref string, args, vars, include map[string]interface{}) (string, error) {
+	if !strings.Contains(ref, "{{") {
+		return ref, nil
+	}
+
+	merged := make(map[string]interface{}, len(args)+len(include))
+	for k, v := range args {
+		merged[k] = v
+	}
+
+	for k, v := range include {
+		merged[k] = v
+	}
+
+	data, err := task.TemplateData(merged, vars)
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New("main").Funcs(task.TemplateFuncs()).Parse(ref)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// includeTask loads a task from a file or url. A task file that only contains
+// a include reference is included relative to the file or url it's defined in.
+func (l *loader) includeTask(ref, base string) (*task.Task, error) {
+	return l.include(refAny, ref, base, nil)
+}
+
+// include loads a task from a reference of the given kind, see refAny, refFile
+// and refHTTP. The args are merged into the included task's args. Remote
+// references can be pinned with a sha256 checksum, e.g deploy.yml#sha256=<hex>,
+// and the host of the first unpinned remote reference is the task's origin,
+// a pinned reference in a unpinned file is not trusted.
+func (l *loader) include(kind, ref, base string, args map[string]interface{}) (*task.Task, error) {
+	remote := false
+	origin := ""
+	argsList := []map[string]interface{}{args}
+
+	// Files and urls that are already included, starting with the config file.
+	var chain []string
+	if len(base) > 0 {
+		chain = append(chain, canonicalRef(base))
+	}
+
+	for i := 0; i < maxIncludeDepth; i++ {
+		ref = resolveRef(base, ref)
+
+		if !isBundle(ref) {
+			path, _ := splitChecksum(ref)
+			canonical := canonicalRef(path)
+
+			for _, c := range chain {
+				if c == canonical {
+					return nil, &IncludeCycleError{Chain: append(chain, canonical)}
+				}
+			}
+
+			chain = append(chain, canonical)
+		}
+
+		// Tagged files are remote when included from a url.
+		switch kind {
+		case refHTTP:
+			remote = true
+		case refAny:
+			remote = isHTTP(ref)
+		}
+
+		var buf []byte
+		var err error
+
+		// Bundles are extracted and their task file is included.
+		if isBundle(ref) {
+			if bundle, sum := splitChecksum(ref); isHTTP(bundle) && len(origin) == 0 {
+				origin = pinnedOrigin(bundle, sum)
+			}
+
+			if ref, err = l.includeBundle(ref); err != nil {
+				return nil, err
+			}
+
+			remote = false
+		}
+
+		if remote {
+			var sum string
+			ref, sum = splitChecksum(ref)
+
+			if len(origin) == 0 {
+				origin = pinnedOrigin(ref, sum)
+			}
+
+			buf, err = l.readHTTP(ref)
+
+			// Download the task again if the cached task has changed.
+			if err == nil && verifyChecksum(ref, sum, buf) != nil && l.cache != nil {
+				l.cache.Delete(ref)
+				buf, err = l.readHTTP(ref)
+			}
+
+			if err == nil {
+				err = verifyChecksum(ref, sum, buf)
+			}
+		} else {
+			buf, err = ioutil.ReadFile(ref)
+
+			// Report local files, even missing ones, so they can be watched.
+			if l.read != nil && !strings.HasPrefix(ref, bundleDir()) {
+				if path, err := filepath.Abs(ref); err == nil {
+					l.read(path)
+				}
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		buf = rewriteTags(buf)
+
+		var v interface{}
+		if err := yaml.Unmarshal(buf, &v); err != nil {
+			return nil, err
+		}
+
+		if k, next, a, ok := tagRef(v); ok {
+			base = ref
+			kind, ref = k, next
+			argsList = append(argsList, a)
+			continue
+		}
+
+		// Snippets from the config can be used in included tasks.
+		if err := expandSnippets(ref, v, l.snippets); err != nil {
+			return nil, err
+		}
+
+		if buf, err = yaml.Marshal(v); err != nil {
+			return nil, err
+		}
+
+		var t *task.Task
+		if err := yaml.Unmarshal(buf, &t); err != nil {
+			return nil, err
+		}
+
+		if t != nil {
+			t.Base(ref)
+			t.Origin(origin)
+
+			// Args closer to the config takes precedence over args in included files.
+			for i := len(argsList) - 1; i >= 0; i-- {
+				if len(argsList[i]) > 0 {
+					t.Args = mergeMap(t.Args, argsList[i])
+				}
+			}
+
+			// Relative directories in bundles are inside the extracted bundle.
+			if dir := filepath.Dir(ref); strings.HasPrefix(ref, bundleDir()) && len(t.Dir) > 0 && !filepath.IsAbs(t.Dir) {
+				t.Dir = filepath.Join(dir, t.Dir)
+			}
+		}
+
+		return t, nil
+	}
+
+	return nil, ErrIncludeDepth
+}
+
+// pinnedOrigin returns the host of a remote reference, empty when the
+// reference is pinned by a checksum.
+func pinnedOrigin(ref, sum string) string {
+	u, err := url.Parse(ref)
+	if err != nil || len(sum) > 0 {
+		return ""
+	}
+
+	return u.Host
+}
@@ -0,0 +1,307 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsHTTP(t *testing.T) {
+	tests := []struct {
+		ref string
+		exp bool
+	}{
+		{"http://example.com/deploy.yml", true},
+		{"https://example.com/deploy.yml", true},
+		{"HTTPS://example.com/deploy.yml", true},
+		{"httpd/build.yml", false},
+		{"./http-stuff.yml", false},
+		{"/srv/http/tasks.yml", false},
+		{"http-tasks.yml", false},
+		{"ftp://example.com/deploy.yml", false},
+		{"C:\\http\\tasks.yml", false},
+	}
+
+	for _, test := range tests {
+		if got := isHTTP(test.ref); got != test.exp {
+			t.Errorf("Expected: %v for %s, got: %v", test.exp, test.ref, got)
+		}
+	}
+}
+
+func TestIncludeLocalHTTPPath(t *testing.T) {
+	defer disableCache()()
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "httpd"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "httpd", "build.yml"), []byte("summary: Build task"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "http-stuff.yml"), []byte("summary: Stuff task"), 0644)
+
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	c, err := ReadContent(`
+tasks:
+  build: httpd/build.yml
+  stuff: ./http-stuff.yml
+`)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.Tasks["build"] == nil || c.Tasks["build"].Summary != "Build task" {
+		t.Errorf("Expected: 'Build task', got: %+v", c.Tasks["build"])
+	}
+
+	if c.Tasks["stuff"] == nil || c.Tasks["stuff"].Summary != "Stuff task" {
+		t.Errorf("Expected: 'Stuff task', got: %+v", c.Tasks["stuff"])
+	}
+}
+
+func TestIncludeTemplateRef(t *testing.T) {
+	defer disableCache()()
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "tasks"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "tasks", "prod.yml"), []byte("summary: Prod task"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "tasks", "stage.yml"), []byte("summary: Stage task"), 0644)
+
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	c, err := ReadContent(`
+args:
+  env: prod
+variables:
+  dir: tasks
+tasks:
+  prod: !include "{{ .vars.dir }}/{{ .env }}.yml"
+  stage:
+    file: "{{ .dir }}/{{ .env }}.yml"
+    args:
+      env: stage
+`)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.Tasks["prod"] == nil || c.Tasks["prod"].Summary != "Prod task" {
+		t.Errorf("Expected: 'Prod task', got: %+v", c.Tasks["prod"])
+	}
+
+	// The include's args shadows the config's args.
+	if c.Tasks["stage"] == nil || c.Tasks["stage"].Summary != "Stage task" {
+		t.Errorf("Expected: 'Stage task', got: %+v", c.Tasks["stage"])
+	}
+
+	if _, err := ReadContent("tasks:\n  bad: !include \"{{ .env\"\n"); err == nil || !strings.Contains(err.Error(), "can't include") {
+		t.Errorf("Expected: include error, got: %v", err)
+	}
+}
+
+func TestResolveRef(t *testing.T) {
+	defer disableCache()()
+
+	tests := []struct {
+		base string
+		ref  string
+		exp  string
+	}{
+		{"", "hello.yml", "hello.yml"},
+		{"tasks/max.yml", "hello.yml", "tasks/hello.yml"},
+		{"tasks/max.yml", "/tmp/hello.yml", "/tmp/hello.yml"},
+		{"https://example.com/tasks/deploy.yml", "common/deploy.yml", "https://example.com/tasks/common/deploy.yml"},
+		{"https://example.com/tasks/deploy.yml", "../deploy.yml", "https://example.com/deploy.yml"},
+		{"https://example.com/tasks/deploy.yml", "https://example.org/deploy.yml", "https://example.org/deploy.yml"},
+		{"", "~/tasks/hello.yml", "/dev/null/tasks/hello.yml"},
+		{"tasks/max.yml", "~/hello.yml", "/dev/null/hello.yml"},
+	}
+
+	for _, test := range tests {
+		if got := resolveRef(test.base, test.ref); got != test.exp {
+			t.Errorf("Expected: %s, got: %s", test.exp, got)
+		}
+	}
+}
+
+func TestIncludeTaskRelativeURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tasks/deploy.yml":
+			w.Write([]byte("!include common/deploy.yml"))
+		case "/tasks/common/deploy.yml":
+			w.Write([]byte(httpTask))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	defer server.Close()
+
+	task, err := (&loader{}).includeTask(server.URL+"/tasks/deploy.yml", "")
+
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if task.Summary != "Hello task" {
+		t.Errorf("Expected: 'Hello task', got: %s", task.Summary)
+	}
+
+	if task.Base() != server.URL+"/tasks/common/deploy.yml" {
+		t.Errorf("Expected: base url, got: %s", task.Base())
+	}
+}
+
+func TestReadContentLenient(t *testing.T) {
+	content := "tasks:\n  hello: hello.yml\n  bad: bad.yml\n  inline:\n    summary: Inline task\n"
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "bad.yml"), []byte("summary: [bad"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content = strings.Replace(content, "bad.yml", filepath.Join(dir, "bad.yml"), 1)
+
+	if _, err := ReadContent(content); err != ErrUnmarshal {
+		t.Errorf("Expected: %v, got: %v", ErrUnmarshal, err)
+	}
+
+	c, err := ReadContentLenient(content)
+
+	errs, ok := err.(IncludeErrors)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("Expected: one include error, got: %v", err)
+	}
+
+	if errs[0].Key != "bad" || errs[0].Ref != filepath.Join(dir, "bad.yml") {
+		t.Errorf("Expected: bad include error, got: %v", errs[0])
+	}
+
+	if c == nil || c.Tasks["hello"] == nil || c.Tasks["inline"] == nil {
+		t.Errorf("Expected: loaded tasks, got: %v", c)
+	}
+}
+
+func TestIncludeTaskOrigin(t *testing.T) {
+	var server *httptest.Server
+
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ref.yml" {
+			w.Write([]byte(server.URL + "/deploy.yml#sha256=" + checksum([]byte(httpTask))))
+			return
+		}
+
+		w.Write([]byte(httpTask))
+	}))
+
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	task, err := (&loader{}).includeTask(server.URL+"/deploy.yml", "")
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if task.Origin() != host {
+		t.Errorf("Expected: %s, got: %s", host, task.Origin())
+	}
+
+	task, err = (&loader{}).includeTask(server.URL+"/deploy.yml#sha256="+checksum([]byte(httpTask)), "")
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if task.Origin() != "" {
+		t.Errorf("Expected: empty origin for pinned task, got: %s", task.Origin())
+	}
+
+	// A pinned reference in a unpinned file is not trusted.
+	task, err = (&loader{}).includeTask(server.URL+"/ref.yml", "")
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if task.Origin() != host {
+		t.Errorf("Expected: %s, got: %s", host, task.Origin())
+	}
+
+	if _, err := (&loader{}).includeTask(server.URL+"/deploy.yml#sha256=abc", ""); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Expected: checksum mismatch error, got: %v", err)
+	}
+}
+
+func TestIncludeCycle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	dir, _ = filepath.EvalSymlinks(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "a.yml"), []byte("!include b.yml"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "b.yml"), []byte("!include ./sub/../a.yml"), 0644)
+	os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	os.Symlink(filepath.Join(dir, "max.yml"), filepath.Join(dir, "link.yml"))
+
+	tests := []struct {
+		content string
+		chain   []string
+	}{
+		{"tasks:\n  deploy: !include a.yml", []string{"max.yml", "a.yml", "b.yml", "a.yml"}},
+		{"tasks:\n  deploy: !include link.yml", []string{"max.yml", "max.yml"}},
+	}
+
+	for _, test := range tests {
+		path := filepath.Join(dir, "max.yml")
+		ioutil.WriteFile(path, []byte(test.content), 0644)
+
+		_, err := ReadFile(path)
+
+		e, ok := err.(*IncludeError)
+		if !ok {
+			t.Fatalf("Expected: include error, got: %v", err)
+		}
+
+		cycle, ok := e.Err.(*IncludeCycleError)
+		if !ok {
+			t.Fatalf("Expected: include cycle error, got: %v", e.Err)
+		}
+
+		var chain []string
+		for _, c := range cycle.Chain {
+			chain = append(chain, filepath.Base(c))
+		}
+
+		if strings.Join(chain, " ") != strings.Join(test.chain, " ") {
+			t.Errorf("Expected: %v, got: %v", test.chain, chain)
+		}
+	}
+}
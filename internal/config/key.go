@@ -0,0 +1,59 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// splitKey splits a path like project.yml#max into the file path and the
+// key the config is read from. Paths of existing files are not split.
+func splitKey(path string) (string, string) {
+	if _, err := os.Stat(path); err == nil {
+		return path, ""
+	}
+
+	i := strings.LastIndex(path, "#")
+	if i <= 0 {
+		return path, ""
+	}
+
+	return path[:i], path[i+1:]
+}
+
+// extractKey returns the content of a key in a yaml or json document,
+// nested keys are separated with a dot, e.g tools.max.
+func extractKey(content []byte, key string) ([]byte, error) {
+	var m yaml.MapSlice
+
+	// Tags are rewritten before since they are lost when the key is marshaled again.
+	if err := yaml.Unmarshal(rewriteTags(content), &m); err != nil {
+		return nil, err
+	}
+
+	var v interface{} = m
+
+	for _, k := range strings.Split(key, ".") {
+		m, ok := v.(yaml.MapSlice)
+		if !ok {
+			return nil, fmt.Errorf("max: config key %s is missing", key)
+		}
+
+		found := false
+
+		for _, item := range m {
+			if fmt.Sprintf("%v", item.Key) == k {
+				v, found = item.Value, true
+				break
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("max: config key %s is missing", key)
+		}
+	}
+
+	return yaml.Marshal(v)
+}
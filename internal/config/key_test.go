@@ -0,0 +1,60 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadFileKey(t *testing.T) {
+	defer disableCache()()
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "project.yml")
+	ioutil.WriteFile(path, []byte(`
+name: project
+max:
+  tasks:
+    build:
+      summary: Build
+    test:
+      summary: Test
+tools:
+  max:
+    tasks:
+      lint:
+        summary: Lint
+`), 0644)
+
+	c, err := ReadFile(path + "#max")
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(c.List(), []string{"build", "test"}) {
+		t.Errorf("Expected: [build test], got: %v", c.List())
+	}
+
+	c, err = ReadFile(path + "#tools.max")
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.Tasks["lint"] == nil {
+		t.Errorf("Expected: lint task, got: %v", c.Tasks)
+	}
+
+	for _, key := range []string{"missing", "name.max", "tools.missing"} {
+		if _, err := ReadFile(path + "#" + key); err == nil || err.Error() != "max: config key "+key+" is missing" {
+			t.Errorf("Expected: missing key error for %s, got: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// TaskInfo represents static information about a task.
+type TaskInfo struct {
+	Name       string    `json:"name"`
+	Summary    string    `json:"summary"`
+	Usage      string    `json:"usage"`
+	Deprecated string    `json:"deprecated,omitempty"`
+	Deps       []string  `json:"deps"`
+	Tasks      []string  `json:"tasks"`
+	Args       []ArgInfo `json:"args"`
+}
+
+// ArgInfo represents static information about a task argument.
+type ArgInfo struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Required bool   `json:"required"`
+}
+
+// List returns task names in declaration order.
+func (c *Config) List() []string {
+	var names []string
+	seen := make(map[string]bool)
+
+	for _, k := range c.order {
+		if _, ok := c.Tasks[k]; ok && !seen[k] {
+			names = append(names, k)
+			seen[k] = true
+		}
+	}
+
+	// Tasks not declared in a file, e.g created in code, are sorted by name.
+	var rest []string
+	for k := range c.Tasks {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+
+	sort.Strings(rest)
+
+	return append(names, rest...)
+}
+
+// Info returns static information about all tasks in declaration order.
+func (c *Config) Info() []TaskInfo {
+	infos := make([]TaskInfo, 0, len(c.Tasks))
+
+	for _, name := range c.List() {
+		t := c.Tasks[name]
+		if t == nil {
+			continue
+		}
+
+		info := TaskInfo{
+			Name:       name,
+			Summary:    t.Summary,
+			Usage:      t.Usage,
+			Deprecated: t.Deprecated,
+			Deps:       t.Deps,
+			Tasks:      t.Tasks.Values,
+			Args:       []ArgInfo{},
+		}
+
+		if info.Deps == nil {
+			info.Deps = []string{}
+		}
+
+		if info.Tasks == nil {
+			info.Tasks = []string{}
+		}
+
+		args := make(map[string]interface{})
+		for k, v := range c.Args {
+			args[k] = v
+		}
+
+		for k, v := range t.Args {
+			args[k] = v
+		}
+
+		var keys []string
+		for k := range args {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			info.Args = append(info.Args, ArgInfo{
+				Name:     k,
+				Type:     argType(args[k]),
+				Required: args[k] == nil,
+			})
+		}
+
+		infos = append(infos, info)
+	}
+
+	return infos
+}
+
+func argType(v interface{}) string {
+	switch v.(type) {
+	case nil, string:
+		return "string"
+	case bool:
+		return "bool"
+	case int, int64, uint64:
+		return "int"
+	case float64:
+		return "float"
+	case []interface{}:
+		return "list"
+	case map[interface{}]interface{}, map[string]interface{}:
+		return "map"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+const listConfig = `args:
+  name: default
+tasks:
+  test:
+    summary: Test task
+    deprecated: use build instead
+    deps: [build]
+  build:
+    args:
+      target:
+      count: 1
+    summary: Build task
+  app: !include hello.yml
+`
+
+func TestList(t *testing.T) {
+	c, err := ReadContent(listConfig)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if got := c.List(); !reflect.DeepEqual(got, []string{"test", "build", "app"}) {
+		t.Errorf("Expected: declaration order, got: %v", got)
+	}
+}
+
+func TestInfo(t *testing.T) {
+	c, err := ReadContent(listConfig)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	infos := c.Info()
+
+	if len(infos) != 3 {
+		t.Fatalf("Expected: 3 tasks, got: %d", len(infos))
+	}
+
+	if infos[0].Deprecated != "use build instead" {
+		t.Errorf("Expected: 'use build instead', got: %s", infos[0].Deprecated)
+	}
+
+	if !reflect.DeepEqual(infos[0].Deps, []string{"build"}) {
+		t.Errorf("Expected: [build], got: %v", infos[0].Deps)
+	}
+
+	exp := []ArgInfo{
+		{Name: "count", Type: "int"},
+		{Name: "name", Type: "string"},
+		{Name: "target", Type: "string", Required: true},
+	}
+
+	if !reflect.DeepEqual(infos[1].Args, exp) {
+		t.Errorf("Expected: %v, got: %v", exp, infos[1].Args)
+	}
+}
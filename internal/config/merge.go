@@ -0,0 +1,148 @@
+package config
+
+import (
+	"os"
+
+	"github.com/frozzare/max/internal/task"
+)
+
+// Merge merges another config into the config. Tasks in the other config
+// replaces tasks with the same name, args and variables are deep merged
+// with the other config's values taking precedence and environments are
+// merged by name. Other values are replaced when they are set in the other
+// config, e.g quiet: false replaces quiet: true.
+func (c *Config) Merge(o *Config) {
+	if o == nil {
+		return
+	}
+
+	c.Args = mergeMap(c.Args, o.Args)
+	c.Variables = mergeMap(c.Variables, o.Variables)
+
+	if len(o.Environments) > 0 && c.Environments == nil {
+		c.Environments = make(map[string]*Environment, len(o.Environments))
+	}
+
+	for k, env := range o.Environments {
+		old, ok := c.Environments[k]
+		if !ok || old == nil || env == nil {
+			c.Environments[k] = env
+			continue
+		}
+
+		c.Environments[k] = &Environment{Variables: mergeMap(old.Variables, env.Variables)}
+	}
+
+	if len(o.CacheScope) > 0 {
+		c.CacheScope = o.CacheScope
+	}
+
+	if o.CleanEnv || o.set["clean_env"] {
+		c.CleanEnv = o.CleanEnv
+	}
+
+	if o.MaxConcurrency > 0 {
+		c.MaxConcurrency = o.MaxConcurrency
+	}
+
+	if len(o.MaxOutput) > 0 {
+		c.MaxOutput = o.MaxOutput
+	}
+
+	if o.Notify != nil {
+		c.Notify = o.Notify
+	}
+
+	if len(o.OnFailure.Values) > 0 {
+		c.OnFailure = o.OnFailure
+	}
+
+	if o.Quiet || o.set["quiet"] {
+		c.Quiet = o.Quiet
+	}
+
+	if len(o.Version) > 0 {
+		c.Version = o.Version
+	}
+
+	if c.Tasks == nil {
+		c.Tasks = make(map[string]*task.Task, len(o.Tasks))
+	}
+
+	for k, t := range o.Tasks {
+		c.Tasks[k] = t
+	}
+
+	seen := make(map[string]bool, len(c.order))
+	for _, k := range c.order {
+		seen[k] = true
+	}
+
+	for _, k := range o.order {
+		if !seen[k] {
+			c.order = append(c.order, k)
+			seen[k] = true
+		}
+	}
+
+	c.errs = append(c.errs, o.errs...)
+	c.fetched = append(c.fetched, o.fetched...)
+	c.files = append(c.files, o.files...)
+	c.includes = append(c.includes, o.includes...)
+}
+
+func mergeMap(dst, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = make(map[string]interface{}, len(src))
+	}
+
+	for k, v := range src {
+		if old, ok := dst[k]; ok {
+			dst[k] = task.MergeValue(old, v)
+		} else {
+			dst[k] = v
+		}
+	}
+
+	return dst
+}
+
+// ReadFiles creates a new config struct from multiple files that are merged
+// in order, later files overrides earlier files. The format is detected from
+// each file's extension unless given and the files are preprocessed when data
+// is not nil. Unlike ReadFile all files must exist.
+func ReadFiles(format string, data map[string]interface{}, paths ...string) (*Config, error) {
+	var res *Config
+
+	for _, path := range paths {
+		// Files are not looked up in parent directories when merging.
+		if _, err := os.Stat(path); err != nil {
+			return nil, err
+		}
+
+		opts := readOptions{format: format, template: data}
+
+		// Share the cache since it can only be opened once.
+		if res != nil {
+			opts.cache = res.cache
+		}
+
+		c, err := readFile(opts, path)
+		if err != nil {
+			if res != nil {
+				res.Close()
+			}
+
+			return nil, err
+		}
+
+		if res == nil {
+			res = c
+			continue
+		}
+
+		res.Merge(c)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadFiles(t *testing.T) {
+	defer disableCache()()
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	base := filepath.Join(dir, "base.yml")
+	overrides := filepath.Join(dir, "overrides.json")
+
+	ioutil.WriteFile(base, []byte(`
+clean_env: true
+quiet: true
+args:
+  db:
+    host: localhost
+    port: 5432
+variables:
+  ENV: dev
+  REGION: eu
+tasks:
+  build:
+    summary: Build
+    commands:
+      - go build
+  deploy:
+    summary: Deploy
+    commands:
+      - deploy
+`), 0644)
+
+	ioutil.WriteFile(overrides, []byte(`{
+  "quiet": false,
+  "args": {"db": {"host": "db"}},
+  "variables": {"ENV": "prod"},
+  "tasks": {
+    "deploy": {"summary": "Deploy to prod", "commands": ["deploy --prod"]},
+    "test": {"commands": ["go test"]}
+  }
+}`), 0644)
+
+	c, err := ReadFiles("", nil, base, overrides)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.Tasks["build"].Summary != "Build" {
+		t.Errorf("Expected: 'Build', got: %s", c.Tasks["build"].Summary)
+	}
+
+	if c.Tasks["deploy"].Summary != "Deploy to prod" || !reflect.DeepEqual(c.Tasks["deploy"].Commands.Values, []string{"deploy --prod"}) {
+		t.Errorf("Expected: task from overrides, got: %+v", c.Tasks["deploy"])
+	}
+
+	if !reflect.DeepEqual(c.List(), []string{"build", "deploy", "test"}) {
+		t.Errorf("Expected: [build deploy test], got: %v", c.List())
+	}
+
+	if !reflect.DeepEqual(c.Variables, map[string]interface{}{"ENV": "prod", "REGION": "eu"}) {
+		t.Errorf("Expected: merged variables, got: %v", c.Variables)
+	}
+
+	if !reflect.DeepEqual(c.Args["db"], map[string]interface{}{"host": "db", "port": 5432}) {
+		t.Errorf("Expected: deep merged args, got: %v", c.Args["db"])
+	}
+
+	// Bools set to false replaces true, unset bools are kept.
+	if c.Quiet || !c.CleanEnv {
+		t.Errorf("Expected: quiet false and clean_env true, got: %v and %v", c.Quiet, c.CleanEnv)
+	}
+
+	if _, err := ReadFiles("", nil, base, filepath.Join(dir, "missing.yml")); !os.IsNotExist(err) {
+		t.Errorf("Expected: not exist error, got: %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"os"
+	"time"
+
+	"github.com/frozzare/max/internal/task"
+)
+
+// defaultNotifyTimeout is used when no notify timeout is configured.
+const defaultNotifyTimeout = 10 * time.Second
+
+// Notify represents a webhook that is notified when a run is done.
+type Notify struct {
+	URL     string
+	Timeout string
+}
+
+// WebhookURL returns the webhook url with environment variables expanded.
+func (n *Notify) WebhookURL() string {
+	return os.ExpandEnv(n.URL)
+}
+
+// TimeoutDuration returns the notify timeout, the default timeout
+// is used when the timeout is missing or invalid.
+func (n *Notify) TimeoutDuration() time.Duration {
+	if d, err := task.ParseDuration(n.Timeout); err == nil && d > 0 {
+		return d
+	}
+
+	return defaultNotifyTimeout
+}
@@ -0,0 +1,63 @@
+package config
+
+import "net/http"
+
+// ReadOption represents a option used when reading configs.
+type ReadOption func(*readOptions)
+
+// HTTPClient returns a read option configured with a http client used for
+// url includes, e.g for custom tls configuration. Default is a client with
+// sane timeouts.
+func HTTPClient(client *http.Client) ReadOption {
+	return func(o *readOptions) {
+		o.client = client
+	}
+}
+
+// WithFormat returns a read option that reads the config in the given format
+// instead of detecting it from the file extension, like ReadFileFormat.
+func WithFormat(format string) ReadOption {
+	return func(o *readOptions) {
+		o.format = format
+	}
+}
+
+// Lenient returns a read option that attempts every include and returns the
+// tasks that could be loaded together with IncludeErrors, like ReadFileLenient.
+func Lenient() ReadOption {
+	return func(o *readOptions) {
+		o.lenient = true
+	}
+}
+
+// NoCache returns a read option that don't use the cache, url includes are always downloaded.
+func NoCache() ReadOption {
+	return func(o *readOptions) {
+		o.noCache = true
+	}
+}
+
+// Refresh returns a read option that downloads url includes even when they
+// are cached and stores them in the cache, e.g to prefetch includes before
+// going offline.
+func Refresh() ReadOption {
+	return func(o *readOptions) {
+		o.refresh = true
+	}
+}
+
+// ReadFileOptions creates a new config struct from a yaml file like ReadFile
+// configured with read options.
+func ReadFileOptions(path string, opts ...ReadOption) (*Config, error) {
+	return readFile(newReadOptions(opts), path)
+}
+
+func newReadOptions(opts []ReadOption) readOptions {
+	var o readOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return o
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHTTPClient(t *testing.T) {
+	defer disableCache()()
+
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("summary: Hello task"))
+	}))
+
+	defer server.Close()
+
+	content := "tasks:\n  hello: !http " + server.URL + "/hello.yml\n"
+
+	// The default client don't trust the test server's certificate.
+	if _, err := ReadContent(content); err == nil {
+		t.Errorf("Expected: error, got: nil")
+	}
+
+	c, err := ReadContent(content, HTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.Tasks["hello"].Summary != "Hello task" {
+		t.Errorf("Expected: 'Hello task', got: %s", c.Tasks["hello"].Summary)
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	defer disableCache()()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/hello.yml" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Write([]byte("summary: Hello task"))
+	}))
+
+	defer server.Close()
+
+	c, err := ReadContent(`
+tasks:
+  hello: !http `+server.URL+`/hello.yml
+  missing: !http `+server.URL+`/missing.yml
+  local: missing.yml
+`, Lenient(), NoCache())
+
+	if _, ok := err.(IncludeErrors); !ok {
+		t.Fatalf("Expected: IncludeErrors, got: %v", err)
+	}
+
+	if c.Cache() != nil {
+		t.Errorf("Expected: no cache, got: %v", c.Cache())
+	}
+
+	includes := c.Includes()
+
+	if len(includes) != 3 {
+		t.Fatalf("Expected: three includes, got: %d", len(includes))
+	}
+
+	if includes[0].Key != "hello" || includes[0].Err != nil {
+		t.Errorf("Expected: included hello task, got: %+v", includes[0])
+	}
+
+	if includes[1].Key != "missing" || includes[1].Err == nil {
+		t.Errorf("Expected: failed missing include, got: %+v", includes[1])
+	}
+
+	if includes[2].Key != "local" || includes[2].Err == nil {
+		t.Errorf("Expected: failed local include, got: %+v", includes[2])
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	requests := 0
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("summary: Hello task"))
+	}))
+
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "max.yml")
+	ioutil.WriteFile(path, []byte("cache_scope: project\ntasks:\n  hello: !http "+server.URL+"/hello.yml\n"), 0644)
+
+	read := func(opts ...ReadOption) []string {
+		c, err := ReadFileOptions(path, opts...)
+		if err != nil {
+			t.Fatalf("Expected: nil, got: %s", err)
+		}
+
+		c.Cache().Close()
+
+		return c.Fetched()
+	}
+
+	if fetched := read(); len(fetched) != 1 || fetched[0] != server.URL+"/hello.yml" {
+		t.Errorf("Expected: fetched hello.yml, got: %v", fetched)
+	}
+
+	if fetched := read(); len(fetched) != 0 || requests != 1 {
+		t.Errorf("Expected: cached include, got: %v and %d requests", fetched, requests)
+	}
+
+	if fetched := read(Refresh()); len(fetched) != 1 || requests != 2 {
+		t.Errorf("Expected: downloaded include, got: %v and %d requests", fetched, requests)
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/frozzare/max/internal/task"
+)
+
+// parsedFile is a parsed config file and the file info it was parsed with.
+type parsedFile struct {
+	config  *Config
+	modTime time.Time
+	size    int64
+}
+
+var parsed = struct {
+	sync.Mutex
+	files map[string]*parsedFile
+}{files: make(map[string]*parsedFile)}
+
+// ReadFileCached creates a new config struct from a yaml file like ReadFile but
+// keeps the parsed config in memory, keyed by path and modification time, and
+// returns a copy of it as long as the file is unchanged. Included files are not
+// checked for changes. Use ReadFile to bypass the cache.
+func ReadFileCached(args ...string) (*Config, error) {
+	var path string
+
+	if len(args) > 0 && args[0] != "" {
+		path = args[0]
+	} else if len(os.Getenv("MAX_CONFIG")) > 0 {
+		return ReadFile(args...)
+	}
+
+	path = findFile(path)
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return ReadFile(args...)
+	}
+
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+
+	parsed.Lock()
+	defer parsed.Unlock()
+
+	if p := parsed.files[path]; p != nil && p.modTime.Equal(fi.ModTime()) && p.size == fi.Size() {
+		return p.config.Copy(), nil
+	}
+
+	c, err := ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed.files[path] = &parsedFile{config: c, modTime: fi.ModTime(), size: fi.Size()}
+
+	return c.Copy(), nil
+}
+
+// Copy returns a copy of the config with copies of all tasks.
+func (c *Config) Copy() *Config {
+	res := *c
+	res.Args = copyMap(c.Args)
+	res.Variables = copyMap(c.Variables)
+	res.Tasks = make(map[string]*task.Task, len(c.Tasks))
+
+	for k, t := range c.Tasks {
+		res.Tasks[k] = t.Copy()
+	}
+
+	return &res
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+
+	res := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+
+	return res
+}
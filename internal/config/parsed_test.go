@@ -0,0 +1,86 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// disableCache makes CreateCache fail fast so multiple reads don't wait on the cache lock.
+func disableCache() func() {
+	home := os.Getenv("HOME")
+	homedir.DisableCache = true
+	os.Setenv("HOME", "/dev/null")
+
+	return func() {
+		os.Setenv("HOME", home)
+		homedir.DisableCache = false
+	}
+}
+
+func TestReadFileCached(t *testing.T) {
+	defer disableCache()()
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "max.yml")
+
+	write := func(summary string, mod time.Time) {
+		if err := ioutil.WriteFile(path, []byte("tasks:\n  hello:\n    summary: "+summary+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.Chtimes(path, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	write("Hello", now)
+
+	c, err := ReadFileCached(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c.Tasks["hello"].Summary = "Changed"
+
+	if c, _ = ReadFileCached(path); c.Tasks["hello"].Summary != "Hello" {
+		t.Errorf("Expected: 'Hello', got: %s", c.Tasks["hello"].Summary)
+	}
+
+	write("World", now.Add(time.Second))
+
+	if c, _ = ReadFileCached(path); c.Tasks["hello"].Summary != "World" {
+		t.Errorf("Expected: 'World', got: %s", c.Tasks["hello"].Summary)
+	}
+}
+
+func BenchmarkReadFile(b *testing.B) {
+	defer disableCache()()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadFile("./config.yml"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReadFileCached(b *testing.B) {
+	defer disableCache()()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadFileCached("./config.yml"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/frozzare/max/internal/task"
+)
+
+// Preprocess delimiters, different from the task template delimiters so
+// task templates are kept as is for the task to render.
+const (
+	preprocessLeft  = "{%"
+	preprocessRight = "%}"
+)
+
+// PreprocessData returns the data used to preprocess config files, environment
+// variables are available under .env and arguments under .args.
+func PreprocessData(args map[string]interface{}) map[string]interface{} {
+	env := make(map[string]string)
+
+	for _, kv := range os.Environ() {
+		if i := strings.Index(kv, "="); i > 0 {
+			env[kv[:i]] = kv[i+1:]
+		}
+	}
+
+	if args == nil {
+		args = make(map[string]interface{})
+	}
+
+	return map[string]interface{}{
+		"args": args,
+		"env":  env,
+	}
+}
+
+// Preprocess renders the raw config with go text template before it's parsed,
+// so keys and tasks can depend on arguments and environment variables, e.g
+// {% if .env.CI %}. Missing environment variables renders as empty strings.
+func Preprocess(content []byte, data map[string]interface{}) ([]byte, error) {
+	tmpl, err := template.New("config").Funcs(task.TemplateFuncs()).Delims(preprocessLeft, preprocessRight).Option("missingkey=zero").Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("max: can't preprocess config: %s", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("max: can't preprocess config: %s", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ReadFileTemplate creates a new config struct from a file like ReadFileFormat
+// but preprocesses the file with the data before it's parsed.
+func ReadFileTemplate(format string, data map[string]interface{}, args ...string) (*Config, error) {
+	if data == nil {
+		data = PreprocessData(nil)
+	}
+
+	return readFile(readOptions{format: format, template: data}, args...)
+}
+
+// ReadContentTemplate creates a new config struct from a string like
+// ReadContentFormat but preprocesses the content with the data before it's parsed.
+func ReadContentTemplate(content, format string, data map[string]interface{}) (*Config, error) {
+	if data == nil {
+		data = PreprocessData(nil)
+	}
+
+	return readFormat([]byte(content), readOptions{format: format, template: data})
+}
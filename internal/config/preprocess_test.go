@@ -0,0 +1,49 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+const preprocessConfig = `tasks:
+  hello:
+    commands:
+      - echo {{ .name }}
+{% if .env.MAX_PREPROCESS_CI %}
+  ci:
+    summary: {% .args.summary %}
+{% end %}
+`
+
+func TestReadContentTemplate(t *testing.T) {
+	defer disableCache()()
+
+	c, err := ReadContentTemplate(preprocessConfig, "", PreprocessData(nil))
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.Tasks["ci"] != nil {
+		t.Errorf("Expected: no ci task, got: %v", c.Tasks["ci"])
+	}
+
+	if got := c.Tasks["hello"].Commands.Values[0]; got != "echo {{ .name }}" {
+		t.Errorf("Expected: task template to be kept, got: %s", got)
+	}
+
+	os.Setenv("MAX_PREPROCESS_CI", "true")
+	defer os.Unsetenv("MAX_PREPROCESS_CI")
+
+	c, err = ReadContentTemplate(preprocessConfig, "", PreprocessData(map[string]interface{}{"summary": "CI task"}))
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.Tasks["ci"] == nil || c.Tasks["ci"].Summary != "CI task" {
+		t.Errorf("Expected: 'CI task', got: %v", c.Tasks["ci"])
+	}
+
+	if _, err := ReadContentTemplate("{% if %}", "", nil); err == nil {
+		t.Error("Expected: error, got: nil")
+	}
+}
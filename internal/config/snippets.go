@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/frozzare/go/yaml2"
+	"gopkg.in/yaml.v2"
+)
+
+// expandSnippets replaces {use: name} items in a task's commands with the
+// commands of the named snippet.
+func expandSnippets(id string, v interface{}, snippets map[string]yaml2.List) error {
+	m, ok := stringMap(v)
+	if !ok {
+		return nil
+	}
+
+	items, ok := m["commands"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var commands []interface{}
+	expanded := false
+
+	for _, item := range items {
+		use, ok := stringMap(item)
+		if !ok || use["use"] == nil {
+			commands = append(commands, item)
+			continue
+		}
+
+		name := fmt.Sprintf("%v", use["use"])
+
+		snippet, ok := snippets[name]
+		if !ok {
+			return fmt.Errorf("max: task %s uses unknown snippet %s", id, name)
+		}
+
+		for _, c := range snippet.Values {
+			commands = append(commands, c)
+		}
+
+		expanded = true
+	}
+
+	if !expanded {
+		return nil
+	}
+
+	switch t := v.(type) {
+	case yaml.MapSlice:
+		for i, item := range t {
+			if item.Key == "commands" {
+				t[i].Value = commands
+			}
+		}
+	case map[interface{}]interface{}:
+		t["commands"] = commands
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSnippets(t *testing.T) {
+	defer disableCache()()
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	deploy := filepath.Join(dir, "deploy.yml")
+	ioutil.WriteFile(deploy, []byte("commands:\n  - {use: setup}\n  - ./deploy.sh\n"), 0644)
+
+	c, err := ReadContent(`
+snippets:
+  setup:
+    - set -a
+    - . ./.env
+  greet: echo hello
+tasks:
+  build:
+    commands:
+      - use: setup
+      - go build
+      - {use: greet}
+  deploy: ` + deploy + `
+`)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	exp := []string{"set -a", ". ./.env", "go build", "echo hello"}
+	if got := c.Tasks["build"].Commands.Values; !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected: %v, got: %v", exp, got)
+	}
+
+	exp = []string{"set -a", ". ./.env", "./deploy.sh"}
+	if got := c.Tasks["deploy"].Commands.Values; !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected: %v, got: %v", exp, got)
+	}
+
+	_, err = ReadContent(`
+tasks:
+  build:
+    commands:
+      - use: missing
+`)
+	if err == nil || err.Error() != "max: task build uses unknown snippet missing" {
+		t.Errorf("Expected: unknown snippet error, got: %v", err)
+	}
+}
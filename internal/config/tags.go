@@ -0,0 +1,174 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Include reference kinds. References tagged with !include are files,
+// resolved relative to the file or url they are defined in, and references
+// tagged with !http are urls. Untagged references are detected from the reference.
+const (
+	refAny  = ""
+	refFile = "include"
+	refHTTP = "http"
+)
+
+var (
+	blockRegexp = regexp.MustCompile(`:\s*[|>][-+0-9]*\s*(#.*)?$`)
+	tagRegexp   = regexp.MustCompile(`^(\s*(?:-\s+)?(?:(?:"[^"]*"|'[^']*'|[^\s#'"!][^#]*?)\s*:\s+)?)!(include|http)\s+(.+?)(\s+#.*)?$`)
+)
+
+// rewriteTags rewrites values tagged with !include or !http to maps with the
+// tag name as key, e.g !http https://example.com/deploy.yml is rewritten to
+// {http: "https://example.com/deploy.yml"}, so includeRef can unmarshal them.
+// The yaml parser drops local tags before unmarshalers are called, so the
+// tags are rewritten in the content. Only block mapping values and list items
+// are rewritten, tags in flow mappings and lists are not supported.
+func rewriteTags(content []byte) []byte {
+	if !bytes.Contains(content, []byte("!include")) && !bytes.Contains(content, []byte("!http")) {
+		return content
+	}
+
+	lines := strings.Split(string(content), "\n")
+	block := -1
+
+	for i, line := range lines {
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+
+		// Skip lines in block scalars, e.g scripts.
+		if block != -1 {
+			if len(strings.TrimSpace(line)) == 0 || indent > block {
+				continue
+			}
+
+			block = -1
+		}
+
+		if blockRegexp.MatchString(line) {
+			block = indent
+			continue
+		}
+
+		m := tagRegexp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		ref := strings.TrimSpace(m[3])
+		if !strings.HasPrefix(ref, `"`) && !strings.HasPrefix(ref, `'`) {
+			ref = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(ref) + `"`
+		}
+
+		lines[i] = fmt.Sprintf("%s{%s: %s}%s", m[1], m[2], ref, m[4])
+	}
+
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// includeRef represents a include reference, a untagged string reference or
+// a map with a include, file or http key and optional args, e.g
+// {file: build.yml, args: {target: prod}}. Values tagged with !include or
+// !http are unmarshaled from the maps rewriteTags rewrites them to.
+type includeRef struct {
+	kind string
+	ref  string
+	args map[string]interface{}
+}
+
+// errNotRef is returned when unmarshaling a value that isn't a include reference.
+var errNotRef = errors.New("max: value is not a include reference")
+
+// UnmarshalYAML implements yaml packages interface to unmarshal include references.
+func (r *includeRef) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var v interface{}
+	if err := unmarshal(&v); err != nil {
+		return err
+	}
+
+	if s, ok := v.(string); ok {
+		r.kind, r.ref = refAny, s
+		return nil
+	}
+
+	m, ok := stringMap(v)
+	if !ok {
+		return errNotRef
+	}
+
+	args, hasArgs := m["args"]
+	if (hasArgs && len(m) != 2) || (!hasArgs && len(m) != 1) {
+		return errNotRef
+	}
+
+	for _, k := range []string{refFile, "file", refHTTP} {
+		if s, ok := m[k].(string); ok {
+			r.kind, r.ref = k, s
+		}
+	}
+
+	if len(r.ref) == 0 {
+		return errNotRef
+	}
+
+	if r.kind == "file" {
+		r.kind = refFile
+	}
+
+	if hasArgs {
+		if r.args, ok = stringMap(args); !ok {
+			return errNotRef
+		}
+	}
+
+	return nil
+}
+
+// tagRef returns the include kind, reference and args of a decoded value,
+// see includeRef.
+func tagRef(v interface{}) (string, string, map[string]interface{}, bool) {
+	switch v.(type) {
+	case string, yaml.MapSlice, map[interface{}]interface{}:
+	default:
+		return "", "", nil, false
+	}
+
+	buf, err := yaml.Marshal(v)
+	if err != nil {
+		return "", "", nil, false
+	}
+
+	var r includeRef
+	if err := yaml.Unmarshal(buf, &r); err != nil {
+		return "", "", nil, false
+	}
+
+	return r.kind, r.ref, r.args, true
+}
+
+// stringMap converts a yaml map to a map with string keys.
+func stringMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case yaml.MapSlice:
+		res := make(map[string]interface{}, len(m))
+		for _, item := range m {
+			res[fmt.Sprintf("%v", item.Key)] = item.Value
+		}
+		return res, true
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			res[fmt.Sprintf("%v", k)] = v
+		}
+		return res, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestRewriteTags(t *testing.T) {
+	tests := []struct {
+		in  string
+		exp string
+	}{
+		{"hello: !include hello.yml", `hello: {include: "hello.yml"}`},
+		{"  deploy: !http https://example.com/deploy.yml # remote", `  deploy: {http: "https://example.com/deploy.yml"} # remote`},
+		{"hello: !include 'hello world.yml'", `hello: {include: 'hello world.yml'}`},
+		{"!include common/deploy.yml", `{include: "common/deploy.yml"}`},
+		{"script: |\n  echo key: !include hello.yml", "script: |\n  echo key: !include hello.yml"},
+		{"\"my task\": !include hello.yml", `"my task": {include: "hello.yml"}`},
+		{"summary: hello", "summary: hello"},
+	}
+
+	for _, test := range tests {
+		if got := string(rewriteTags([]byte(test.in))); got != test.exp {
+			t.Errorf("Expected: %q, got: %q", test.exp, got)
+		}
+	}
+}
+
+func TestIncludeRef(t *testing.T) {
+	tests := []struct {
+		in   string
+		kind string
+		ref  string
+		args map[string]interface{}
+		err  bool
+	}{
+		{"hello.yml", refAny, "hello.yml", nil, false},
+		{"{include: hello.yml}", refFile, "hello.yml", nil, false},
+		{"{file: hello.yml, args: {target: prod}}", refFile, "hello.yml", map[string]interface{}{"target": "prod"}, false},
+		{"{http: https://example.com/deploy.yml}", refHTTP, "https://example.com/deploy.yml", nil, false},
+		{"1", "", "", nil, true},
+		{"{summary: hello}", "", "", nil, true},
+		{"{file: hello.yml, summary: hello}", "", "", nil, true},
+	}
+
+	for _, test := range tests {
+		var r includeRef
+		err := yaml.Unmarshal([]byte(test.in), &r)
+
+		if test.err {
+			if err == nil {
+				t.Errorf("Expected: error for %s, got: %+v", test.in, r)
+			}
+
+			continue
+		}
+
+		if err != nil || r.kind != test.kind || r.ref != test.ref || !reflect.DeepEqual(r.args, test.args) {
+			t.Errorf("Expected: %s %s %v for %s, got: %+v, %v", test.kind, test.ref, test.args, test.in, r, err)
+		}
+	}
+}
+
+func TestIncludeTags(t *testing.T) {
+	defer disableCache()()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("summary: Deploy task"))
+	}))
+
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	// A local path with http in it is still a file when tagged with !include.
+	file := filepath.Join(dir, "http-tasks.yml")
+	ioutil.WriteFile(file, []byte("summary: Local task"), 0644)
+
+	c, err := ReadContent(`
+tasks:
+  local: !include ` + file + `
+  deploy: !http ` + server.URL + `/deploy.yml
+`)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.Tasks["local"] == nil || c.Tasks["local"].Summary != "Local task" {
+		t.Errorf("Expected: 'Local task', got: %+v", c.Tasks["local"])
+	}
+
+	if c.Tasks["deploy"] == nil || c.Tasks["deploy"].Summary != "Deploy task" {
+		t.Errorf("Expected: 'Deploy task', got: %+v", c.Tasks["deploy"])
+	}
+}
+
+func TestIncludeArgs(t *testing.T) {
+	defer disableCache()()
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "build.yml")
+	ioutil.WriteFile(file, []byte(`
+args:
+  target: dev
+  arch: amd64
+commands:
+  - make {{ .target }} {{ .arch }}
+`), 0644)
+
+	c, err := ReadContent(`
+tasks:
+  build: !include ` + file + `
+  build-prod:
+    file: ` + file + `
+    args:
+      target: prod
+`)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if c.Tasks["build"].Args["target"] != "dev" {
+		t.Errorf("Expected: 'dev', got: %v", c.Tasks["build"].Args["target"])
+	}
+
+	prod := c.Tasks["build-prod"]
+	if prod.Args["target"] != "prod" || prod.Args["arch"] != "amd64" {
+		t.Errorf("Expected: merged args, got: %v", prod.Args)
+	}
+
+	if err := prod.Prepare(); err != nil || prod.Commands.Values[0] != "make prod amd64" {
+		t.Errorf("Expected: 'make prod amd64', got: %v, %v", prod.Commands.Values, err)
+	}
+}
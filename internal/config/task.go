@@ -0,0 +1,53 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/frozzare/max/internal/task"
+	"gopkg.in/yaml.v2"
+)
+
+// ErrEmptyTask is returned when a task document is empty.
+var ErrEmptyTask = errors.New("max: task is empty, add commands to the task")
+
+// ReadTask reads a single task from a yaml or json document, e.g a task
+// piped to max run -. The task is unmarshaled like tasks in config files.
+func ReadTask(r io.Reader) (*task.Task, error) {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(bytes.TrimSpace(buf)) == 0 {
+		return nil, ErrEmptyTask
+	}
+
+	var t *task.Task
+
+	if err := yaml.Unmarshal(buf, &t); err != nil {
+		if _, ok := err.(*task.UnitError); ok {
+			return nil, err
+		}
+
+		return nil, ErrUnmarshal
+	}
+
+	if t == nil {
+		return nil, ErrEmptyTask
+	}
+
+	return t, nil
+}
+
+// AddTask adds a task to the config, e.g a task read with ReadTask.
+// A existing task with the same id is replaced.
+func (c *Config) AddTask(id string, t *task.Task) {
+	if c.Tasks == nil {
+		c.Tasks = make(map[string]*task.Task)
+	}
+
+	c.Tasks[id] = t
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadTask(t *testing.T) {
+	task, err := ReadTask(strings.NewReader("summary: Hello\ncommands:\n  - echo hi\n"))
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if task.Summary != "Hello" || len(task.Commands.Values) != 1 || task.Commands.Values[0] != "echo hi" {
+		t.Errorf("Expected: task with commands, got: %v", task)
+	}
+
+	if _, err := ReadTask(strings.NewReader(`{"commands": ["echo hi"]}`)); err != nil {
+		t.Errorf("Expected: json task, got: %s", err)
+	}
+
+	if _, err := ReadTask(strings.NewReader("  \n")); err != ErrEmptyTask {
+		t.Errorf("Expected: %s, got: %v", ErrEmptyTask, err)
+	}
+
+	if _, err := ReadTask(strings.NewReader("- echo hi\n")); err != ErrUnmarshal {
+		t.Errorf("Expected: %s, got: %v", ErrUnmarshal, err)
+	}
+}
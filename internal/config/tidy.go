@@ -0,0 +1,396 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ErrFormatChanged is returned when formatting would change the config.
+var ErrFormatChanged = errors.New("max: can't format config, the formatted config is not equal to the config")
+
+// anchorRegexp matches yaml anchors, aliases and merge keys.
+var anchorRegexp = regexp.MustCompile(`(:|^\s*-)\s+[&*][^\s]+|<<\s*:`)
+
+// fmtNode represents a mapping key with its leading comments and either
+// nested mapping keys or a raw block, e.g a list or a block scalar. The
+// line of the key and the end of its block are used by documents.
+type fmtNode struct {
+	index    int
+	indent   int
+	end      int
+	lead     []string
+	key      string
+	raw      string
+	value    string
+	children []*fmtNode
+	body     []string
+}
+
+// Format formats a yaml config. Mappings are indented with two spaces,
+// keys are sorted with version first and tasks last at the top level
+// while tasks keeps their order, string includes are written with the
+// !include or !http tag and runs of blank lines between keys are collapsed.
+// Comments are kept with the key they are written above. Lists and block
+// scalars are kept as is but indented under their key. The config is not
+// changed if the formatted config is not equal to it. Formatting is
+// idempotent.
+func Format(content []byte) ([]byte, error) {
+	var v interface{}
+	if err := yaml.Unmarshal(rewriteTags(content), &v); err != nil {
+		return nil, err
+	}
+
+	if _, ok := v.(map[interface{}]interface{}); !ok && v != nil {
+		return nil, errors.New("max: can't format config, the config is not a mapping")
+	}
+
+	lines := strings.Split(string(content), "\n")
+
+	nodes, trail, err := parseDocument(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	// Comments separated from the first key by a blank line and the start
+	// marker are a header.
+	var header []string
+	if len(nodes) > 0 {
+		for i := len(nodes[0].lead) - 1; i >= 0; i-- {
+			if len(nodes[0].lead[i]) == 0 || markerRegexp.MatchString(nodes[0].lead[i]) {
+				header, nodes[0].lead = nodes[0].lead[:i+1], nodes[0].lead[i+1:]
+				break
+			}
+		}
+	}
+
+	sorted := !anchorRegexp.Match(content)
+
+	var buf bytes.Buffer
+	writeLines(&buf, header, 0)
+	writeNodes(&buf, nodes, nil, 0, sorted)
+	writeLines(&buf, trail, 0)
+
+	res := []byte(strings.TrimRight(strings.TrimLeft(buf.String(), "\n"), "\n") + "\n")
+
+	var got interface{}
+	if err := yaml.Unmarshal(rewriteTags(res), &got); err != nil {
+		return nil, ErrFormatChanged
+	}
+
+	if !reflect.DeepEqual(normalizeIncludes(v), normalizeIncludes(got)) {
+		return nil, ErrFormatChanged
+	}
+
+	return res, nil
+}
+
+// FormatFile formats a yaml config file and reports whether the file
+// was changed.
+func FormatFile(path string) (bool, error) {
+	if detectFormat(path) == FormatJSON {
+		return false, fmt.Errorf("max: can't format %s, only yaml configs can be formatted", path)
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return false, err
+	}
+
+	res, err := Format(buf)
+	if err != nil {
+		return false, err
+	}
+
+	if bytes.Equal(buf, res) {
+		return false, nil
+	}
+
+	return true, ioutil.WriteFile(path, res, 0644)
+}
+
+// parseDocument parses the mapping keys of a document. A start marker is
+// only allowed before the first key and a end marker after the last key so
+// only single documents are parsed.
+func parseDocument(lines []string) ([]*fmtNode, []string, error) {
+	key, end := false, false
+
+	for i, l := range lines {
+		switch {
+		case markerRegexp.MatchString(l) && strings.HasPrefix(l, "---"):
+			if key || end {
+				return nil, nil, fmt.Errorf("max: can't parse config, unexpected document at line %d", i+1)
+			}
+		case markerRegexp.MatchString(l):
+			end = true
+		case !isBlank(l):
+			if end {
+				return nil, nil, fmt.Errorf("max: can't parse config, unexpected line %d after the end of the document: %s", i+1, strings.TrimSpace(l))
+			}
+
+			key = true
+		}
+	}
+
+	return parseNodes(lines, 0, len(lines))
+}
+
+// parseNodes parses the mapping keys in the range, it's used by both
+// Format and documents.
+func parseNodes(lines []string, start, end int) ([]*fmtNode, []string, error) {
+	var nodes []*fmtNode
+	var lead []string
+
+	for i := start; i < end; {
+		if isBlank(lines[i]) {
+			lead = append(lead, strings.TrimSpace(lines[i]))
+			i++
+			continue
+		}
+
+		l, ok := parseLine(i, lines[i])
+		if !ok || strings.HasPrefix(strings.TrimSpace(lines[i]), "- ") {
+			return nil, nil, fmt.Errorf("max: can't parse config, unexpected line %d: %s", i+1, strings.TrimSpace(lines[i]))
+		}
+
+		next := blockEnd(lines, l, end)
+
+		n := &fmtNode{index: i, indent: l.indent, end: next, lead: collapseBlank(lead), key: l.key, raw: l.raw, value: l.value}
+		lead = nil
+
+		if next > i+1 {
+			value, _ := splitComment(n.value)
+			first := i + 1
+			for isBlank(lines[first]) {
+				first++
+			}
+
+			if len(value) == 0 && keyRegexp.MatchString(lines[first]) && !strings.HasPrefix(strings.TrimSpace(lines[first]), "- ") {
+				children, trail, err := parseNodes(lines, i+1, next)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				n.children = children
+				if len(trail) > 0 {
+					return nil, nil, fmt.Errorf("max: can't parse config, unexpected comment at line %d", next)
+				}
+			} else {
+				n.body = lines[i+1 : next]
+			}
+		}
+
+		nodes = append(nodes, n)
+		i = next
+	}
+
+	return nodes, collapseBlank(lead), nil
+}
+
+// writeNodes writes the nodes at the indentation, path contains the keys
+// of the parent mappings.
+func writeNodes(buf *bytes.Buffer, nodes []*fmtNode, path []string, indent int, sorted bool) {
+	tasks := len(path) == 1 && path[0] == "tasks"
+
+	if sorted && !tasks {
+		sortNodes(nodes, len(path) == 0)
+	}
+
+	for _, n := range nodes {
+		if tasks {
+			normalizeInclude(n)
+		}
+
+		writeLines(buf, n.lead, indent)
+
+		line := strings.Repeat(" ", indent) + n.raw + ":"
+		if len(n.value) > 0 {
+			line += " " + n.value
+		}
+
+		buf.WriteString(line + "\n")
+
+		writeNodes(buf, n.children, append(path, n.key), indent+2, sorted)
+		writeBody(buf, n.body, indent+2, n.value)
+	}
+}
+
+// sortNodes sorts keys by name, at the top level version is first and
+// tasks is last.
+func sortNodes(nodes []*fmtNode, top bool) {
+	rank := func(n *fmtNode) int {
+		if !top {
+			return 1
+		}
+
+		switch n.key {
+		case "version":
+			return 0
+		case "tasks":
+			return 2
+		default:
+			return 1
+		}
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if ri, rj := rank(nodes[i]), rank(nodes[j]); ri != rj {
+			return ri < rj
+		}
+
+		return nodes[i].key < nodes[j].key
+	})
+}
+
+// normalizeInclude writes string includes and include maps without args
+// with the !include or !http tag.
+func normalizeInclude(n *fmtNode) {
+	value, comment := splitComment(n.value)
+
+	// Include maps, e.g file: deploy.yml, are written as tagged strings.
+	if len(value) == 0 && len(n.body) == 0 && len(n.children) == 1 {
+		c := n.children[0]
+		v, _ := splitComment(c.value)
+
+		if (c.key == "file" || c.key == refFile || c.key == refHTTP) && len(c.children) == 0 && len(c.body) == 0 && len(v) > 0 && len(c.lead) == 0 {
+			kind := refFile
+			if c.key == refHTTP {
+				kind = refHTTP
+			}
+
+			n.value, n.children = "!"+kind+" "+c.value, nil
+			return
+		}
+	}
+
+	if len(value) == 0 || len(n.body) > 0 || strings.ContainsAny(value[:1], "!{[|>&*") {
+		return
+	}
+
+	var s interface{}
+	if err := yaml.Unmarshal([]byte(value), &s); err != nil {
+		return
+	}
+
+	ref, ok := s.(string)
+	if !ok {
+		return
+	}
+
+	kind := refFile
+	if isHTTP(ref) {
+		kind = refHTTP
+	}
+
+	n.value = "!" + kind + " " + value + comment
+}
+
+// normalizeIncludes returns the value with task includes as references so
+// configs with different include forms are equal.
+func normalizeIncludes(v interface{}) interface{} {
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return v
+	}
+
+	tasks, ok := m["tasks"].(map[interface{}]interface{})
+	if !ok {
+		return v
+	}
+
+	res := make(map[interface{}]interface{}, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+
+	norm := make(map[interface{}]interface{}, len(tasks))
+	for k, t := range tasks {
+		if _, ref, args, ok := tagRef(t); ok && len(args) == 0 {
+			norm[k] = ref
+		} else {
+			norm[k] = t
+		}
+	}
+
+	res["tasks"] = norm
+
+	return res
+}
+
+// writeLines writes comments and blank lines at the indentation.
+func writeLines(buf *bytes.Buffer, lines []string, indent int) {
+	for _, l := range lines {
+		if len(l) == 0 {
+			buf.WriteString("\n")
+		} else {
+			buf.WriteString(strings.Repeat(" ", indent) + l + "\n")
+		}
+	}
+}
+
+// writeBody writes a raw block shifted to the indentation, blocks with
+// explicit indentation indicators are written as is.
+func writeBody(buf *bytes.Buffer, body []string, indent int, value string) {
+	if len(body) == 0 {
+		return
+	}
+
+	v, _ := splitComment(value)
+	if strings.HasPrefix(v, "|") || strings.HasPrefix(v, ">") {
+		if strings.ContainsAny(v, "123456789") {
+			writeRaw(buf, body)
+			return
+		}
+	}
+
+	min := -1
+	for _, l := range body {
+		if len(strings.TrimSpace(l)) == 0 {
+			continue
+		}
+
+		if n := len(l) - len(strings.TrimLeft(l, " ")); min == -1 || n < min {
+			min = n
+		}
+	}
+
+	for _, l := range body {
+		// Lines with spaces beyond the block indentation are content.
+		if len(strings.TrimSpace(l)) == 0 && len(l) <= min {
+			buf.WriteString("\n")
+			continue
+		}
+
+		buf.WriteString(strings.Repeat(" ", indent) + l[min:] + "\n")
+	}
+}
+
+func writeRaw(buf *bytes.Buffer, lines []string) {
+	for _, l := range lines {
+		buf.WriteString(l + "\n")
+	}
+}
+
+// collapseBlank collapses runs of blank lines in comments and blank lines
+// written between keys to one blank line, blocks are not collapsed so the
+// content of block scalars is kept.
+func collapseBlank(lines []string) []string {
+	var res []string
+
+	for i, l := range lines {
+		if len(l) == 0 && i > 0 && len(lines[i-1]) == 0 {
+			continue
+		}
+
+		res = append(res, l)
+	}
+
+	return res
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const unformatted = `# Project tasks
+
+variables:
+    REGION: eu
+    APP: max # app name
+version: "1"
+tasks:
+    # Build the app
+    build:
+        summary: Build
+        commands:
+        - go build
+        - |
+          echo multi
+          echo line
+    lint: lint.yml
+
+
+    deploy: "https://example.com/deploy.yml"
+    other:
+      file: other.yml
+args:
+  name: x
+`
+
+const formatted = `# Project tasks
+
+version: "1"
+args:
+  name: x
+variables:
+  APP: max # app name
+  REGION: eu
+tasks:
+  # Build the app
+  build:
+    commands:
+      - go build
+      - |
+        echo multi
+        echo line
+    summary: Build
+  lint: !include lint.yml
+
+  deploy: !http "https://example.com/deploy.yml"
+  other: !include other.yml
+`
+
+func TestFormat(t *testing.T) {
+	got, err := Format([]byte(unformatted))
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if string(got) != formatted {
+		t.Errorf("Expected: %s, got: %s", formatted, got)
+	}
+
+	again, err := Format(got)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if string(again) != formatted {
+		t.Errorf("Expected: idempotent format, got: %s", again)
+	}
+
+	// Anchors must be declared before aliases so keys are not sorted.
+	anchors := "variables:\n  b: &b value\n  a: *b\n"
+	if got, err := Format([]byte(anchors)); err != nil || string(got) != anchors {
+		t.Errorf("Expected: %q, got: %q, %v", anchors, got, err)
+	}
+
+	if _, err := Format([]byte("- a\n- b\n")); err == nil {
+		t.Error("Expected: error for non mapping config, got: nil")
+	}
+}
+
+func TestFormatFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "max.yml")
+	ioutil.WriteFile(path, []byte(unformatted), 0644)
+
+	if changed, err := FormatFile(path); err != nil || !changed {
+		t.Fatalf("Expected: changed file, got: %v, %v", changed, err)
+	}
+
+	if changed, err := FormatFile(path); err != nil || changed {
+		t.Errorf("Expected: unchanged file, got: %v, %v", changed, err)
+	}
+
+	if buf, _ := ioutil.ReadFile(path); string(buf) != formatted {
+		t.Errorf("Expected: %s, got: %s", formatted, buf)
+	}
+}
+
+func TestFormatBlockScalar(t *testing.T) {
+	content := "tasks:\n  build:\n    commands:\n      - |\n        echo a\n\n\n        echo b\n    summary: Build\n"
+
+	got, err := Format([]byte(content))
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if string(got) != content {
+		t.Errorf("Expected: %q, got: %q", content, got)
+	}
+}
+
+func TestFormatDocumentMarkers(t *testing.T) {
+	got, err := Format([]byte("---\ntasks:\n    build:\n        summary: Build\nversion: \"1\"\n...\n"))
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	exp := "---\nversion: \"1\"\ntasks:\n  build:\n    summary: Build\n...\n"
+	if string(got) != exp {
+		t.Errorf("Expected: %s, got: %s", exp, got)
+	}
+
+	for _, content := range []string{"version: \"1\"\n---\nversion: \"2\"\n", "version: \"1\"\n...\nversion: \"2\"\n"} {
+		if _, err := Format([]byte(content)); err == nil {
+			t.Errorf("Expected: error for multiple documents, got: nil")
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package config
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	argRefRegexp      = regexp.MustCompile(`\.([a-zA-Z_][a-zA-Z0-9_]*)`)
+	templateRegexp    = regexp.MustCompile(`{{[^}]*}}`)
+	variableRefRegexp = regexp.MustCompile(`\$\{?([a-zA-Z_][a-zA-Z0-9_]*)`)
+
+	// lookPath is used to find binaries on PATH that task names shadows.
+	lookPath = exec.LookPath
+)
+
+// builtins contains common shell builtins that task names should not shadow.
+var builtins = map[string]bool{
+	"alias":   true,
+	"bg":      true,
+	"cd":      true,
+	"command": true,
+	"echo":    true,
+	"eval":    true,
+	"exec":    true,
+	"exit":    true,
+	"export":  true,
+	"false":   true,
+	"fg":      true,
+	"jobs":    true,
+	"kill":    true,
+	"printf":  true,
+	"pwd":     true,
+	"read":    true,
+	"return":  true,
+	"set":     true,
+	"shift":   true,
+	"source":  true,
+	"test":    true,
+	"trap":    true,
+	"true":    true,
+	"type":    true,
+	"ulimit":  true,
+	"umask":   true,
+	"unalias": true,
+	"unset":   true,
+	"wait":    true,
+}
+
+// Warning represents a config problem that don't prevent tasks from running.
+type Warning struct {
+	Task    string
+	Message string
+}
+
+// String returns the warning message prefixed with the task id if any.
+func (w Warning) String() string {
+	if len(w.Task) == 0 {
+		return w.Message
+	}
+
+	return fmt.Sprintf("task %s: %s", w.Task, w.Message)
+}
+
+// Validate validates all tasks and returns warnings for problems
+// that don't prevent tasks from running, e.g unused args and variables
+// or task names that shadows shell builtins and binaries on PATH.
+func (c *Config) Validate() ([]Warning, error) {
+	var warnings []Warning
+
+	used := make(map[string]bool)
+
+	for _, id := range c.List() {
+		t := c.Tasks[id]
+		if t == nil {
+			continue
+		}
+
+		if err := t.Validate(); err != nil {
+			return nil, fmt.Errorf("task %s: %s", id, err)
+		}
+
+		if w, ok := shadows(id); ok {
+			warnings = append(warnings, w)
+		}
+
+		refs, err := references(t)
+		if err != nil {
+			return nil, err
+		}
+
+		for k := range refs {
+			used[k] = true
+		}
+
+		for _, k := range sortedKeys(t.Args) {
+			if !refs[k] {
+				warnings = append(warnings, Warning{id, fmt.Sprintf("arg %s is never used", k)})
+			}
+		}
+
+		for _, k := range sortedKeys(t.Variables) {
+			if !refs[k] {
+				warnings = append(warnings, Warning{id, fmt.Sprintf("variable %s is never used", k)})
+			}
+		}
+	}
+
+	for _, k := range sortedKeys(c.Args) {
+		if !used[k] {
+			warnings = append(warnings, Warning{Message: fmt.Sprintf("arg %s is never used", k)})
+		}
+	}
+
+	for _, k := range sortedKeys(c.Variables) {
+		if !used[k] {
+			warnings = append(warnings, Warning{Message: fmt.Sprintf("variable %s is never used", k)})
+		}
+	}
+
+	return warnings, nil
+}
+
+// shadows returns a warning when a task name is a shell builtin or a binary
+// found on PATH, e.g a task named ls.
+func shadows(id string) (Warning, bool) {
+	if builtins[id] {
+		return Warning{id, fmt.Sprintf("task name shadows the shell builtin %s", id)}, true
+	}
+
+	if path, err := lookPath(id); err == nil {
+		return Warning{id, fmt.Sprintf("task name shadows %s on PATH", path)}, true
+	}
+
+	return Warning{}, false
+}
+
+// references returns all template and environment variable names referenced by a task.
+func references(t interface{}) (map[string]bool, error) {
+	buf, err := yaml.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string]bool)
+
+	for _, tmpl := range templateRegexp.FindAll(buf, -1) {
+		for _, m := range argRefRegexp.FindAllSubmatch(tmpl, -1) {
+			refs[string(m[1])] = true
+		}
+	}
+
+	for _, m := range variableRefRegexp.FindAllSubmatch(buf, -1) {
+		refs[string(m[1])] = true
+	}
+
+	return refs, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
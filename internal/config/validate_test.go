@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const validateConfig = `args:
+  name: default
+  unused_arg: x
+variables:
+  NAME: max
+  UNUSED_VAR: x
+tasks:
+  hello:
+    args:
+      greeting: Hello
+      typo: x
+    variables:
+      BASE: /opt
+      BIN: ${BASE}/bin
+    commands:
+      - echo {{ .greeting }} {{ .name }} $NAME $BIN
+`
+
+func TestValidate(t *testing.T) {
+	c, err := ReadContent(validateConfig)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	warnings, err := c.Validate()
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	var got []string
+	for _, w := range warnings {
+		got = append(got, w.String())
+	}
+
+	exp := []string{
+		"task hello: arg typo is never used",
+		"arg unused_arg is never used",
+		"variable UNUSED_VAR is never used",
+	}
+
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected: %v, got: %v", exp, got)
+	}
+}
+
+func TestValidateScriptAndCommands(t *testing.T) {
+	c, err := ReadContent("tasks:\n  hello:\n    script: echo Hello\n    commands:\n      - echo Hello\n")
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if _, err := c.Validate(); err == nil {
+		t.Error("Expected: error, got: nil")
+	}
+}
+
+func TestValidateShadows(t *testing.T) {
+	defer func(fn func(string) (string, error)) {
+		lookPath = fn
+	}(lookPath)
+
+	lookPath = func(name string) (string, error) {
+		if name == "ls" {
+			return "/bin/ls", nil
+		}
+
+		return "", errors.New("not found")
+	}
+
+	c, err := ReadContent("tasks:\n  cd:\n    commands:\n      - echo cd\n  ls:\n    commands:\n      - echo ls\n  build:\n    commands:\n      - echo build\n")
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	warnings, err := c.Validate()
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	var got []string
+	for _, w := range warnings {
+		got = append(got, w.String())
+	}
+
+	exp := []string{
+		"task cd: task name shadows the shell builtin cd",
+		"task ls: task name shadows /bin/ls on PATH",
+	}
+
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected: %v, got: %v", exp, got)
+	}
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// UseVariablesJSON merges a json object into the config's variables, e.g
+// {"env": "prod", "count": 3}. Nested objects are deep merged with the
+// variables and override them like the other config's variables when
+// configs are merged. Whole numbers are integers like in yaml configs.
+func (c *Config) UseVariablesJSON(data []byte) error {
+	var v interface{}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("max: variables json is not valid: %s", err)
+	}
+
+	if dec.More() {
+		return errors.New("max: variables json is not valid: unexpected data after the object")
+	}
+
+	vars, ok := fromJSON(v).(map[string]interface{})
+	if !ok {
+		return errors.New(`max: variables json must be a object, e.g {"env": "prod"}`)
+	}
+
+	c.Variables = mergeMap(c.Variables, vars)
+
+	return nil
+}
+
+// fromJSON converts json numbers to integers or floats.
+func fromJSON(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			if int64(int(i)) == i {
+				return int(i)
+			}
+
+			return i
+		}
+
+		f, _ := x.Float64()
+		return f
+	case map[string]interface{}:
+		for k, v := range x {
+			x[k] = fromJSON(v)
+		}
+
+		return x
+	case []interface{}:
+		for i, v := range x {
+			x[i] = fromJSON(v)
+		}
+
+		return x
+	default:
+		return v
+	}
+}
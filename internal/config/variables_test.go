@@ -0,0 +1,37 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUseVariablesJSON(t *testing.T) {
+	c := &Config{Variables: map[string]interface{}{
+		"env":    "dev",
+		"region": "eu",
+		"db":     map[interface{}]interface{}{"host": "localhost", "port": 5432},
+	}}
+
+	if err := c.UseVariablesJSON([]byte(`{"env": "prod", "count": 3, "ratio": 0.5, "hosts": ["a", "b"], "db": {"host": "db.prod"}}`)); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	exp := map[string]interface{}{
+		"env":    "prod",
+		"region": "eu",
+		"count":  3,
+		"ratio":  0.5,
+		"hosts":  []interface{}{"a", "b"},
+		"db":     map[string]interface{}{"host": "db.prod", "port": 5432},
+	}
+
+	if !reflect.DeepEqual(c.Variables, exp) {
+		t.Errorf("Expected: %v, got: %v", exp, c.Variables)
+	}
+
+	for _, s := range []string{`{"env":`, `["prod"]`, `"prod"`, `{} {}`} {
+		if err := c.UseVariablesJSON([]byte(s)); err == nil {
+			t.Errorf("Expected: error for %s, got: nil", s)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// Verbosity returns the default quiet and verbose values. The MAX_QUIET and
+// MAX_VERBOSE environment variables, e.g MAX_QUIET=1, overrides the config's
+// quiet value and values that aren't booleans are ignored. Flags overrides
+// both in the cli.
+func (c *Config) Verbosity() (bool, bool) {
+	quiet, verbose := c.Quiet, false
+
+	if v, ok := envBool("MAX_QUIET"); ok {
+		quiet = v
+	}
+
+	if v, ok := envBool("MAX_VERBOSE"); ok {
+		verbose = v
+	}
+
+	return quiet, verbose
+}
+
+// envBool returns the boolean value of a environment variable, false if
+// it's not set or not a boolean.
+func envBool(key string) (bool, bool) {
+	b, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return false, false
+	}
+
+	return b, true
+}
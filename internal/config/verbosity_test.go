@@ -0,0 +1,36 @@
+package config
+
+import (
+	"os"
+	"testing"
+)
+
+func TestVerbosity(t *testing.T) {
+	defer os.Unsetenv("MAX_QUIET")
+	defer os.Unsetenv("MAX_VERBOSE")
+
+	tests := []struct {
+		config  bool
+		quiet   string
+		verbose string
+		exp     [2]bool
+	}{
+		{false, "", "", [2]bool{false, false}},
+		{true, "", "", [2]bool{true, false}},
+		{true, "0", "", [2]bool{false, false}},
+		{false, "1", "", [2]bool{true, false}},
+		{false, "yes", "true", [2]bool{false, true}},
+		{true, "", "1", [2]bool{true, true}},
+	}
+
+	for _, test := range tests {
+		os.Setenv("MAX_QUIET", test.quiet)
+		os.Setenv("MAX_VERBOSE", test.verbose)
+
+		c := &Config{Quiet: test.config}
+
+		if quiet, verbose := c.Verbosity(); quiet != test.exp[0] || verbose != test.exp[1] {
+			t.Errorf("Expected: %v for %+v, got: [%v %v]", test.exp, test, quiet, verbose)
+		}
+	}
+}
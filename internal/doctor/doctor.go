@@ -0,0 +1,238 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/task"
+)
+
+// Check represents a diagnostic check result.
+type Check struct {
+	Name string
+	Err  error
+	Hint string
+}
+
+// String returns the check as a checklist line, failed checks includes the error and hint.
+func (c Check) String() string {
+	if c.Err == nil {
+		return fmt.Sprintf("[ok]   %s", c.Name)
+	}
+
+	s := fmt.Sprintf("[fail] %s: %s", c.Name, c.Err)
+
+	if len(c.Hint) > 0 {
+		s += "\n       " + c.Hint
+	}
+
+	return s
+}
+
+// CacheDir checks that the cache directory can be created and written to.
+func CacheDir(dir string) Check {
+	check := Check{
+		Name: fmt.Sprintf("cache directory %s is writable", dir),
+		Hint: "check the directory permissions or set HOME to a writable directory",
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		check.Err = err
+		return check
+	}
+
+	f, err := ioutil.TempFile(dir, ".doctor")
+	if err != nil {
+		check.Err = err
+		return check
+	}
+
+	f.Close()
+	check.Err = os.Remove(f.Name())
+
+	return check
+}
+
+// Config checks that the config could be loaded, each failed include
+// is reported as a check and the tasks are validated.
+func Config(c *config.Config, err error) []Check {
+	if errs, ok := err.(config.IncludeErrors); ok {
+		checks := make([]Check, 0, len(errs)+2)
+
+		for _, e := range errs {
+			checks = append(checks, Check{
+				Name: fmt.Sprintf("include %s from %s", e.Key, e.Ref),
+				Err:  e.Err,
+				Hint: "check that the file exists or that the url is reachable, use http_headers for includes that requires authentication",
+			})
+		}
+
+		return append(checks, validate(c))
+	}
+
+	check := Check{
+		Name: "config is loaded",
+		Err:  err,
+		Hint: "check the config syntax, run with --config to use another file",
+	}
+
+	if err != nil {
+		return []Check{check}
+	}
+
+	return []Check{check, validate(c)}
+}
+
+func validate(c *config.Config) Check {
+	check := Check{
+		Name: "config is valid",
+		Hint: "fix the task configuration, run with --verbose to print warnings",
+	}
+
+	if c == nil {
+		check.Err = fmt.Errorf("no config")
+		return check
+	}
+
+	_, check.Err = c.Validate()
+
+	return check
+}
+
+// Binaries checks that the shells used by tasks and the binaries tasks
+// requires are found. Requirements are rendered with the config's args and
+// variables.
+func Binaries(c *config.Config) []Check {
+	if c == nil {
+		return nil
+	}
+
+	var checks []Check
+
+	shells := make(map[string][]string)
+	requires := make(map[string][]string)
+
+	for _, id := range c.List() {
+		t := c.Tasks[id]
+		if t == nil {
+			continue
+		}
+
+		if fields := strings.Fields(t.Shell); len(fields) > 0 {
+			shells[fields[0]] = append(shells[fields[0]], id)
+		}
+
+		t = t.Copy()
+		t.Options(task.Args(c.Args), task.Variables(c.Variables))
+
+		names, err := t.Requirements()
+		if err != nil {
+			checks = append(checks, Check{
+				Name: fmt.Sprintf("requires of task %s can be rendered", id),
+				Err:  err,
+				Hint: "fix the requires templates of the task",
+			})
+		}
+
+		for _, name := range names {
+			requires[name] = append(requires[name], id)
+		}
+	}
+
+	for _, name := range sortedKeys(shells) {
+		_, err := osexec.LookPath(name)
+
+		checks = append(checks, Check{
+			Name: fmt.Sprintf("shell %s is installed (%s)", filepath.Base(name), strings.Join(shells[name], ", ")),
+			Err:  err,
+			Hint: fmt.Sprintf("install %s or change the tasks shell", name),
+		})
+	}
+
+	for _, name := range sortedKeys(requires) {
+		_, err := osexec.LookPath(name)
+
+		checks = append(checks, Check{
+			Name: fmt.Sprintf("required binary %s is installed (%s)", name, strings.Join(requires[name], ", ")),
+			Err:  err,
+			Hint: fmt.Sprintf("install %s or add it to PATH", name),
+		})
+	}
+
+	return checks
+}
+
+// sortedKeys returns the keys of the map in sorted order.
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Docker checks that the docker daemon is reachable when any task uses docker.
+func Docker(c *config.Config) []Check {
+	if c == nil {
+		return nil
+	}
+
+	used := false
+
+	for _, t := range c.Tasks {
+		if t != nil && t.Docker != nil && len(t.Docker.Image) > 0 {
+			used = true
+			break
+		}
+	}
+
+	if !used {
+		return nil
+	}
+
+	check := Check{
+		Name: "docker daemon is reachable",
+		Hint: "start docker or set DOCKER_HOST to a running daemon",
+	}
+
+	cli, err := client.NewEnvClient()
+	if err != nil {
+		check.Err = err
+		return []Check{check}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, check.Err = cli.Ping(ctx)
+
+	return []Check{check}
+}
+
+// Print prints the checks as a checklist and returns true if all checks passed.
+func Print(w io.Writer, checks []Check) bool {
+	ok := true
+
+	for _, c := range checks {
+		fmt.Fprintln(w, c)
+
+		if c.Err != nil {
+			ok = false
+		}
+	}
+
+	return ok
+}
@@ -0,0 +1,115 @@
+package doctor
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/task"
+)
+
+func TestCacheDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	if c := CacheDir(filepath.Join(dir, ".max")); c.Err != nil {
+		t.Errorf("Expected: nil, got: %s", c.Err)
+	}
+
+	file := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(file, []byte("test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if c := CacheDir(filepath.Join(file, ".max")); c.Err == nil {
+		t.Errorf("Expected: error, got: nil")
+	}
+}
+
+func TestConfig(t *testing.T) {
+	checks := Config(nil, errors.New("bad config"))
+
+	if len(checks) != 1 || checks[0].Err == nil {
+		t.Fatalf("Expected: one failed check, got: %v", checks)
+	}
+
+	c := &config.Config{Tasks: map[string]*task.Task{"hello": {}}}
+	errs := config.IncludeErrors{&config.IncludeError{Key: "deploy", Ref: "https://example.com/deploy.yml", Err: errors.New("not found")}}
+
+	checks = Config(c, errs)
+
+	if len(checks) != 2 {
+		t.Fatalf("Expected: two checks, got: %v", checks)
+	}
+
+	if !strings.Contains(checks[0].String(), "include deploy from https://example.com/deploy.yml: not found") {
+		t.Errorf("Expected: include check, got: %s", checks[0])
+	}
+
+	if checks[1].Err != nil {
+		t.Errorf("Expected: valid config, got: %s", checks[1].Err)
+	}
+}
+
+func TestBinaries(t *testing.T) {
+	c := &config.Config{
+		Tasks: map[string]*task.Task{
+			"a": {Shell: "sh"},
+			"b": {Shell: "max-missing-shell -c"},
+			"c": {},
+			"d": {Requires: yaml2.NewList([]string{"max-missing-binary"})},
+			"e": {Requires: yaml2.NewList([]string{"{{ .bin }}"})},
+		},
+		Variables: map[string]interface{}{"bin": "sh"},
+	}
+
+	checks := Binaries(c)
+
+	if len(checks) != 4 {
+		t.Fatalf("Expected: four checks, got: %v", checks)
+	}
+
+	if checks[0].Name != "shell max-missing-shell is installed (b)" || checks[0].Err == nil {
+		t.Errorf("Expected: failed check for missing shell, got: %s", checks[0])
+	}
+
+	if checks[1].Err != nil {
+		t.Errorf("Expected: nil, got: %s", checks[1].Err)
+	}
+
+	if checks[2].Name != "required binary max-missing-binary is installed (d)" || checks[2].Err == nil {
+		t.Errorf("Expected: failed check for missing binary, got: %s", checks[2])
+	}
+
+	if checks[3].Name != "required binary sh is installed (e)" || checks[3].Err != nil {
+		t.Errorf("Expected: check for rendered binary, got: %s", checks[3])
+	}
+}
+
+func TestPrint(t *testing.T) {
+	var buf bytes.Buffer
+
+	ok := Print(&buf, []Check{
+		{Name: "first"},
+		{Name: "second", Err: errors.New("failed"), Hint: "fix it"},
+	})
+
+	if ok {
+		t.Errorf("Expected: false, got: true")
+	}
+
+	expected := "[ok]   first\n[fail] second: failed\n       fix it\n"
+	if buf.String() != expected {
+		t.Errorf("Expected: %q, got: %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,32 @@
+package exec
+
+import "fmt"
+
+// CommandError represents a failed command with the directory and shell it
+// ran in so it can be reproduced manually.
+type CommandError struct {
+	Command string
+	Dir     string
+	Shell   string
+	Err     error
+}
+
+// Error returns the command error message.
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the command error.
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// Details returns the command, directory and shell on separate lines.
+func (e *CommandError) Details() string {
+	shell := e.Shell
+	if len(shell) == 0 {
+		shell = "built in"
+	}
+
+	return fmt.Sprintf("  command: %s\n  dir: %s\n  shell: %s", e.Command, e.Dir, shell)
+}
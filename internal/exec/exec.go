@@ -10,15 +10,45 @@ import (
 	"mvdan.cc/sh/syntax"
 )
 
+// cleanEnv contains the environment variables that are kept with a clean environment.
+var cleanEnv = []string{"HOME", "PATH", "SYSTEMROOT"}
+
 // Options represents execute options.
 type Options struct {
-	Context context.Context
-	Dir     string
-	Env     []string
-	Command string
-	Stdin   io.Reader
-	Stdout  io.Writer
-	Stderr  io.Writer
+	Context  context.Context
+	CleanEnv bool
+	Dir      string
+	Env      []string
+	Command  string
+	Nice     int
+	Shell    string
+	Stdin    io.Reader
+	Stdout   io.Writer
+	Stderr   io.Writer
+}
+
+// Environ returns the process environment, only PATH, HOME and SYSTEMROOT
+// on windows are kept when clean is true.
+func Environ(clean bool) []string {
+	if !clean {
+		return os.Environ()
+	}
+
+	var env []string
+
+	for _, k := range cleanEnv {
+		if v, ok := os.LookupEnv(k); ok {
+			env = append(env, k+"="+v)
+		}
+	}
+
+	return env
+}
+
+// Environ returns the environment the command runs with, the process
+// environment or only the kept variables with CleanEnv, followed by Env.
+func (o *Options) Environ() []string {
+	return append(Environ(o.CleanEnv), o.Env...)
 }
 
 // Exec will execute a input cmd string.
@@ -38,23 +68,33 @@ func Exec(opts *Options) error {
 		path = wd
 	}
 
+	env := opts.Environ()
+
+	// Use a external shell if configured instead of the built in interpreter.
+	if len(opts.Shell) > 0 {
+		return execShell(opts.Context, opts, path, env)
+	}
+
 	p, err := syntax.NewParser().Parse(strings.NewReader(opts.Command), "")
 	if err != nil {
 		return err
 	}
 
-	env := os.Environ()
-	env = append(env, opts.Env...)
 	envi, err := interp.EnvFromList(env)
 	if err != nil {
 		return err
 	}
 
+	exec := interp.DefaultExec
+	if opts.Nice != 0 {
+		exec = niceExec(opts.Nice)
+	}
+
 	r := interp.Runner{
 		Context: opts.Context,
 		Env:     envi,
 		Dir:     path,
-		Exec:    interp.DefaultExec,
+		Exec:    exec,
 		Open:    interp.OpenDevImpls(interp.DefaultOpen),
 		Stdin:   opts.Stdin,
 		Stdout:  opts.Stdout,
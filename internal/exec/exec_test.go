@@ -1,7 +1,11 @@
 package exec
 
 import (
+	"bytes"
 	"os"
+	osexec "os/exec"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -19,3 +23,66 @@ func TestCmd(t *testing.T) {
 		t.Errorf("Expected: nil, got: %s", err)
 	}
 }
+
+func TestCleanEnv(t *testing.T) {
+	os.Setenv("MAX_TEST_AMBIENT", "yes")
+	defer os.Unsetenv("MAX_TEST_AMBIENT")
+
+	for _, clean := range []bool{false, true} {
+		var buf bytes.Buffer
+
+		if err := Exec(&Options{
+			CleanEnv: clean,
+			Env:      []string{"MAX_TEST_DECLARED=yes"},
+			Command:  `echo "$MAX_TEST_AMBIENT $MAX_TEST_DECLARED ${PATH:+path}"`,
+			Stdout:   &buf,
+		}); err != nil {
+			t.Fatalf("Expected: nil, got: %s", err)
+		}
+
+		exp := "yes yes path\n"
+		if clean {
+			exp = " yes path\n"
+		}
+
+		if buf.String() != exp {
+			t.Errorf("Expected: %q with clean env %t, got: %q", exp, clean, buf.String())
+		}
+	}
+}
+
+func TestNice(t *testing.T) {
+	if !SupportsNice() {
+		t.Skip("process priorities are not supported")
+	}
+
+	if _, err := osexec.LookPath("nice"); err != nil {
+		t.Skip("nice is not installed")
+	}
+
+	// The priority is set after the command is started on other platforms
+	// than linux, the shell sleeps so its priority is set before nice runs.
+	command := "nice"
+	if runtime.GOOS != "linux" {
+		command = "sh -c 'sleep 0.2; nice'"
+	}
+
+	for _, shell := range []string{"", "sh"} {
+		var buf bytes.Buffer
+
+		err := Exec(&Options{
+			Command: command,
+			Nice:    10,
+			Shell:   shell,
+			Stdout:  &buf,
+		})
+
+		if err != nil {
+			t.Fatalf("Expected: nil, got: %s", err)
+		}
+
+		if got := strings.TrimSpace(buf.String()); got != "10" {
+			t.Errorf("Expected: 10 with shell %q, got: %s", shell, got)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	osexec "os/exec"
+	"syscall"
+
+	"mvdan.cc/sh/interp"
+)
+
+// SupportsNice reports whether process priorities can be set on the platform.
+func SupportsNice() bool {
+	return niceSupported
+}
+
+// startNice starts the command with the priority, priorities are ignored
+// on platforms without support.
+func startNice(cmd *osexec.Cmd, nice int) error {
+	if nice == 0 || !niceSupported {
+		return cmd.Start()
+	}
+
+	return startPriority(cmd, nice)
+}
+
+// niceExec returns a interpreter exec module that runs programs like
+// interp.DefaultExec with the given priority.
+func niceExec(nice int) interp.ModuleExec {
+	return func(ctx interp.Ctxt, path string, args []string) error {
+		if len(path) == 0 {
+			fmt.Fprintf(ctx.Stderr, "%q: executable file not found in $PATH\n", args[0])
+			return interp.ExitCode(127)
+		}
+
+		var env []string
+		for _, name := range ctx.Env.Names() {
+			value, _ := ctx.Env.Get(name)
+			env = append(env, name+"="+value)
+		}
+
+		cmd := &osexec.Cmd{
+			Path:   path,
+			Args:   args,
+			Env:    env,
+			Dir:    ctx.Dir,
+			Stdin:  ctx.Stdin,
+			Stdout: ctx.Stdout,
+			Stderr: ctx.Stderr,
+		}
+
+		err := startNice(cmd, nice)
+		if err == nil {
+			done := make(chan struct{})
+			defer close(done)
+
+			go func() {
+				select {
+				case <-ctx.Context.Done():
+					cmd.Process.Signal(os.Kill)
+				case <-done:
+				}
+			}()
+
+			err = cmd.Wait()
+		}
+
+		switch x := err.(type) {
+		case *osexec.ExitError:
+			if status, ok := x.Sys().(syscall.WaitStatus); ok {
+				if status.Signaled() && ctx.Context.Err() != nil {
+					return ctx.Context.Err()
+				}
+
+				return interp.ExitCode(status.ExitStatus())
+			}
+
+			return interp.ExitCode(1)
+		case *osexec.Error:
+			fmt.Fprintf(ctx.Stderr, "%v\n", err)
+			return interp.ExitCode(127)
+		default:
+			return err
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package exec
+
+import (
+	"fmt"
+	osexec "os/exec"
+	"runtime"
+	"syscall"
+)
+
+const niceSupported = true
+
+// startPriority starts the command with the priority. Priorities are per
+// thread on linux and inherited by the processes a thread starts, so the
+// command is started from a locked thread with the priority set before the
+// command runs. The thread is never unlocked so it's terminated when the
+// goroutine returns instead of being reused with the changed priority.
+func startPriority(cmd *osexec.Cmd, nice int) error {
+	errc := make(chan error, 1)
+
+	go func() {
+		runtime.LockOSThread()
+
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, syscall.Gettid(), nice); err != nil {
+			errc <- fmt.Errorf("max: can't set nice %d: %s", nice, err)
+			return
+		}
+
+		errc <- cmd.Start()
+	}()
+
+	return <-errc
+}
@@ -0,0 +1,11 @@
+//go:build windows || plan9
+
+package exec
+
+import osexec "os/exec"
+
+const niceSupported = false
+
+func startPriority(cmd *osexec.Cmd, nice int) error {
+	return cmd.Start()
+}
@@ -0,0 +1,28 @@
+//go:build !windows && !plan9 && !linux
+
+package exec
+
+import (
+	"fmt"
+	osexec "os/exec"
+	"syscall"
+)
+
+const niceSupported = true
+
+// startPriority starts the command and sets its priority. Priorities are
+// per process on these platforms so the priority can only be set after the
+// command is started.
+func startPriority(cmd *osexec.Cmd, nice int) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	if err := syscall.Setpriority(syscall.PRIO_PROCESS, cmd.Process.Pid, nice); err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return fmt.Errorf("max: can't set nice %d: %s", nice, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package exec
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// ErrNoPTY is returned when pseudo-terminals are not supported on the platform.
+var ErrNoPTY = errors.New("max: pseudo-terminals are not supported on this platform")
+
+// PTY represents a pseudo-terminal that forwards its output to a writer.
+type PTY struct {
+	done   chan struct{}
+	master *os.File
+	slave  *os.File
+}
+
+// NewPTY allocates a pseudo-terminal and forwards its output to w until
+// the terminal is closed. ErrNoPTY is returned on platforms without
+// pseudo-terminals.
+func NewPTY(w io.Writer) (*PTY, error) {
+	master, slave, err := openPTY()
+	if err != nil {
+		return nil, err
+	}
+
+	p := &PTY{done: make(chan struct{}), master: master, slave: slave}
+
+	go func() {
+		defer close(p.done)
+		io.Copy(w, master)
+	}()
+
+	return p, nil
+}
+
+// File returns the terminal file that commands should use as stdout and stderr.
+func (p *PTY) File() *os.File {
+	return p.slave
+}
+
+// Close closes the terminal and waits for the remaining output to be forwarded.
+func (p *PTY) Close() error {
+	err := p.slave.Close()
+	<-p.done
+
+	if merr := p.master.Close(); err == nil {
+		err = merr
+	}
+
+	return err
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package exec
+
+import "os"
+
+func openPTY() (*os.File, *os.File, error) {
+	return nil, nil, ErrNoPTY
+}
@@ -0,0 +1,37 @@
+package exec
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPTY(t *testing.T) {
+	var buf bytes.Buffer
+
+	p, err := NewPTY(&buf)
+	if err == ErrNoPTY {
+		t.Skip(err)
+	}
+
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if err := Exec(&Options{
+		Command: "test -t 1 && echo tty",
+		Shell:   "sh",
+		Stdout:  p.File(),
+		Stderr:  p.File(),
+	}); err != nil {
+		t.Errorf("Expected: nil, got: %s", err)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Errorf("Expected: nil, got: %s", err)
+	}
+
+	// Newlines are not translated to \r\n.
+	if got := buf.String(); got != "tty\n" {
+		t.Errorf("Expected: tty, got: %q", got)
+	}
+}
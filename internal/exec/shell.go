@@ -0,0 +1,102 @@
+package exec
+
+import (
+	"context"
+	osexec "os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Shells maps known shells to the arguments used to run a command string.
+var Shells = map[string][]string{
+	"ash":        {"-c"},
+	"bash":       {"-c"},
+	"cmd":        {"/C"},
+	"dash":       {"-c"},
+	"fish":       {"-c"},
+	"ksh":        {"-c"},
+	"powershell": {"-NoProfile", "-Command"},
+	"pwsh":       {"-NoProfile", "-Command"},
+	"sh":         {"-c"},
+	"zsh":        {"-c"},
+}
+
+// IsPOSIX reports whether the shell is the built in interpreter or a POSIX shell.
+func IsPOSIX(shell string) bool {
+	fields := strings.Fields(shell)
+	if len(fields) == 0 {
+		return true
+	}
+
+	switch shellName(fields[0]) {
+	case "cmd", "powershell", "pwsh":
+		return false
+	default:
+		return true
+	}
+}
+
+// Strict prefixes a command with the shell's strict mode options, e.g
+// set -e -u -o pipefail for POSIX shells. Shells without an equivalent are left as is.
+func Strict(shell, command string) string {
+	fields := strings.Fields(shell)
+	if len(fields) == 0 {
+		return "set -e -u -o pipefail\n" + command
+	}
+
+	switch shellName(fields[0]) {
+	case "cmd", "fish":
+		return command
+	case "powershell", "pwsh":
+		return "$ErrorActionPreference = 'Stop'; Set-StrictMode -Version Latest; " + command
+	default:
+		return "set -e -u -o pipefail\n" + command
+	}
+}
+
+func shellName(path string) string {
+	return strings.ToLower(strings.TrimSuffix(filepath.Base(path), ".exe"))
+}
+
+// ShellArgs returns the argv used to run a command with the given shell.
+// A shell with arguments, e.g "bash -eu -c", is used as is.
+func ShellArgs(shell, command string) []string {
+	fields := strings.Fields(shell)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	if len(fields) > 1 {
+		return append(fields, command)
+	}
+
+	args, ok := Shells[shellName(fields[0])]
+	if !ok {
+		args = []string{"-c"}
+	}
+
+	argv := append([]string{fields[0]}, args...)
+
+	return append(argv, command)
+}
+
+func execShell(ctx context.Context, opts *Options, dir string, env []string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	argv := ShellArgs(opts.Shell, opts.Command)
+
+	cmd := osexec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Dir = dir
+	cmd.Env = env
+	cmd.Stdin = opts.Stdin
+	cmd.Stdout = opts.Stdout
+	cmd.Stderr = opts.Stderr
+
+	if err := startNice(cmd, opts.Nice); err != nil {
+		return err
+	}
+
+	return cmd.Wait()
+}
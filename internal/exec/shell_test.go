@@ -0,0 +1,77 @@
+package exec
+
+import (
+	"bytes"
+	osexec "os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShellArgs(t *testing.T) {
+	tests := map[string][]string{
+		"sh":                 {"sh", "-c", "echo hi"},
+		"/bin/bash":          {"/bin/bash", "-c", "echo hi"},
+		"cmd.exe":            {"cmd.exe", "/C", "echo hi"},
+		"powershell":         {"powershell", "-NoProfile", "-Command", "echo hi"},
+		"bash -eu -c":        {"bash", "-eu", "-c", "echo hi"},
+		"unknown":            {"unknown", "-c", "echo hi"},
+		"python3 -c":         {"python3", "-c", "echo hi"},
+		"pwsh":               {"pwsh", "-NoProfile", "-Command", "echo hi"},
+		"C:\\Windows\\x\\sh": {"C:\\Windows\\x\\sh", "-c", "echo hi"},
+	}
+
+	for shell, exp := range tests {
+		if got := ShellArgs(shell, "echo hi"); !reflect.DeepEqual(got, exp) {
+			t.Errorf("Expected: %v, got: %v", exp, got)
+		}
+	}
+}
+
+func TestIsPOSIX(t *testing.T) {
+	if !IsPOSIX("") || !IsPOSIX("/bin/bash") || IsPOSIX("cmd.exe") || IsPOSIX("pwsh") {
+		t.Error("Expected only cmd and powershell to be non posix shells")
+	}
+}
+
+func TestExecShell(t *testing.T) {
+	for _, shell := range []string{"sh", "bash"} {
+		if _, err := osexec.LookPath(shell); err != nil {
+			continue
+		}
+
+		var buf bytes.Buffer
+
+		if err := Exec(&Options{
+			Command: `echo "Hello 'max'  world"`,
+			Shell:   shell,
+			Stdout:  &buf,
+		}); err != nil {
+			t.Errorf("Expected: nil, got: %s", err)
+		}
+
+		if got := strings.TrimSpace(buf.String()); got != "Hello 'max'  world" {
+			t.Errorf("Expected: Hello 'max'  world, got: %s", got)
+		}
+	}
+}
+
+func TestStrict(t *testing.T) {
+	var buf bytes.Buffer
+
+	if err := Exec(&Options{Command: Strict("", "false | true"), Stderr: &buf}); err == nil {
+		t.Error("Expected: error, got: nil")
+	}
+
+	if err := Exec(&Options{Command: "false | true", Stderr: &buf}); err != nil {
+		t.Errorf("Expected: nil, got: %s", err)
+	}
+
+	if err := Exec(&Options{Command: Strict("", "echo $MAX_UNSET_VARIABLE"), Stdout: &buf, Stderr: &buf}); err == nil {
+		t.Error("Expected: error, got: nil")
+	}
+
+	if got := Strict("cmd", "echo hi"); got != "echo hi" {
+		t.Errorf("Expected: echo hi, got: %s", got)
+	}
+}
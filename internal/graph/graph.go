@@ -0,0 +1,188 @@
+package graph
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/task"
+)
+
+// Edge kinds.
+const (
+	Dep  = "dep"
+	Task = "task"
+)
+
+// Edge represents a edge between two tasks.
+type Edge struct {
+	From string
+	To   string
+	Kind string
+}
+
+// Graph represents a task dependency graph.
+type Graph struct {
+	Nodes []string
+	Edges []Edge
+
+	tasks map[string]*task.Task
+}
+
+// New creates a new graph from config tasks using deps and tasks relationships.
+func New(c *config.Config) *Graph {
+	g := &Graph{tasks: c.Tasks}
+
+	for _, id := range c.List() {
+		t := c.Tasks[id]
+		if t == nil {
+			continue
+		}
+
+		g.Nodes = append(g.Nodes, id)
+
+		for _, dep := range t.AllDeps() {
+			g.Edges = append(g.Edges, Edge{From: id, To: dep, Kind: Dep})
+		}
+
+		for _, other := range t.Tasks.Values {
+			g.Edges = append(g.Edges, Edge{From: id, To: other, Kind: Task})
+		}
+	}
+
+	return g
+}
+
+// edges returns the outgoing edges of a node.
+func (g *Graph) edges(id string) []Edge {
+	var edges []Edge
+
+	for _, e := range g.Edges {
+		if e.From == id {
+			edges = append(edges, e)
+		}
+	}
+
+	return edges
+}
+
+// Subgraph returns the graph reachable from a target.
+func (g *Graph) Subgraph(target string) (*Graph, error) {
+	if !g.has(target) {
+		return nil, fmt.Errorf("max: task missing: %s", target)
+	}
+
+	sub := &Graph{tasks: g.tasks}
+	seen := make(map[string]bool)
+
+	var walk func(string)
+	walk = func(id string) {
+		if seen[id] {
+			return
+		}
+
+		seen[id] = true
+		sub.Nodes = append(sub.Nodes, id)
+
+		for _, e := range g.edges(id) {
+			sub.Edges = append(sub.Edges, e)
+			walk(e.To)
+		}
+	}
+
+	walk(target)
+
+	return sub, nil
+}
+
+// Cycle returns the first cycle found as a path, e.g [a b a], or nil.
+func (g *Graph) Cycle() []string {
+	const (
+		visiting = 1
+		done     = 2
+	)
+
+	state := make(map[string]int)
+	var path []string
+	var cycle []string
+
+	var visit func(string) bool
+	visit = func(id string) bool {
+		switch state[id] {
+		case visiting:
+			for i, p := range path {
+				if p == id {
+					cycle = append(append([]string{}, path[i:]...), id)
+				}
+			}
+			return true
+		case done:
+			return false
+		}
+
+		state[id] = visiting
+		path = append(path, id)
+
+		for _, e := range g.edges(id) {
+			if visit(e.To) {
+				return true
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[id] = done
+
+		return false
+	}
+
+	for _, id := range g.Nodes {
+		if visit(id) {
+			return cycle
+		}
+	}
+
+	return nil
+}
+
+// DOT returns the graph in graphviz dot format or a error if the graph has a cycle.
+func (g *Graph) DOT() (string, error) {
+	if cycle := g.Cycle(); cycle != nil {
+		return "", CycleError(cycle)
+	}
+
+	var buf bytes.Buffer
+
+	buf.WriteString("digraph max {\n")
+
+	for _, id := range g.Nodes {
+		fmt.Fprintf(&buf, "  %q;\n", id)
+	}
+
+	for _, e := range g.Edges {
+		if e.Kind == Task {
+			fmt.Fprintf(&buf, "  %q -> %q [style=dashed];\n", e.From, e.To)
+		} else {
+			fmt.Fprintf(&buf, "  %q -> %q;\n", e.From, e.To)
+		}
+	}
+
+	buf.WriteString("}\n")
+
+	return buf.String(), nil
+}
+
+func (g *Graph) has(id string) bool {
+	for _, n := range g.Nodes {
+		if n == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CycleError returns a error describing a dependency cycle.
+func CycleError(cycle []string) error {
+	return fmt.Errorf("max: dependency cycle %s", strings.Join(cycle, " -> "))
+}
@@ -0,0 +1,81 @@
+package graph
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/task"
+)
+
+func testConfig(tasks map[string]*task.Task) *config.Config {
+	return &config.Config{Tasks: tasks}
+}
+
+func TestDOT(t *testing.T) {
+	g := New(testConfig(map[string]*task.Task{
+		"build":  {Deps: []string{"lint"}},
+		"deploy": {Deps: []string{"build"}, Tasks: yaml2.NewList("notify")},
+		"lint":   {},
+		"notify": {},
+	}))
+
+	got, err := g.DOT()
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	exp := `digraph max {
+  "build";
+  "deploy";
+  "lint";
+  "notify";
+  "build" -> "lint";
+  "deploy" -> "build";
+  "deploy" -> "notify" [style=dashed];
+}
+`
+
+	if got != exp {
+		t.Errorf("Expected: %s, got: %s", exp, got)
+	}
+}
+
+func TestSubgraph(t *testing.T) {
+	g := New(testConfig(map[string]*task.Task{
+		"build":  {Deps: []string{"lint"}},
+		"deploy": {Deps: []string{"build"}},
+		"lint":   {},
+		"other":  {},
+	}))
+
+	sub, err := g.Subgraph("build")
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(sub.Nodes, []string{"build", "lint"}) {
+		t.Errorf("Expected: [build lint], got: %v", sub.Nodes)
+	}
+
+	if _, err := g.Subgraph("missing"); err == nil {
+		t.Error("Expected: error, got: nil")
+	}
+}
+
+func TestCycle(t *testing.T) {
+	g := New(testConfig(map[string]*task.Task{
+		"a": {Deps: []string{"b"}},
+		"b": {Deps: []string{"c"}},
+		"c": {Deps: []string{"a"}},
+	}))
+
+	if cycle := g.Cycle(); !reflect.DeepEqual(cycle, []string{"a", "b", "c", "a"}) {
+		t.Errorf("Expected: [a b c a], got: %v", cycle)
+	}
+
+	if _, err := g.DOT(); err == nil {
+		t.Error("Expected: error, got: nil")
+	}
+}
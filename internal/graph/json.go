@@ -0,0 +1,57 @@
+package graph
+
+import "encoding/json"
+
+// JSONVersion is the version of the json graph schema, it's increased when
+// fields are changed or removed.
+const JSONVersion = 1
+
+// jsonGraph represents the json graph schema.
+type jsonGraph struct {
+	Version int        `json:"version"`
+	Nodes   []jsonNode `json:"nodes"`
+}
+
+// jsonNode represents a task with its adjacency lists.
+type jsonNode struct {
+	ID      string   `json:"id"`
+	Summary string   `json:"summary"`
+	Deps    []string `json:"deps"`
+	Tasks   []string `json:"tasks"`
+	Cached  bool     `json:"cached"`
+	Missing bool     `json:"missing"`
+}
+
+// JSON returns the graph as versioned json adjacency lists, each node has
+// the tasks it depends on and runs, its summary, if it's skipped by a cache
+// key and if the task is missing. Cycles are errors like with DOT.
+func (g *Graph) JSON() ([]byte, error) {
+	if cycle := g.Cycle(); cycle != nil {
+		return nil, CycleError(cycle)
+	}
+
+	res := jsonGraph{Version: JSONVersion, Nodes: []jsonNode{}}
+
+	for _, id := range g.Nodes {
+		n := jsonNode{ID: id, Deps: []string{}, Tasks: []string{}}
+
+		if t := g.tasks[id]; t != nil {
+			n.Summary = t.Summary
+			n.Cached = len(t.CacheKey) > 0
+		} else {
+			n.Missing = true
+		}
+
+		for _, e := range g.edges(id) {
+			if e.Kind == Task {
+				n.Tasks = append(n.Tasks, e.To)
+			} else {
+				n.Deps = append(n.Deps, e.To)
+			}
+		}
+
+		res.Nodes = append(res.Nodes, n)
+	}
+
+	return json.MarshalIndent(res, "", "  ")
+}
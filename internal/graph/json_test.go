@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+	"github.com/frozzare/max/internal/task"
+)
+
+func TestJSON(t *testing.T) {
+	g := New(testConfig(map[string]*task.Task{
+		"build":  {CacheKey: "{{ .version }}", Deps: []string{"lint"}, Summary: "Build"},
+		"deploy": {Deps: []string{"build"}, Tasks: yaml2.NewList("notify")},
+		"lint":   {},
+	}))
+
+	sub, err := g.Subgraph("deploy")
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	buf, err := sub.JSON()
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	var got jsonGraph
+	if err := json.Unmarshal(buf, &got); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	exp := jsonGraph{
+		Version: JSONVersion,
+		Nodes: []jsonNode{
+			{ID: "deploy", Deps: []string{"build"}, Tasks: []string{"notify"}},
+			{ID: "build", Summary: "Build", Deps: []string{"lint"}, Tasks: []string{}, Cached: true},
+			{ID: "lint", Deps: []string{}, Tasks: []string{}},
+			{ID: "notify", Deps: []string{}, Tasks: []string{}, Missing: true},
+		},
+	}
+
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected: %+v, got: %+v", exp, got)
+	}
+
+	g = New(testConfig(map[string]*task.Task{
+		"a": {Deps: []string{"b"}},
+		"b": {Deps: []string{"a"}},
+	}))
+
+	if _, err := g.JSON(); err == nil {
+		t.Error("Expected: cycle error, got: nil")
+	}
+}
@@ -0,0 +1,160 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics collects task durations and success/failure counts.
+type Metrics struct {
+	tasks map[string]*taskMetrics
+
+	sync.Mutex
+}
+
+// Result represents the collected metrics for a task.
+type Result struct {
+	Task     string
+	Duration time.Duration
+	Success  int
+	Failure  int
+	Commands []Command
+}
+
+// Command represents the duration of a command in the last task run.
+type Command struct {
+	Command  string
+	Duration time.Duration
+}
+
+type taskMetrics struct {
+	duration time.Duration
+	success  int
+	failure  int
+	commands []Command
+}
+
+// New creates a new metrics collector.
+func New() *Metrics {
+	return &Metrics{
+		tasks: make(map[string]*taskMetrics),
+	}
+}
+
+// Observe records a task run.
+func (m *Metrics) Observe(task string, d time.Duration, err error) {
+	m.Lock()
+	defer m.Unlock()
+
+	t, ok := m.tasks[task]
+	if !ok {
+		t = &taskMetrics{}
+		m.tasks[task] = t
+	}
+
+	t.duration = d
+
+	if err != nil {
+		t.failure++
+	} else {
+		t.success++
+	}
+}
+
+// ObserveCommands records the command durations of the last task run.
+func (m *Metrics) ObserveCommands(task string, commands []Command) {
+	m.Lock()
+	defer m.Unlock()
+
+	t, ok := m.tasks[task]
+	if !ok {
+		t = &taskMetrics{}
+		m.tasks[task] = t
+	}
+
+	t.commands = commands
+}
+
+// Results returns the collected metrics for all tasks sorted by task name.
+func (m *Metrics) Results() []Result {
+	m.Lock()
+	defer m.Unlock()
+
+	results := make([]Result, 0, len(m.tasks))
+
+	for k, t := range m.tasks {
+		results = append(results, Result{Task: k, Duration: t.duration, Success: t.success, Failure: t.failure, Commands: t.commands})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Task < results[j].Task
+	})
+
+	return results
+}
+
+// String returns the metrics in the Prometheus text format.
+func (m *Metrics) String() string {
+	m.Lock()
+	defer m.Unlock()
+
+	var names []string
+	for k := range m.tasks {
+		names = append(names, k)
+	}
+
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+
+	write := func(name, kind, help string, value func(*taskMetrics) float64) {
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for _, k := range names {
+			fmt.Fprintf(&buf, "%s{task=\"%s\"} %g\n", name, escape(k), value(m.tasks[k]))
+		}
+	}
+
+	write("max_task_duration_seconds", "gauge", "Duration of the last task run.", func(t *taskMetrics) float64 {
+		return t.duration.Seconds()
+	})
+
+	write("max_task_success_total", "counter", "Number of successful task runs.", func(t *taskMetrics) float64 {
+		return float64(t.success)
+	})
+
+	write("max_task_failure_total", "counter", "Number of failed task runs.", func(t *taskMetrics) float64 {
+		return float64(t.failure)
+	})
+
+	return buf.String()
+}
+
+// Push pushes the metrics to a Prometheus pushgateway.
+func (m *Metrics) Push(url, job string) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+	url = fmt.Sprintf("%s/metrics/job/%s", strings.TrimRight(url, "/"), job)
+
+	res, err := client.Post(url, "text/plain; version=0.0.4", strings.NewReader(m.String()))
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("max: bad status code %d from %s", res.StatusCode, url)
+	}
+
+	return nil
+}
+
+func escape(s string) string {
+	s = strings.Replace(s, `\`, `\\`, -1)
+	s = strings.Replace(s, `"`, `\"`, -1)
+	return strings.Replace(s, "\n", `\n`, -1)
+}
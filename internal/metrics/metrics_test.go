@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	m := New()
+	m.Observe("build", 2*time.Second, nil)
+	m.Observe("build", 1*time.Second, nil)
+	m.Observe("test", time.Second, errors.New("exit status 1"))
+	m.ObserveCommands("build", []Command{{Command: "go build", Duration: time.Second}})
+
+	s := m.String()
+
+	for _, exp := range []string{
+		`max_task_duration_seconds{task="build"} 1`,
+		`max_task_success_total{task="build"} 2`,
+		`max_task_failure_total{task="build"} 0`,
+		`max_task_failure_total{task="test"} 1`,
+	} {
+		if !strings.Contains(s, exp) {
+			t.Errorf("Expected: %s in metrics, got: %s", exp, s)
+		}
+	}
+
+	if c := m.Results()[0].Commands; len(c) != 1 || c[0].Command != "go build" {
+		t.Errorf("Expected: go build command, got: %v", c)
+	}
+}
+
+func TestPush(t *testing.T) {
+	var path, body string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf, _ := ioutil.ReadAll(r.Body)
+		path, body = r.URL.Path, string(buf)
+	}))
+
+	defer server.Close()
+
+	m := New()
+	m.Observe("build", time.Second, nil)
+
+	if err := m.Push(server.URL+"/", "max"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if path != "/metrics/job/max" {
+		t.Errorf("Expected: /metrics/job/max, got: %s", path)
+	}
+
+	if body != m.String() {
+		t.Errorf("Expected: metrics body, got: %s", body)
+	}
+}
+
+func TestResults(t *testing.T) {
+	m := New()
+	m.Observe("test", time.Second, errors.New("exit status 1"))
+	m.Observe("build", 2*time.Second, nil)
+
+	results := m.Results()
+
+	if len(results) != 2 || results[0].Task != "build" || results[1].Task != "test" {
+		t.Fatalf("Expected: build and test results, got: %v", results)
+	}
+
+	if results[0].Duration != 2*time.Second || results[0].Success != 1 || results[1].Failure != 1 {
+		t.Errorf("Expected: collected results, got: %v", results)
+	}
+}
@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/frozzare/max/internal/metrics"
+)
+
+// Payload represents the run summary sent to a webhook.
+type Payload struct {
+	Text     string       `json:"text"`
+	Status   string       `json:"status"`
+	Duration float64      `json:"duration_seconds"`
+	Tasks    []TaskResult `json:"tasks"`
+	Failures []string     `json:"failures"`
+	Error    string       `json:"error,omitempty"`
+}
+
+// TaskResult represents the result of a task in the run summary.
+type TaskResult struct {
+	Name     string          `json:"name"`
+	Status   string          `json:"status"`
+	Duration float64         `json:"duration_seconds"`
+	Commands []CommandResult `json:"commands,omitempty"`
+}
+
+// CommandResult represents the duration of a command when benchmarking tasks.
+type CommandResult struct {
+	Command  string  `json:"command"`
+	Duration float64 `json:"duration_seconds"`
+}
+
+// New creates a new payload from task metrics, the run duration and the run error.
+// The text field makes the payload usable with Slack incoming webhooks.
+func New(results []metrics.Result, d time.Duration, err error) *Payload {
+	p := &Payload{
+		Status:   "success",
+		Duration: d.Seconds(),
+		Tasks:    []TaskResult{},
+		Failures: []string{},
+	}
+
+	for _, r := range results {
+		status := "success"
+		if r.Failure > 0 {
+			status = "failure"
+			p.Failures = append(p.Failures, r.Task)
+		}
+
+		tr := TaskResult{Name: r.Task, Status: status, Duration: r.Duration.Seconds()}
+		for _, c := range r.Commands {
+			tr.Commands = append(tr.Commands, CommandResult{Command: c.Command, Duration: c.Duration.Seconds()})
+		}
+
+		p.Tasks = append(p.Tasks, tr)
+	}
+
+	if err != nil {
+		p.Status = "failure"
+		p.Error = err.Error()
+	}
+
+	p.Text = fmt.Sprintf("max: run %s in %s", p.Status, d.Round(time.Millisecond))
+	if len(p.Failures) > 0 {
+		p.Text += fmt.Sprintf(", failed tasks: %v", p.Failures)
+	}
+
+	return p
+}
+
+// Send posts the payload as json to the webhook url. The url is not
+// part of returned errors since webhook urls often contains secrets.
+func Send(webhook string, timeout time.Duration, p *Payload) error {
+	buf, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: timeout}
+
+	res, err := client.Post(webhook, "application/json", bytes.NewReader(buf))
+	if err != nil {
+		if e, ok := err.(*url.Error); ok {
+			return e.Err
+		}
+
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("max: bad status code %d from webhook", res.StatusCode)
+	}
+
+	return nil
+}
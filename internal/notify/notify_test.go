@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/frozzare/max/internal/metrics"
+)
+
+func TestNew(t *testing.T) {
+	m := metrics.New()
+	m.Observe("build", time.Second, nil)
+	m.Observe("test", time.Second, errors.New("exit status 1"))
+
+	p := New(m.Results(), 2*time.Second, errors.New("exit status 1"))
+
+	if p.Status != "failure" || p.Duration != 2 || p.Error != "exit status 1" {
+		t.Errorf("Expected: failed run, got: %v", p)
+	}
+
+	if !reflect.DeepEqual(p.Failures, []string{"test"}) {
+		t.Errorf("Expected: [test], got: %v", p.Failures)
+	}
+
+	if len(p.Tasks) != 2 || p.Tasks[0].Status != "success" {
+		t.Errorf("Expected: task results, got: %v", p.Tasks)
+	}
+}
+
+func TestSend(t *testing.T) {
+	var got Payload
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+
+	defer server.Close()
+
+	if err := Send(server.URL, time.Second, New(nil, time.Second, nil)); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if got.Status != "success" {
+		t.Errorf("Expected: success, got: %s", got.Status)
+	}
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+
+	defer slow.Close()
+
+	if err := Send(slow.URL, 50*time.Millisecond, New(nil, time.Second, nil)); err == nil {
+		t.Error("Expected: timeout error, got: nil")
+	}
+}
@@ -0,0 +1,55 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/frozzare/max/internal/metrics"
+)
+
+// bench collects the duration of each command in a task run.
+type bench struct {
+	commands []metrics.Command
+
+	sync.Mutex
+}
+
+// observe records the duration of a command.
+func (b *bench) observe(command string, d time.Duration) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.commands = append(b.commands, metrics.Command{Command: command, Duration: d})
+}
+
+// report logs the command durations of a task and adds them to the metrics.
+func (r *Runner) report(id string, b *bench) {
+	b.Lock()
+	defer b.Unlock()
+
+	if len(b.commands) == 0 {
+		return
+	}
+
+	r.log.Printf("Bench task %s:\n", color.GreenString(id))
+
+	for _, c := range b.commands {
+		r.log.Printf("  %10s  $ %s\n", c.Duration.Round(time.Millisecond), firstLine(c.Command))
+	}
+
+	if r.metrics != nil {
+		r.metrics.ObserveCommands(id, b.commands)
+	}
+}
+
+// firstLine returns the first line of a command, e.g for scripts.
+func firstLine(s string) string {
+	if i := strings.Index(s, "\n"); i >= 0 {
+		return fmt.Sprintf("%s ...", s[:i])
+	}
+
+	return s
+}
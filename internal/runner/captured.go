@@ -0,0 +1,56 @@
+package runner
+
+import "sync"
+
+// captured contains variables captured by tasks and shared with later tasks.
+type captured struct {
+	tasks map[string]map[string]interface{}
+	vars  map[string]interface{}
+
+	sync.Mutex
+}
+
+func newCaptured() *captured {
+	return &captured{
+		tasks: make(map[string]map[string]interface{}),
+		vars:  make(map[string]interface{}),
+	}
+}
+
+// get returns a copy of the captured variables.
+func (c *captured) get() map[string]interface{} {
+	c.Lock()
+	defer c.Unlock()
+
+	vars := make(map[string]interface{}, len(c.vars))
+	for k, v := range c.vars {
+		vars[k] = v
+	}
+
+	return vars
+}
+
+// set stores variables captured by a task.
+func (c *captured) set(id string, vars map[string]interface{}) {
+	c.Lock()
+	defer c.Unlock()
+
+	for k, v := range vars {
+		c.vars[k] = v
+	}
+
+	if len(vars) > 0 {
+		c.tasks[id] = vars
+	}
+}
+
+// task returns the variables captured by a task, false if the task has
+// not run or captured no variables.
+func (c *captured) task(id string) (map[string]interface{}, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	vars, ok := c.tasks[id]
+
+	return vars, ok
+}
@@ -0,0 +1,35 @@
+package runner
+
+import "sync"
+
+// groups contains a mutex per concurrency group so tasks in the same
+// group don't run at the same time.
+type groups struct {
+	locks map[string]*sync.Mutex
+
+	sync.Mutex
+}
+
+func newGroups() *groups {
+	return &groups{locks: make(map[string]*sync.Mutex)}
+}
+
+// lock locks the concurrency group and returns a function that unlocks it.
+// Tasks without a group are not locked.
+func (g *groups) lock(name string) func() {
+	if len(name) == 0 {
+		return func() {}
+	}
+
+	g.Lock()
+	mu, ok := g.locks[name]
+	if !ok {
+		mu = &sync.Mutex{}
+		g.locks[name] = mu
+	}
+	g.Unlock()
+
+	mu.Lock()
+
+	return mu.Unlock
+}
@@ -0,0 +1,141 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+)
+
+// DryRun writes the tasks that would run in execution order, one task per
+// line, without running them. Tasks that are up to date or have a unchanged
+// cache key are left out. When running in parallel the tasks are grouped by
+// the slot they run in, groups run in parallel and tasks in a group in order.
+func (r *Runner) DryRun(w io.Writer, ids ...string) error {
+	groups, err := r.schedule(ids)
+	if err != nil {
+		return err
+	}
+
+	for i, group := range groups {
+		if r.parallel {
+			fmt.Fprintf(w, "group %d:\n", i+1)
+		}
+
+		for _, id := range group {
+			if r.parallel {
+				fmt.Fprintf(w, "  %s\n", id)
+			} else {
+				fmt.Fprintln(w, id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// schedule returns the tasks that would run for each task id in the order
+// they are started, with dependencies before the tasks that depends on them.
+func (r *Runner) schedule(ids []string) ([][]string, error) {
+	ids, err := r.expand(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(r.since) > 0 {
+		if ids, err = r.filterChanged(ids); err != nil {
+			return nil, err
+		}
+	}
+
+	var groups [][]string
+
+	for _, id := range r.sort(ids) {
+		var plan []string
+		r.plan(id, true, &plan)
+
+		var group []string
+		for _, id := range plan {
+			if !r.skipped(id) {
+				group = append(group, id)
+			}
+		}
+
+		if len(group) > 0 {
+			groups = append(groups, group)
+		}
+	}
+
+	// Tasks run in order when not running in parallel.
+	if !r.parallel && len(groups) > 1 {
+		var all []string
+		for _, group := range groups {
+			all = append(all, group...)
+		}
+
+		groups = [][]string{all}
+	}
+
+	return groups, nil
+}
+
+// ListDeps writes the transitive dependencies of a task in execution order,
+// one task per line, without running them. Dependencies used by many tasks
+// are only listed the first time and dependencies that would be skipped by
+// their status commands or cache key are marked with the reason.
+func (r *Runner) ListDeps(w io.Writer, id string) error {
+	if r.Task(id) == nil {
+		return r.missing(id)
+	}
+
+	var plan []string
+	r.plan(id, false, &plan)
+
+	seen := map[string]bool{id: true}
+
+	for _, dep := range plan {
+		if seen[dep] {
+			continue
+		}
+
+		seen[dep] = true
+
+		if r.Task(dep) == nil {
+			fmt.Fprintf(w, "%s (missing)\n", dep)
+		} else if reason := r.skipReason(dep); len(reason) > 0 {
+			fmt.Fprintf(w, "%s (%s)\n", dep, reason)
+		} else {
+			fmt.Fprintln(w, dep)
+		}
+	}
+
+	return nil
+}
+
+// skipped reports whether a task would be skipped by its status commands or cache key.
+func (r *Runner) skipped(id string) bool {
+	return len(r.skipReason(id)) > 0
+}
+
+// skipReason returns why a task would be skipped, empty when it would run.
+func (r *Runner) skipReason(id string) string {
+	orig := r.Task(id)
+	if orig == nil || r.trusted(orig) != nil {
+		return ""
+	}
+
+	if orig.UpToDate(r.ctx) {
+		return "skipped, up to date"
+	}
+
+	if len(orig.CacheKey) == 0 {
+		return ""
+	}
+
+	t := r.prepareTask(orig.Copy())
+	t.ID(id)
+
+	if t.Prepare() == nil && r.cached(t) {
+		return "skipped, cache key is up to date"
+	}
+
+	return ""
+}
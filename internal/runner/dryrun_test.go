@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/task"
+)
+
+func TestRunnerDryRun(t *testing.T) {
+	run := func(opts ...Option) string {
+		var buf bytes.Buffer
+		var out bytes.Buffer
+
+		runner := New(append([]Option{
+			Config(&config.Config{
+				Tasks: map[string]*task.Task{
+					"build": {
+						Commands: yaml2.NewList("echo build"),
+						Deps:     []string{"generate", "vendor"},
+					},
+					"generate": {
+						Commands: yaml2.NewList("echo generate"),
+					},
+					"lint": {
+						Commands: yaml2.NewList("echo lint"),
+					},
+					"vendor": {
+						Commands: yaml2.NewList("echo vendor"),
+						Status:   yaml2.NewList("true"),
+					},
+				},
+				Variables: map[string]interface{}{},
+			}),
+		}, opts...)...)
+
+		runner.Stdout = &out
+
+		if err := runner.DryRun(&buf, "build", "lint"); err != nil {
+			t.Fatalf("Expected: nil, got: %s", err)
+		}
+
+		if out.Len() > 0 {
+			t.Errorf("Expected: no task output, got: %s", out.String())
+		}
+
+		return buf.String()
+	}
+
+	if got := run(); got != "generate\nbuild\nlint\n" {
+		t.Errorf("Expected: tasks in execution order, got: %s", got)
+	}
+
+	if got := run(NoDeps(true)); got != "build\nlint\n" {
+		t.Errorf("Expected: tasks without deps, got: %s", got)
+	}
+
+	if got := run(Parallel(true)); got != "group 1:\n  generate\n  build\ngroup 2:\n  lint\n" {
+		t.Errorf("Expected: tasks grouped by slot, got: %s", got)
+	}
+}
+
+func TestRunnerListDeps(t *testing.T) {
+	var buf bytes.Buffer
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"build": {
+					Commands: yaml2.NewList("echo build"),
+					Deps:     []string{"generate", "vendor"},
+				},
+				"deploy": {
+					Commands: yaml2.NewList("echo deploy"),
+					Deps:     []string{"build", "generate", "upload"},
+				},
+				"generate": {
+					Commands: yaml2.NewList("echo generate"),
+				},
+				"vendor": {
+					Commands: yaml2.NewList("echo vendor"),
+					Status:   yaml2.NewList("true"),
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+	)
+
+	if err := runner.ListDeps(&buf, "deploy"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	exp := "generate\nvendor (skipped, up to date)\nbuild\nupload (missing)\n"
+	if got := buf.String(); got != exp {
+		t.Errorf("Expected: %q, got: %q", exp, got)
+	}
+
+	if err := runner.ListDeps(&buf, "missing"); err == nil {
+		t.Error("Expected: error, got: nil")
+	}
+}
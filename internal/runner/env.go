@@ -0,0 +1,148 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/frozzare/max/internal/exec"
+	"github.com/frozzare/max/internal/task"
+)
+
+// ShowEnv writes the environment that a task's commands would receive
+// without running the task. The environment is built like when the task
+// is executed: the process environment, unless the task runs in docker,
+// or only PATH and HOME with a clean environment, followed by env files,
+// captured, env_from, shell and task variables. Capture and shell variable
+// commands are run to resolve their values, the env_from tasks that has not
+// run only runs their capture commands. Values of variables that looks like
+// secrets are masked. Tasks that are not trusted are errors since their
+// commands are not run.
+func (r *Runner) ShowEnv(w io.Writer, id string) error {
+	orig := r.Task(id)
+	if orig == nil {
+		return r.missing(id)
+	}
+
+	t := r.prepareTask(orig.Copy())
+	t.ID(id)
+	r.cleanEnv(t)
+
+	// Remote commands are only run from trusted hosts.
+	if err := r.trusted(t); err != nil {
+		return err
+	}
+
+	if err := t.ExpandPaths(); err != nil {
+		return err
+	}
+
+	if err := r.captureEnvFrom(t, map[string]bool{id: true}); err != nil {
+		return err
+	}
+
+	if err := r.loadEnv(t, r.Stderr); err != nil {
+		return err
+	}
+
+	if err := t.Prepare(); err != nil {
+		return err
+	}
+
+	vars := toEnv(t.Env())
+
+	// Docker containers don't get the process environment.
+	if t.Docker == nil {
+		vars = (&exec.Options{CleanEnv: t.CleanEnvEnabled(), Env: vars}).Environ()
+	}
+
+	env := make(map[string]string)
+
+	for _, kv := range vars {
+		if i := strings.Index(kv, "="); i > 0 {
+			env[kv[:i]] = kv[i+1:]
+		}
+	}
+
+	for _, k := range sortedStringKeys(env) {
+		fmt.Fprintf(w, "%s=%s\n", k, task.Mask(k, env[k]))
+	}
+
+	return nil
+}
+
+// loadEnv loads the variables a task's commands runs with: env files,
+// variables captured by earlier tasks and by the env_from tasks, the task's
+// own captured variables, that are shared with later tasks, and the shell
+// variables the task references.
+func (r *Runner) loadEnv(t *task.Task, stderr io.Writer) error {
+	// Load env files before capturing variables so capture commands can use them.
+	if err := t.LoadEnvFiles(); err != nil {
+		return err
+	}
+
+	// Use variables captured by earlier tasks.
+	t.Options(task.Variables(r.captured.get()))
+
+	// Import variables captured by the env_from tasks, they take precedence
+	// over variables captured by other tasks.
+	for _, id := range t.EnvFrom {
+		vars, ok := r.captured.task(id)
+		if !ok {
+			return fmt.Errorf("max: task %s imports env from %s but %s has not run or captured no variables", t.ID(), id, id)
+		}
+
+		t.Options(task.Variables(vars))
+	}
+
+	// Capture the task's own variables and share them with later tasks.
+	vars, err := t.CaptureVariables(r.ctx, stderr)
+	if err != nil {
+		return err
+	}
+
+	r.captured.set(t.ID(), vars)
+
+	// Run shell variable commands that are referenced by the task.
+	return t.ResolveShellVariables(r.ctx, stderr)
+}
+
+// captureEnvFrom captures the variables of the env_from tasks that has not
+// run, and of their env_from tasks, without running their commands. Seen
+// contains the tasks that are already being captured.
+func (r *Runner) captureEnvFrom(t *task.Task, seen map[string]bool) error {
+	for _, id := range t.EnvFrom {
+		if _, ok := r.captured.task(id); ok || seen[id] {
+			continue
+		}
+
+		seen[id] = true
+
+		orig := r.Task(id)
+		if orig == nil {
+			return r.missing(id)
+		}
+
+		other := r.prepareTask(orig.Copy())
+		other.ID(id)
+		r.cleanEnv(other)
+
+		if err := r.trusted(other); err != nil {
+			return err
+		}
+
+		if err := other.ExpandPaths(); err != nil {
+			return err
+		}
+
+		if err := r.captureEnvFrom(other, seen); err != nil {
+			return err
+		}
+
+		if err := r.loadEnv(other, r.Stderr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package runner
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/task"
+)
+
+func TestRunnerShowEnv(t *testing.T) {
+	var buf bytes.Buffer
+	var out bytes.Buffer
+
+	os.Setenv("MAX_SHOW_ENV", "process")
+	defer os.Unsetenv("MAX_SHOW_ENV")
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"deploy": {
+					Commands: yaml2.NewList("echo deploy"),
+					Variables: map[string]interface{}{
+						"API_TOKEN": "secret",
+						"REGION":    "{{ .region }}",
+					},
+				},
+			},
+			Variables: map[string]interface{}{"region": "eu"},
+		}),
+	)
+
+	runner.Stdout = &out
+
+	if err := runner.ShowEnv(&buf, "deploy"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	got := buf.String()
+
+	for _, exp := range []string{
+		"API_TOKEN=****\n",
+		"REGION=eu\n",
+		"MAX_SHOW_ENV=process\n",
+	} {
+		if !strings.Contains(got, exp) {
+			t.Errorf("Expected: %s in env, got: %s", exp, got)
+		}
+	}
+
+	if out.Len() > 0 {
+		t.Errorf("Expected: no task output, got: %s", out.String())
+	}
+
+	if err := runner.ShowEnv(&buf, "deplo"); err == nil {
+		t.Error("Expected: missing task error, got: nil")
+	}
+}
+
+func TestRunnerShowEnvClean(t *testing.T) {
+	var buf bytes.Buffer
+
+	os.Setenv("MAX_SHOW_ENV", "process")
+	defer os.Unsetenv("MAX_SHOW_ENV")
+
+	disabled := false
+
+	runner := New(
+		Config(&config.Config{
+			CleanEnv: true,
+			Tasks: map[string]*task.Task{
+				"clean": {
+					Commands:  yaml2.NewList("echo clean"),
+					Variables: map[string]interface{}{"REGION": "eu"},
+				},
+				"inherit": {
+					CleanEnv: &disabled,
+					Commands: yaml2.NewList("echo inherit"),
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+	)
+
+	if err := runner.ShowEnv(&buf, "clean"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if got := buf.String(); strings.Contains(got, "MAX_SHOW_ENV") || !strings.Contains(got, "REGION=eu\n") || !strings.Contains(got, "PATH=") {
+		t.Errorf("Expected: clean env with PATH and task variables, got: %s", got)
+	}
+
+	buf.Reset()
+
+	if err := runner.ShowEnv(&buf, "inherit"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if !strings.Contains(buf.String(), "MAX_SHOW_ENV=process\n") {
+		t.Errorf("Expected: process env, got: %s", buf.String())
+	}
+}
+
+func TestRunnerShowEnvFrom(t *testing.T) {
+	var buf bytes.Buffer
+	var out bytes.Buffer
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"api": {
+					Capture:  map[string]string{"version": "echo 1.0.0"},
+					Commands: yaml2.NewList("echo api"),
+				},
+				"deploy": {
+					Commands: yaml2.NewList("echo deploy"),
+					EnvFrom:  []string{"api"},
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+	)
+
+	runner.Stdout = &out
+
+	if err := runner.ShowEnv(&buf, "deploy"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if got := buf.String(); !strings.Contains(got, "version=1.0.0\n") {
+		t.Errorf("Expected: version=1.0.0 in env, got: %s", got)
+	}
+
+	// Only the capture commands of the env_from tasks runs.
+	if out.Len() > 0 {
+		t.Errorf("Expected: no task output, got: %s", out.String())
+	}
+}
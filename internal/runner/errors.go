@@ -0,0 +1,66 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrUpToDate is returned when a task is skipped since its status commands passes.
+var ErrUpToDate = errors.New("task is up to date")
+
+// TimeoutExitStatus is the exit status used when a run exceeds the timeout.
+const TimeoutExitStatus = 124
+
+// TimeoutError is returned when a run exceeds the timeout and all tasks are cancelled.
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+// Error returns the error message with the timeout.
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("max: run exceeded the timeout of %s", e.Timeout)
+}
+
+// TaskError represents a failed task.
+type TaskError struct {
+	ID  string
+	Err error
+}
+
+// Error returns the task id and the error message.
+func (e *TaskError) Error() string {
+	return fmt.Sprintf("%s: %s", e.ID, e.Err)
+}
+
+// Unwrap returns the task's error.
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// Errors represents all failed tasks of a run.
+type Errors []*TaskError
+
+// Error returns all task errors as a single message.
+func (e Errors) Error() string {
+	ids := make([]string, len(e))
+	msgs := make([]string, len(e))
+
+	for i, err := range e {
+		ids[i] = err.ID
+		msgs[i] = err.Error()
+	}
+
+	return fmt.Sprintf("failed tasks %s (%s)", strings.Join(ids, ", "), strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the task errors.
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
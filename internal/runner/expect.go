@@ -0,0 +1,38 @@
+package runner
+
+import "github.com/frozzare/max/internal/task"
+
+// maxExpectOutput is the number of trailing output bytes the task's
+// expectations are checked against.
+const maxExpectOutput = 1024 * 1024
+
+// expect checks the output and exit status of a attempt against the task's
+// expectations, errors that aren't exit errors are returned as is. Tasks
+// that expects a exit status other than zero succeeds when the commands
+// exits with it.
+func (r *Runner) expect(t *task.Task, output *tailBuffer, err error) error {
+	if t.Expect == nil {
+		return err
+	}
+
+	status := 0
+	if err != nil {
+		if !IsExitError(err) {
+			return err
+		}
+
+		status = ExitStatus(err)
+	}
+
+	failures, cerr := t.Expect.Check(string(output.Bytes()), status)
+	if cerr != nil {
+		return cerr
+	}
+
+	if len(failures) > 0 {
+		r.whyf(t.ID(), "fails, output doesn't satisfy the expectations")
+		return &task.ExpectError{Failures: failures, ID: t.ID(), Output: string(output.Bytes())}
+	}
+
+	return nil
+}
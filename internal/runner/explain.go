@@ -0,0 +1,216 @@
+package runner
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/frozzare/max/internal/exec"
+	"github.com/frozzare/max/internal/graph"
+	"github.com/frozzare/max/internal/task"
+)
+
+// Explain writes the execution plan for a task without running it: tasks in
+// execution order with interpolated commands, environment, working directory
+// and skip conditions. Status commands are run to evaluate skip conditions,
+// except for tasks that are not trusted.
+func (r *Runner) Explain(w io.Writer, id string) error {
+	if r.Task(id) == nil {
+		return r.missing(id)
+	}
+
+	g, err := graph.New(r.config).Subgraph(id)
+	if err != nil {
+		return err
+	}
+
+	if cycle := g.Cycle(); cycle != nil {
+		return graph.CycleError(cycle)
+	}
+
+	var plan []string
+	r.plan(id, true, &plan)
+
+	fmt.Fprintf(w, "Plan for %s:\n", id)
+
+	for i, id := range plan {
+		if err := r.explainTask(w, i+1, id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// plan appends tasks in the order they are run.
+func (r *Runner) plan(id string, root bool, plan *[]string) {
+	t := r.Task(id)
+	if t == nil {
+		*plan = append(*plan, id)
+		return
+	}
+
+	if !root || !r.noDeps {
+		for _, dep := range r.sort(t.AllDeps()) {
+			r.plan(dep, false, plan)
+		}
+	}
+
+	for _, other := range t.Tasks.Values {
+		r.plan(other, false, plan)
+	}
+
+	*plan = append(*plan, id)
+}
+
+func (r *Runner) explainTask(w io.Writer, n int, id string) error {
+	orig := r.Task(id)
+	if orig == nil {
+		fmt.Fprintf(w, "\n%d. %s (missing)\n", n, id)
+		return nil
+	}
+
+	t := r.prepareTask(orig.Copy())
+	t.ID(id)
+
+	if err := t.ExpandPaths(); err != nil {
+		return err
+	}
+
+	if err := t.LoadEnvFiles(); err != nil {
+		return err
+	}
+
+	// Shell variables are not run, their commands are shown instead.
+	for k, command := range t.ShellVariables() {
+		t.Variables[k] = fmt.Sprintf("$(%s)", command)
+	}
+
+	if err := t.Prepare(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\n%d. %s\n", n, id)
+
+	// Mask secret values in interpolated commands.
+	masked := t.MaskSecrets
+
+	dir := t.Dir
+	if len(dir) == 0 {
+		dir, _ = os.Getwd()
+	}
+
+	fmt.Fprintf(w, "   dir: %s\n", dir)
+
+	if t.Docker != nil {
+		fmt.Fprintf(w, "   docker: %s\n", t.Docker.Image)
+	}
+
+	if len(t.Shell) > 0 {
+		fmt.Fprintf(w, "   shell: %s\n", t.Shell)
+	}
+
+	if len(t.Capture) > 0 {
+		fmt.Fprintln(w, "   capture:")
+		for _, k := range sortedStringKeys(t.Capture) {
+			fmt.Fprintf(w, "     %s: $ %s\n", k, masked(t.Capture[k]))
+		}
+	}
+
+	if len(t.Script) > 0 {
+		fmt.Fprintln(w, "   script:")
+		for _, line := range strings.Split(t.Script, "\n") {
+			fmt.Fprintf(w, "     %s\n", masked(line))
+		}
+	}
+
+	if len(t.Commands.Values) > 0 {
+		fmt.Fprintln(w, "   commands:")
+		for _, c := range t.Commands.Values {
+			fmt.Fprintf(w, "     $ %s\n", masked(c))
+		}
+	}
+
+	if env := t.Env(); len(env) > 0 {
+		fmt.Fprintln(w, "   env:")
+		for _, k := range sortedStringKeys(env) {
+			fmt.Fprintf(w, "     %s=%s\n", k, task.Mask(k, env[k]))
+		}
+	}
+
+	if len(t.Status.Values) > 0 || len(t.CacheKey) > 0 || len(t.Deprecated) > 0 {
+		fmt.Fprintln(w, "   skip:")
+	}
+
+	// Remote commands are only run from trusted hosts.
+	untrusted := r.trusted(t)
+	if untrusted != nil {
+		fmt.Fprintf(w, "   trust: %s\n", strings.TrimPrefix(untrusted.Error(), "max: "))
+	}
+
+	for _, c := range t.Status.Values {
+		if untrusted != nil {
+			fmt.Fprintf(w, "     status $ %s: not run, task is not trusted\n", masked(c))
+			continue
+		}
+
+		err := exec.Exec(&exec.Options{
+			Context: r.ctx,
+			Dir:     t.Dir,
+			Env:     toEnv(t.Env()),
+			Command: c,
+			Shell:   t.Shell,
+		})
+
+		result := "passes, task is up to date if all status commands passes"
+		if err != nil {
+			result = fmt.Sprintf("fails (%s), task runs", err)
+		}
+
+		fmt.Fprintf(w, "     status $ %s: %s\n", masked(c), result)
+	}
+
+	if len(t.CacheKey) > 0 {
+		result := "changed, task runs"
+		if r.cached(t) {
+			result = "unchanged, task is skipped"
+		}
+
+		fmt.Fprintf(w, "     cache_key %s: %s\n", t.CacheKey, result)
+	}
+
+	if len(t.Deprecated) > 0 {
+		result := "warning"
+		if r.failDeprecated {
+			result = "fails"
+		}
+
+		fmt.Fprintf(w, "     deprecated %s: %s\n", t.Deprecated, result)
+	}
+
+	return nil
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func toEnv(env map[string]string) []string {
+	var res []string
+
+	for k, v := range env {
+		res = append(res, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	return res
+}
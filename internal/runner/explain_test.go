@@ -0,0 +1,99 @@
+package runner
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/task"
+)
+
+func TestRunnerExplain(t *testing.T) {
+	var buf bytes.Buffer
+	var out bytes.Buffer
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"build": {
+					Commands: yaml2.NewList("echo build {{ .name }}"),
+					Status:   yaml2.NewList("false"),
+				},
+				"deploy": {
+					Args:     map[string]interface{}{"name": "max"},
+					Commands: yaml2.NewList("echo deploy $API_TOKEN"),
+					Deps:     []string{"build"},
+					Variables: map[string]interface{}{
+						"API_TOKEN": "secret",
+						"REGION":    "eu",
+					},
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+	)
+
+	runner.Stdout = &out
+
+	if err := runner.Explain(&buf, "deploy"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	got := buf.String()
+
+	for _, exp := range []string{
+		"1. build",
+		"$ echo build <no value>",
+		"status $ false: fails",
+		"2. deploy",
+		"$ echo deploy ****",
+		"API_TOKEN=****",
+		"REGION=eu",
+	} {
+		if !strings.Contains(got, exp) {
+			t.Errorf("Expected: %s in plan, got: %s", exp, got)
+		}
+	}
+
+	if out.Len() > 0 {
+		t.Errorf("Expected: no task output, got: %s", out.String())
+	}
+
+	if err := runner.Explain(&buf, "deplo"); err == nil || !strings.Contains(err.Error(), "did you mean deploy") {
+		t.Errorf("Expected: missing task error, got: %v", err)
+	}
+}
+
+func TestRunnerExplainUntrusted(t *testing.T) {
+	var buf bytes.Buffer
+
+	remote := &task.Task{
+		Commands: yaml2.NewList("echo remote"),
+		Status:   yaml2.NewList("echo status >&2"),
+	}
+	remote.Origin("example.com")
+
+	runner := New(
+		Config(&config.Config{
+			Tasks:     map[string]*task.Task{"remote": remote},
+			Variables: map[string]interface{}{},
+		}),
+	)
+
+	var out bytes.Buffer
+	runner.Stdout, runner.Stderr = &out, &out
+
+	if err := runner.Explain(&buf, "remote"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if got := buf.String(); !strings.Contains(got, "status $ echo status >&2: not run, task is not trusted") {
+		t.Errorf("Expected: status to not run, got: %s", got)
+	}
+
+	if err := runner.ShowEnv(&buf, "remote"); err == nil || !strings.Contains(err.Error(), "not trusted") {
+		t.Errorf("Expected: untrusted error, got: %v", err)
+	}
+}
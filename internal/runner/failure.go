@@ -0,0 +1,111 @@
+package runner
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/frozzare/go/yaml2"
+	"github.com/frozzare/max/internal/task"
+)
+
+// failures contains the errors that failure handlers have run for so a
+// failure is only handled once, by the failed task and not by the tasks
+// that depends on it.
+type failures struct {
+	errs []error
+
+	sync.Mutex
+}
+
+func newFailures() *failures {
+	return &failures{}
+}
+
+// handle reports whether a handler should run for the error and marks
+// the error as handled.
+func (f *failures) handle(err error) bool {
+	f.Lock()
+	defer f.Unlock()
+
+	if reflect.TypeOf(err).Comparable() {
+		for _, e := range f.errs {
+			if reflect.TypeOf(e) == reflect.TypeOf(err) && e == err {
+				return false
+			}
+		}
+	}
+
+	f.errs = append(f.errs, err)
+
+	return true
+}
+
+// onFailure runs the task's on_failure handler, or the global on_failure
+// handler for tasks without one, when the task fails. A handler with a
+// single task name runs the task and other handlers runs as commands on
+// the host in the failed task's directory. MAX_FAILED_TASK, MAX_ERROR and
+// MAX_EXIT_STATUS contains the failure. Failed handlers are logged as
+// warnings and the task's error is kept. Handlers are not run for tasks
+// that are not trusted.
+func (r *Runner) onFailure(t *task.Task, err error) {
+	handler := t.OnFailure
+	if len(handler.Values) == 0 && r.config != nil {
+		handler = r.config.OnFailure
+	}
+
+	// Failure handlers don't trigger other failure handlers.
+	if len(handler.Values) == 0 || r.handler || errors.Is(err, ErrUpToDate) || !r.failures.handle(err) {
+		return
+	}
+
+	// Handlers of remote tasks are only run from trusted hosts.
+	if r.trusted(t) != nil {
+		return
+	}
+
+	vars := map[string]interface{}{
+		"MAX_ERROR":       err.Error(),
+		"MAX_EXIT_STATUS": strconv.Itoa(ExitStatus(err)),
+		"MAX_FAILED_TASK": t.ID(),
+	}
+
+	if !r.quiet {
+		r.log.Printf("Running failure handler of task %s\n", color.GreenString(t.ID()))
+	}
+
+	if herr := r.handleFailure(t, handler, vars); herr != nil {
+		r.log.Printf("max: warning: on_failure of task %s failed: %s\n", t.ID(), herr)
+	}
+}
+
+// handleFailure runs a failure handler task or commands with the variables.
+func (r *Runner) handleFailure(t *task.Task, handler yaml2.List, vars map[string]interface{}) error {
+	if id := handler.Values[0]; len(handler.Values) == 1 && r.Task(id) != nil {
+		h := r.Task(id).Copy()
+		h.ID(id)
+		h.Options(task.Variables(vars))
+
+		if err := r.trusted(h); err != nil {
+			return err
+		}
+
+		c := r.child(Once(true))
+		c.handler = true
+
+		return <-c.execAll(h)
+	}
+
+	// Run the commands as a task with only the commands.
+	c := t.Copy()
+	c.Commands = yaml2.NewList(append([]string{}, handler.Values...))
+	c.Deps = nil
+	c.Expect = nil
+	c.Post = yaml2.List{}
+	c.Script = ""
+	c.Tasks = yaml2.List{}
+
+	return r.post(c, vars)
+}
@@ -1,15 +1,60 @@
 package runner
 
 import (
+	"context"
+	"io"
 	"log"
+	"time"
 
 	"github.com/frozzare/max/internal/backend"
+	"github.com/frozzare/max/internal/cache"
 	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/metrics"
+	"github.com/frozzare/max/internal/task"
 )
 
 // Option configures a runtime option.
 type Option func(*Runner)
 
+// Bench returns an option configured with a bench value, the duration of
+// each command in a task is reported after the task.
+func Bench(bench bool) Option {
+	return func(r *Runner) {
+		r.bench = bench
+	}
+}
+
+// Budget returns an option configured with a time budget, tasks that
+// are not started when the budget is exceeded are skipped.
+func Budget(budget time.Duration) Option {
+	return func(r *Runner) {
+		r.budget = budget
+	}
+}
+
+// BudgetCancel returns an option configured with a budget cancel value,
+// running tasks are cancelled when the budget is exceeded.
+func BudgetCancel(cancel bool) Option {
+	return func(r *Runner) {
+		r.budgetCancel = cancel
+	}
+}
+
+// Cache returns an option configured with a cache value.
+func Cache(cache cache.Store) Option {
+	return func(r *Runner) {
+		r.cache = cache
+	}
+}
+
+// Context returns an option configured with a context, running tasks are
+// cancelled when the context is done, e.g when max is interrupted.
+func Context(ctx context.Context) Option {
+	return func(r *Runner) {
+		r.ctx = ctx
+	}
+}
+
 // Config returns an option configured with a config value.
 func Config(config *config.Config) Option {
 	return func(r *Runner) {
@@ -24,6 +69,30 @@ func Engine(engine backend.Engine) Option {
 	}
 }
 
+// FailDeprecated returns an option configured with a fail deprecated value,
+// running deprecated tasks fails instead of printing a warning.
+func FailDeprecated(failDeprecated bool) Option {
+	return func(r *Runner) {
+		r.failDeprecated = failDeprecated
+	}
+}
+
+// FailFast returns an option configured with a fail fast value.
+func FailFast(failFast bool) Option {
+	return func(r *Runner) {
+		r.failFast = failFast
+	}
+}
+
+// Flags returns an option configured with parsed task flags, the values
+// overrides global arguments and --key flags, e.g typed values from
+// task.ParseFlags.
+func Flags(flags map[string]interface{}) Option {
+	return func(r *Runner) {
+		r.flags = flags
+	}
+}
+
 // Log returns an option configured with a log value.
 func Log(log *log.Logger) Option {
 	return func(r *Runner) {
@@ -31,6 +100,29 @@ func Log(log *log.Logger) Option {
 	}
 }
 
+// MaxConcurrency returns an option configured with the max number of tasks
+// running at the same time with the Parallel option, regardless of their
+// weight. The lowest of the option and the config's max_concurrency is used.
+func MaxConcurrency(n int) Option {
+	return func(r *Runner) {
+		r.maxConcurrency = n
+	}
+}
+
+// Metrics returns an option configured with a metrics collector.
+func Metrics(metrics *metrics.Metrics) Option {
+	return func(r *Runner) {
+		r.metrics = metrics
+	}
+}
+
+// NoDeps returns an option configured with a no deps value.
+func NoDeps(noDeps bool) Option {
+	return func(r *Runner) {
+		r.noDeps = noDeps
+	}
+}
+
 // Once returns an option configured with a once value.
 func Once(once bool) Option {
 	return func(r *Runner) {
@@ -38,6 +130,102 @@ func Once(once bool) Option {
 	}
 }
 
+// Parallel returns an option configured with a parallel value, tasks
+// are run concurrently within the resource budget.
+func Parallel(parallel bool) Option {
+	return func(r *Runner) {
+		r.parallel = parallel
+	}
+}
+
+// Prefix returns an option configured with a prefix value, output lines
+// from task commands are prefixed with the task id, e.g [build].
+func Prefix(prefix bool) Option {
+	return func(r *Runner) {
+		r.prefix = prefix
+	}
+}
+
+// RemoteCache returns an option configured with a remote cache, tasks with
+// a cache key that have run with the same key on another machine are skipped.
+func RemoteCache(remote cache.Store) Option {
+	return func(r *Runner) {
+		r.remote = remote
+	}
+}
+
+// Resolvers returns an option configured with variable resolvers by scheme,
+// e.g a vault resolver resolves variables with vault:secret/db values.
+func Resolvers(resolvers map[string]task.VarResolver) Option {
+	return func(r *Runner) {
+		r.resolvers = resolvers
+	}
+}
+
+// Resources returns an option configured with a resource budget for parallel
+// tasks, the combined weight of running tasks don't exceed the budget.
+func Resources(resources int) Option {
+	return func(r *Runner) {
+		if resources > 0 {
+			r.resources = resources
+		}
+	}
+}
+
+// Since returns an option configured with a git ref, only tasks with sources
+// matching files changed since the ref, or depending on such tasks, are run.
+func Since(ref string) Option {
+	return func(r *Runner) {
+		r.since = ref
+	}
+}
+
+// SkipNoSources returns an option configured with a skip no sources value,
+// tasks without sources are skipped instead of run when using Since.
+func SkipNoSources(skip bool) Option {
+	return func(r *Runner) {
+		r.skipNoSources = skip
+	}
+}
+
+// Stream returns an option configured with a stream writer, task output
+// is written as json line events to the writer instead of stdout and stderr.
+func Stream(w io.Writer) Option {
+	s := newStream(w)
+
+	return func(r *Runner) {
+		r.stream = s
+	}
+}
+
+// StrictTemplates returns an option configured with a strict templates value,
+// missing keys in task templates are errors instead of "<no value>".
+func StrictTemplates(strict bool) Option {
+	return func(r *Runner) {
+		r.strict = strict
+	}
+}
+
+// Timeout returns an option configured with a timeout for the whole run,
+// all tasks are cancelled when the timeout is exceeded.
+func Timeout(timeout time.Duration) Option {
+	return func(r *Runner) {
+		r.timeout = timeout
+	}
+}
+
+// Trust returns an option configured with the hosts that tasks included
+// from urls are trusted to run from.
+func Trust(hosts []string) Option {
+	return func(r *Runner) {
+		r.trust = make(map[string]bool, len(hosts))
+
+		for _, host := range hosts {
+			r.trust[host] = true
+		}
+	}
+}
+
 // Quiet returns an option configured with a quiet value.
 func Quiet(quiet bool) Option {
 	return func(r *Runner) {
@@ -51,3 +239,11 @@ func Verbose(verbose bool) Option {
 		r.verbose = verbose
 	}
 }
+
+// Why returns an option configured with a why value, the reason a task
+// runs or is skipped by its status commands, cache key or sources is logged.
+func Why(why bool) Option {
+	return func(r *Runner) {
+		r.why = why
+	}
+}
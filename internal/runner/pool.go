@@ -0,0 +1,120 @@
+package runner
+
+import (
+	"io"
+	"sync"
+)
+
+// pool limits the combined weight of running tasks.
+type pool struct {
+	cond *sync.Cond
+	free int
+	size int
+}
+
+func newPool(size int) *pool {
+	if size < 1 {
+		size = 1
+	}
+
+	return &pool{
+		cond: sync.NewCond(&sync.Mutex{}),
+		free: size,
+		size: size,
+	}
+}
+
+// clamp returns a weight within the pool size so heavy tasks can run alone.
+func (p *pool) clamp(w int) int {
+	if w > p.size {
+		return p.size
+	}
+
+	return w
+}
+
+// acquire waits until the weight is available.
+func (p *pool) acquire(w int) {
+	w = p.clamp(w)
+
+	p.cond.L.Lock()
+	defer p.cond.L.Unlock()
+
+	for p.free < w {
+		p.cond.Wait()
+	}
+
+	p.free -= w
+}
+
+// release makes the weight available.
+func (p *pool) release(w int) {
+	w = p.clamp(w)
+
+	p.cond.L.Lock()
+	p.free += w
+	p.cond.L.Unlock()
+
+	p.cond.Broadcast()
+}
+
+// slots limits the number of running tasks regardless of their weight.
+type slots chan struct{}
+
+// newSlots returns slots for n tasks, nil slots don't limit tasks.
+func newSlots(n int) slots {
+	if n < 1 {
+		return nil
+	}
+
+	return make(slots, n)
+}
+
+// acquire waits until a slot is free.
+func (s slots) acquire() {
+	if s != nil {
+		s <- struct{}{}
+	}
+}
+
+// release frees a slot.
+func (s slots) release() {
+	if s != nil {
+		<-s
+	}
+}
+
+// concurrency returns the max number of tasks running at the same time,
+// the lowest of the MaxConcurrency option and the config's max_concurrency.
+// Zero is no limit.
+func (r *Runner) concurrency() int {
+	n := r.maxConcurrency
+
+	if c := r.config.MaxConcurrency; c > 0 && (n == 0 || c < n) {
+		n = c
+	}
+
+	return n
+}
+
+// weight returns the task weight used in parallel mode, default is 1.
+func (r *Runner) weight(id string) int {
+	if t := r.Task(id); t != nil && t.Weight > 0 {
+		return t.Weight
+	}
+
+	return 1
+}
+
+// lockedWriter serializes writes from tasks running in parallel.
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return w.w.Write(p)
+}
@@ -0,0 +1,115 @@
+package runner
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/frozzare/max/internal/config"
+)
+
+func TestPool(t *testing.T) {
+	p := newPool(3)
+
+	var (
+		mu      sync.Mutex
+		current int
+		max     int
+		wg      sync.WaitGroup
+	)
+
+	for _, w := range []int{2, 2, 1, 5, 1} {
+		p.acquire(w)
+		wg.Add(1)
+
+		go func(w int) {
+			defer wg.Done()
+			defer p.release(w)
+
+			w = p.clamp(w)
+
+			mu.Lock()
+			current += w
+			if current > max {
+				max = current
+			}
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			current -= w
+			mu.Unlock()
+		}(w)
+	}
+
+	wg.Wait()
+
+	if max > 3 {
+		t.Errorf("Expected: max weight 3, got: %d", max)
+	}
+}
+
+func TestRunnerMaxConcurrency(t *testing.T) {
+	tests := []struct {
+		option int
+		config int
+		exp    int
+	}{
+		{0, 0, 0},
+		{2, 0, 2},
+		{0, 3, 3},
+		{2, 3, 2},
+		{4, 1, 1},
+	}
+
+	for _, test := range tests {
+		r := New(Config(&config.Config{MaxConcurrency: test.config}), MaxConcurrency(test.option))
+
+		if got := r.concurrency(); got != test.exp {
+			t.Errorf("Expected: %d for %+v, got: %d", test.exp, test, got)
+		}
+	}
+}
+
+func TestSlots(t *testing.T) {
+	s := newSlots(2)
+
+	var (
+		mu      sync.Mutex
+		current int
+		max     int
+		wg      sync.WaitGroup
+	)
+
+	for i := 0; i < 5; i++ {
+		s.acquire()
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			defer s.release()
+
+			mu.Lock()
+			if current++; current > max {
+				max = current
+			}
+			mu.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mu.Lock()
+			current--
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	if max != 2 {
+		t.Errorf("Expected: 2 running tasks, got: %d", max)
+	}
+
+	// Nil slots don't limit tasks.
+	newSlots(0).acquire()
+}
@@ -0,0 +1,138 @@
+package runner
+
+import (
+	"context"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/frozzare/go/yaml2"
+	backendConfig "github.com/frozzare/max/internal/backend/config"
+	"github.com/frozzare/max/internal/backend/local"
+	"github.com/frozzare/max/internal/task"
+)
+
+// posts contains the post commands of started tasks, they are run in
+// reverse order when the run is done.
+type posts struct {
+	ids     map[string]bool
+	running sync.Mutex
+	stack   []*task.Task
+
+	sync.Mutex
+}
+
+func newPosts() *posts {
+	return &posts{ids: make(map[string]bool)}
+}
+
+// push registers the post commands of a task once per task id.
+func (p *posts) push(t *task.Task) {
+	if len(t.Post.Values) == 0 {
+		return
+	}
+
+	p.Lock()
+	defer p.Unlock()
+
+	if p.ids[t.ID()] {
+		return
+	}
+
+	// Run the post commands as a task with only the post commands.
+	c := t.Copy()
+	c.Commands = yaml2.NewList(append([]string{}, t.Post.Values...))
+	c.Deps = nil
+	c.Post = yaml2.List{}
+	c.Script = ""
+	c.Tasks = yaml2.List{}
+
+	p.ids[t.ID()] = true
+	p.stack = append(p.stack, c)
+}
+
+// pop returns the last registered post task, nil when empty.
+func (p *posts) pop() *task.Task {
+	p.Lock()
+	defer p.Unlock()
+
+	if len(p.stack) == 0 {
+		return nil
+	}
+
+	t := p.stack[len(p.stack)-1]
+	p.stack = p.stack[:len(p.stack)-1]
+
+	return t
+}
+
+// Cleanup runs the post commands of the tasks that have started in reverse
+// order, the run error is available in the MAX_STATUS and MAX_ERROR variables.
+// Failed post commands are logged as warnings and the remaining post commands
+// are still run. Post commands are only run once and concurrent calls waits
+// for the running post commands, e.g when the run is interrupted by a signal
+// and RunAll returns.
+func (r *Runner) Cleanup(err error) {
+	r.posts.running.Lock()
+	defer r.posts.running.Unlock()
+
+	status, msg := "success", ""
+	if err != nil {
+		status, msg = "failure", err.Error()
+	}
+
+	for t := r.posts.pop(); t != nil; t = r.posts.pop() {
+		if !r.quiet {
+			r.log.Printf("Running post commands of task %s\n", color.GreenString(t.ID()))
+		}
+
+		vars := map[string]interface{}{
+			"MAX_ERROR":  msg,
+			"MAX_STATUS": status,
+		}
+
+		if err := r.post(t, vars); err != nil {
+			r.log.Printf("max: warning: post commands of task %s failed: %s\n", t.ID(), err)
+		}
+	}
+}
+
+// post runs post commands on the host, even when the run was cancelled.
+// Commands of remote tasks are only run from trusted hosts.
+func (r *Runner) post(t *task.Task, vars map[string]interface{}) error {
+	if err := r.trusted(t); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	t = r.prepareTask(t)
+
+	if err := t.ExpandPaths(); err != nil {
+		return err
+	}
+
+	if err := t.LoadEnvFiles(); err != nil {
+		return err
+	}
+
+	t.Options(task.Variables(r.captured.get()), task.Variables(vars))
+
+	if err := t.Prepare(); err != nil {
+		return err
+	}
+
+	engine := local.New(&backendConfig.Backend{
+		Log:     r.log,
+		Stdin:   r.Stdin,
+		Stdout:  r.Stdout,
+		Stderr:  r.Stderr,
+		Verbose: r.verbose,
+	})
+
+	defer engine.Destroy(ctx, t)
+
+	if err := engine.Setup(ctx, t); err != nil {
+		return err
+	}
+
+	return engine.Exec(ctx, t)
+}
@@ -0,0 +1,69 @@
+package runner
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// prefixWriter prefixes each line written to the underlying writer.
+// Partial lines are buffered until a newline is written or the writer is flushed.
+type prefixWriter struct {
+	buf    []byte
+	mu     sync.Mutex
+	prefix []byte
+	w      io.Writer
+}
+
+func newPrefixWriter(w io.Writer, prefix string) *prefixWriter {
+	return &prefixWriter{prefix: []byte(prefix), w: w}
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i == -1 {
+			break
+		}
+
+		if err := w.writeLine(w.buf[:i+1]); err != nil {
+			return len(p), err
+		}
+
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes a buffered partial line followed by a newline.
+func (w *prefixWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	err := w.writeLine(append(w.buf, '\n'))
+	w.buf = nil
+
+	return err
+}
+
+// writeLine writes the prefix and line in a single write so lines
+// from tasks running in parallel are not interleaved.
+func (w *prefixWriter) writeLine(line []byte) error {
+	out := make([]byte, 0, len(w.prefix)+len(line))
+	out = append(out, w.prefix...)
+	out = append(out, line...)
+
+	_, err := w.w.Write(out)
+
+	return err
+}
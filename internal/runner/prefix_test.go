@@ -0,0 +1,31 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrefixWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := newPrefixWriter(&buf, "[build] ")
+
+	w.Write([]byte("first\nsec"))
+	w.Write([]byte("ond\n"))
+
+	if buf.String() != "[build] first\n[build] second\n" {
+		t.Errorf("Expected: prefixed lines, got: %q", buf.String())
+	}
+
+	w.Write([]byte("partial"))
+
+	if buf.String() != "[build] first\n[build] second\n" {
+		t.Errorf("Expected: partial line to be buffered, got: %q", buf.String())
+	}
+
+	w.Flush()
+
+	if buf.String() != "[build] first\n[build] second\n[build] partial\n" {
+		t.Errorf("Expected: flushed partial line, got: %q", buf.String())
+	}
+}
@@ -0,0 +1,78 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/frozzare/max/internal/config"
+)
+
+// osNames contains the operating systems of os specific tasks, e.g
+// build_windows, they are not matched by task name prefixes.
+var osNames = []string{"aix", "android", "darwin", "dragonfly", "freebsd", "illumos", "ios", "js", "linux", "netbsd", "openbsd", "plan9", "solaris", "windows"}
+
+// AmbiguousTaskError is returned when a task name prefix matches more
+// than one task.
+type AmbiguousTaskError struct {
+	Name    string
+	Matches []string
+}
+
+// Error returns the error message with the matching task names.
+func (e *AmbiguousTaskError) Error() string {
+	return fmt.Sprintf("max: task %s is ambiguous, it matches %s", e.Name, strings.Join(e.Matches, ", "))
+}
+
+// ResolveTask returns the task name for a exact task name or a unique
+// task name prefix, e.g deploy for dep. Exact names always takes priority
+// over prefixes. A AmbiguousTaskError is returned with the candidates when
+// the prefix matches more than one task and a error when no task matches.
+func ResolveTask(c *config.Config, name string) (string, error) {
+	if c == nil || len(name) == 0 {
+		return "", fmt.Errorf("task missing: %s", name)
+	}
+
+	if c.Tasks[name] != nil {
+		return name, nil
+	}
+
+	var matches []string
+
+	for _, k := range c.List() {
+		if strings.HasPrefix(k, name) && !osTask(c, k) {
+			matches = append(matches, k)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("task missing: %s", name)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", &AmbiguousTaskError{Name: name, Matches: matches}
+	}
+}
+
+// Resolve returns the task name for a exact task name or a unique task
+// name prefix like ResolveTask, missing tasks are errors with suggested
+// task names.
+func (r *Runner) Resolve(name string) (string, error) {
+	id, err := ResolveTask(r.config, name)
+	if _, ok := err.(*AmbiguousTaskError); err != nil && !ok {
+		return "", r.missing(name)
+	}
+
+	return id, err
+}
+
+// osTask reports whether a task is a os specific variant of another task.
+func osTask(c *config.Config, name string) bool {
+	for _, goos := range osNames {
+		if base := strings.TrimSuffix(name, "_"+goos); base != name && c.Tasks[base] != nil {
+			return true
+		}
+	}
+
+	return false
+}
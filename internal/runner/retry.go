@@ -0,0 +1,140 @@
+package runner
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/frozzare/max/internal/task"
+)
+
+// maxRetryOutput is the number of trailing stderr bytes matched by
+// retry_if_output.
+const maxRetryOutput = 64 * 1024
+
+// retry runs fn and retries it up to the task's retries when it fails,
+// waiting the retry delay between attempts. Tasks with retry_on exit
+// codes only retries when the command exits with one of the codes and
+// tasks with retry_if_output only retries when the attempt's stderr
+// matches the pattern.
+func (r *Runner) retry(t *task.Task, quiet bool, output *tailBuffer, fn func() error) error {
+	var delay time.Duration
+
+	if len(t.RetryDelay) > 0 {
+		d, err := task.ParseDuration(t.RetryDelay)
+		if err != nil {
+			return fmt.Errorf("max: bad retry delay %s", t.RetryDelay)
+		}
+		delay = d
+	}
+
+	var pattern *regexp.Regexp
+
+	if len(t.RetryIf) > 0 {
+		p, err := regexp.Compile(t.RetryIf)
+		if err != nil {
+			return fmt.Errorf("max: bad retry_if_output %q: %s", t.RetryIf, err)
+		}
+		pattern = p
+	}
+
+	for attempt := 1; ; attempt++ {
+		output.Reset()
+
+		err := fn()
+		if err == nil || attempt > t.Retries || r.ctx.Err() != nil || !retryable(t, err) {
+			return err
+		}
+
+		if pattern != nil && !pattern.Match(output.Bytes()) {
+			r.whyf(t.ID(), "is not retried, stderr doesn't match %s", t.RetryIf)
+			return err
+		}
+
+		if !quiet {
+			r.log.Printf("Retrying task %s (%d/%d): %s\n", color.GreenString(t.ID()), attempt, t.Retries, err)
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// retryable reports whether a failed task should be retried, tasks
+// without retry_on exit codes retries on any error.
+func retryable(t *task.Task, err error) bool {
+	if len(t.RetryOn) == 0 {
+		return true
+	}
+
+	if !IsExitError(err) {
+		return false
+	}
+
+	status := ExitStatus(err)
+
+	for _, code := range t.RetryOn {
+		if code == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tailBuffer is a writer that keeps the last written bytes, nil buffers
+// discards writes.
+type tailBuffer struct {
+	buf []byte
+	max int
+	mu  sync.Mutex
+}
+
+func newTailBuffer(max int) *tailBuffer {
+	return &tailBuffer{max: max}
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	if b == nil {
+		return len(p), nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > b.max {
+		b.buf = append([]byte{}, b.buf[len(b.buf)-b.max:]...)
+	}
+
+	return len(p), nil
+}
+
+// Bytes returns the kept bytes.
+func (b *tailBuffer) Bytes() []byte {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]byte{}, b.buf...)
+}
+
+// Reset discards the kept bytes.
+func (b *tailBuffer) Reset() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.buf = nil
+}
@@ -4,13 +4,18 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/fatih/color"
@@ -18,37 +23,70 @@ import (
 	backendConfig "github.com/frozzare/max/internal/backend/config"
 	"github.com/frozzare/max/internal/backend/docker"
 	"github.com/frozzare/max/internal/backend/local"
+	"github.com/frozzare/max/internal/cache"
 	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/metrics"
 	"github.com/frozzare/max/internal/task"
-	"github.com/gorhill/cronexpr"
 	"github.com/pkg/errors"
 	"golang.org/x/sync/errgroup"
 )
 
 // Runner represents a the runner.
 type Runner struct {
-	args    map[string]interface{}
-	ctx     context.Context
-	engine  backend.Engine
-	config  *config.Config
-	log     *log.Logger
-	once    bool
-	opts    []Option
-	quiet   bool
-	Stdin   io.Reader
-	Stdout  io.Writer
-	Stderr  io.Writer
-	verbose bool
+	bench          bool
+	budget         time.Duration
+	budgetCancel   bool
+	cache          cache.Store
+	captured       *captured
+	groups         *groups
+	handler        bool
+	ctx            context.Context
+	engine         backend.Engine
+	config         *config.Config
+	failDeprecated bool
+	failFast       bool
+	failures       *failures
+	flags          map[string]interface{}
+	log            *log.Logger
+	maxConcurrency int
+	metrics        *metrics.Metrics
+	noDeps         bool
+	once           bool
+	opts           []Option
+	parallel       bool
+	posts          *posts
+	prefix         bool
+	quiet          bool
+	remote         cache.Store
+	resolvers      map[string]task.VarResolver
+	resources      int
+	since          string
+	stream         *stream
+	strict         bool
+	timeout        time.Duration
+	trust          map[string]bool
+	skipNoSources  bool
+	Stdin          io.Reader
+	Stdout         io.Writer
+	Stderr         io.Writer
+	verbose        bool
+	why            bool
 }
 
 // New creates a new runner.
 func New(opts ...Option) *Runner {
 	r := &Runner{
-		opts:   opts,
-		ctx:    context.Background(),
-		Stdin:  os.Stdin,
-		Stdout: os.Stdout,
-		Stderr: os.Stderr,
+		captured:  newCaptured(),
+		failures:  newFailures(),
+		groups:    newGroups(),
+		opts:      opts,
+		posts:     newPosts(),
+		ctx:       context.Background(),
+		failFast:  true,
+		resources: runtime.NumCPU(),
+		Stdin:     os.Stdin,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
 	}
 
 	for _, opts := range opts {
@@ -59,89 +97,448 @@ func New(opts ...Option) *Runner {
 		r.log = log.New(os.Stderr, "", 0)
 	}
 
+	if r.cache == nil && r.config != nil {
+		if c := r.config.Cache(); c != nil {
+			r.cache = c
+		}
+	}
+
 	return r
 }
 
+// ExitStatus returns the exit status that should be used for a run error.
+func ExitStatus(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	if _, ok := err.(*TimeoutError); ok {
+		return TimeoutExitStatus
+	}
+
+	// Use the first failed task's exit status.
+	if errs, ok := err.(Errors); ok && len(errs) > 0 {
+		return ExitStatus(errs[0].Err)
+	}
+
+	if IsExitError(err) {
+		s := strings.Split(err.Error(), " ")
+		if i, err := strconv.Atoi(s[len(s)-1]); err == nil {
+			return i
+		}
+	}
+
+	return 1
+}
+
+// IsExitError reports whether the error is a command exit status error.
+func IsExitError(err error) bool {
+	if _, ok := err.(Errors); ok {
+		return false
+	}
+
+	return err != nil && strings.Contains(err.Error(), "exit status")
+}
+
+// child creates a new runner for a sub task that shares the same streams.
+func (r *Runner) child(opts ...Option) *Runner {
+	c := New(append(r.opts, opts...)...)
+	c.captured = r.captured
+	c.failures = r.failures
+	c.groups = r.groups
+	c.handler = r.handler
+	c.posts = r.posts
+	c.ctx = r.ctx
+	c.Stdin = r.Stdin
+	c.Stdout = r.Stdout
+	c.Stderr = r.Stderr
+
+	return c
+}
+
 // Run runs a task.
 func (r *Runner) Run(id string) error {
 	t := r.Task(id)
 
 	if t == nil {
-		return fmt.Errorf("task missing: %s", id)
+		return r.missing(id)
 	}
 
+	// Run a copy so the config task is unchanged and can be run concurrently.
+	t = t.Copy()
 	t.ID(id)
 
 	return <-r.execAll(t)
 }
 
+// RunAll runs tasks in order. When fail fast is disabled all tasks
+// are run and the failed tasks are returned as Errors. In parallel mode
+// tasks are started in order as long as their combined weight is
+// within the resource budget. Post commands of started tasks are run
+// when the tasks are done, see Cleanup.
+func (r *Runner) RunAll(ids ...string) error {
+	err := r.runAll(ids)
+
+	// Cancelled runs returns the cause, e.g the signal max was interrupted by.
+	if r.ctx.Err() != nil {
+		if cause := context.Cause(r.ctx); cause != r.ctx.Err() {
+			err = cause
+		}
+	}
+
+	r.Cleanup(err)
+
+	return err
+}
+
+func (r *Runner) runAll(ids []string) error {
+	var errs Errors
+
+	ids, err := r.expand(ids)
+	if err != nil {
+		return err
+	}
+
+	// Only run tasks affected by files changed since a git ref.
+	if len(r.since) > 0 {
+		if ids, err = r.filterChanged(ids); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	ctx := r.ctx
+
+	// Cancel all tasks when the run exceeds the timeout.
+	var timeoutCtx context.Context
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		timeoutCtx = ctx
+		defer cancel()
+	}
+
+	// Cancel running tasks when the budget is exceeded.
+	if r.budget > 0 && r.budgetCancel {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.budget)
+		defer cancel()
+	}
+
+	ids = r.sort(ids)
+
+	size := 1
+	stdout, stderr := r.Stdout, r.Stderr
+
+	if r.parallel {
+		size = r.resources
+
+		var mu sync.Mutex
+		stdout, stderr = &lockedWriter{&mu, r.Stdout}, &lockedWriter{&mu, r.Stderr}
+	}
+
+	var (
+		failed  bool
+		mu      sync.Mutex
+		pool    = newPool(size)
+		results = make([]error, len(ids))
+		slots   = newSlots(r.concurrency())
+		wg      sync.WaitGroup
+	)
+
+	for i, id := range ids {
+		w := 1
+		if r.parallel {
+			w = r.weight(id)
+		}
+
+		slots.acquire()
+		pool.acquire(w)
+
+		mu.Lock()
+		stop := failed && r.failFast
+		mu.Unlock()
+
+		// Don't start new tasks when the run has timed out.
+		if stop || (timeoutCtx != nil && timeoutCtx.Err() != nil) {
+			pool.release(w)
+			slots.release()
+			break
+		}
+
+		// Don't start new tasks when the budget is exceeded.
+		if r.budget > 0 && time.Since(start) >= r.budget {
+			pool.release(w)
+			slots.release()
+			r.log.Printf("max: budget of %s exceeded, skipped tasks %s\n", r.budget, strings.Join(ids[i:], ", "))
+			break
+		}
+
+		c := r.child()
+		c.ctx = ctx
+		c.Stdout, c.Stderr = stdout, stderr
+
+		wg.Add(1)
+
+		go func(i int, id string, w int) {
+			defer wg.Done()
+			defer slots.release()
+			defer pool.release(w)
+
+			if err := c.Run(id); err != nil {
+				mu.Lock()
+				results[i] = err
+				failed = true
+				mu.Unlock()
+			}
+		}(i, id, w)
+	}
+
+	wg.Wait()
+
+	if timeoutCtx != nil && timeoutCtx.Err() == context.DeadlineExceeded {
+		return &TimeoutError{Timeout: r.timeout}
+	}
+
+	for i, err := range results {
+		if err == nil {
+			continue
+		}
+
+		if r.failFast {
+			return err
+		}
+
+		errs = append(errs, &TaskError{ID: ids[i], Err: err})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
 func (r *Runner) exec(t *task.Task) error {
-	if t.UpToDate(r.ctx) {
-		return errors.New("task is up to date")
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	// Remote commands are only run from trusted hosts.
+	if err := r.trusted(t); err != nil {
+		return err
+	}
+
+	// Task clean env value overrides the global clean env value.
+	r.cleanEnv(t)
+
+	// Task quiet value overrides the global quiet value and command echo.
+	quiet, echo := r.quiet, r.verbose
+	if t.Quiet != nil {
+		quiet, echo = *t.Quiet, !*t.Quiet
+	}
+
+	if len(t.Deprecated) > 0 {
+		if r.failDeprecated {
+			return fmt.Errorf("max: task %s is deprecated: %s", t.ID(), t.Deprecated)
+		}
+
+		if !quiet {
+			r.log.Printf("max: warning: task %s is deprecated: %s\n", t.ID(), t.Deprecated)
+		}
+	}
+
+	if len(t.Status.Values) > 0 {
+		command, failed := t.FailedStatus(r.ctx)
+		if !failed {
+			r.whyf(t.ID(), "is skipped, status commands passes")
+			return ErrUpToDate
+		}
+
+		r.whyf(t.ID(), "runs, status command %s fails", command)
+	}
+
+	stdout, stderr := r.Stdout, r.Stderr
+
+	// Write output lines as events when streaming or prefix them with the task id.
+	if r.stream != nil {
+		sout, serr := r.stream.writer(t.ID(), "stdout"), r.stream.writer(t.ID(), "stderr")
+
+		defer func() {
+			sout.Flush()
+			serr.Flush()
+		}()
+
+		stdout, stderr = sout, serr
+	} else if r.prefix {
+		prefix := fmt.Sprintf("[%s] ", t.ID())
+		pout, perr := newPrefixWriter(r.Stdout, prefix), newPrefixWriter(r.Stderr, prefix)
+
+		defer func() {
+			pout.Flush()
+			perr.Flush()
+		}()
+
+		stdout, stderr = pout, perr
+	}
+
+	// Truncate output beyond the task's max output or the global max output,
+	// stdout and stderr shares the limit.
+	if len(t.MaxOutput) == 0 {
+		t.MaxOutput = r.config.MaxOutput
+	}
+
+	limit, err := t.OutputLimit()
+	if err != nil {
+		return err
+	}
+
+	stdout, stderr = task.LimitWriters(stdout, stderr, limit)
+
+	// Keep the stderr of each attempt when retries depends on the output.
+	var output *tailBuffer
+	if len(t.RetryIf) > 0 && t.Retries > 0 {
+		output = newTailBuffer(maxRetryOutput)
+		stderr = io.MultiWriter(output, stderr)
+	}
+
+	// Keep the output of each attempt when the task has expectations.
+	var expected *tailBuffer
+	if t.Expect != nil {
+		expected = newTailBuffer(maxExpectOutput)
+		stdout, stderr = io.MultiWriter(expected, stdout), io.MultiWriter(expected, stderr)
 	}
 
 	backendConfig := &backendConfig.Backend{
 		Log:     r.log,
 		Stdin:   r.Stdin,
-		Stdout:  r.Stdout,
-		Stderr:  r.Stderr,
-		Verbose: r.verbose,
+		Stdout:  stdout,
+		Stderr:  stderr,
+		Verbose: echo,
+	}
+
+	// Time each command when benchmarking and report after the task.
+	if r.bench {
+		b := &bench{}
+		backendConfig.Timing = b.observe
+		defer r.report(t.ID(), b)
 	}
 
-	// Use docker if docker configuration is not nil.
+	// Use docker if docker configuration is not nil. The engine is created
+	// for each run so it writes to this run's writers, e.g for intervals.
+	engine := r.engine
 	if t.Docker != nil {
-		engine, err := docker.New(backendConfig)
-		if err != nil {
+		if engine, err = docker.New(backendConfig); err != nil {
 			return err
 		}
-		r.engine = engine
 	}
 
-	if r.engine == nil {
-		r.engine = local.New(backendConfig)
+	if engine == nil {
+		engine = local.New(backendConfig)
 	}
 
 	defer func() {
-		r.engine.Destroy(r.ctx, t)
+		engine.Destroy(r.ctx, t)
 	}()
 
-	if err := r.engine.Setup(r.ctx, t); err != nil {
+	if err := engine.Setup(r.ctx, t); err != nil {
 		return err
 	}
 
 	t = r.prepareTask(t)
 
-	if !r.quiet {
+	// Expand ~ in the task's directory and env files.
+	if err := t.ExpandPaths(); err != nil {
+		return err
+	}
+
+	if !quiet {
 		r.log.Printf("Starting task %s\n", color.GreenString(t.ID()))
 	}
 
-	// Run deps before task.
-	for _, id := range t.Deps {
-		if err := New(append(r.opts, Once(true))...).Run(id); err != nil {
-			return err
+	// Check that the required binaries are installed before deps runs.
+	missing, err := t.MissingRequirements()
+	if err != nil {
+		return err
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("max: task %s requires %s, install them or add them to PATH", t.ID(), strings.Join(missing, ", "))
+	}
+
+	// Register post commands before deps so they run even when deps fails.
+	r.posts.push(t)
+
+	// Run deps and env_from tasks before task unless skipped for the root task.
+	if !r.noDeps {
+		for _, id := range r.sort(t.AllDeps()) {
+			if err := r.child(Once(true)).Run(id); err != nil {
+				return err
+			}
 		}
 	}
 
 	// Run other tasks.
 	for _, id := range t.Tasks.Values {
-		if err := New(r.opts...).Run(id); err != nil {
+		if err := r.child(NoDeps(false)).Run(id); err != nil {
 			return err
 		}
 	}
 
+	// Load the variables the commands runs with, e.g captured variables.
+	if err := r.loadEnv(t, stderr); err != nil {
+		return err
+	}
+
 	// Prepare tasks, e.g replace arguments and environment variables.
 	if err := t.Prepare(); err != nil {
 		return err
 	}
 
-	// Execute task in engine.
-	if err := r.engine.Exec(r.ctx, t); err != nil {
+	// Skip task if the cache key is the same as the last successful run.
+	if r.cached(t) {
+		r.whyf(t.ID(), "is skipped, cache key %s is up to date", t.CacheKey)
+
+		if !quiet {
+			r.log.Printf("Skipping task %s, cache key is up to date\n", color.GreenString(t.ID()))
+		}
+
+		return nil
+	}
+
+	r.whyCache(t)
+
+	// Don't run tasks in the same concurrency group at the same time.
+	defer r.groups.lock(t.Concurrency)()
+
+	// Don't run tasks while another process holds the file lock.
+	if t.Lock != nil {
+		unlock, err := t.Lock.Acquire(r.ctx, t.Dir)
+		if err != nil {
+			return err
+		}
+
+		defer unlock()
+	}
+
+	// Wait until the task is ready to run.
+	if t.Wait != nil {
+		if err := t.Wait.Run(r.ctx); err != nil {
+			return err
+		}
+	}
+
+	// Execute task in engine, failed tasks are retried when configured.
+	if err := r.retry(t, quiet, output, func() error {
+		expected.Reset()
+		return r.expect(t, expected, r.execTimeout(engine, t))
+	}); err != nil {
 		return err
 	}
 
 	// Get logs from engine.
 	go func() {
-		rc, err := r.engine.Logs(r.ctx, t)
+		rc, err := engine.Logs(r.ctx, t)
 		if rc != nil && err == nil {
 			scanner := bufio.NewScanner(rc)
 			defer rc.Close()
@@ -152,7 +549,7 @@ func (r *Runner) exec(t *task.Task) error {
 	}()
 
 	for {
-		exited, err := r.engine.Wait(r.ctx, t)
+		exited, err := engine.Wait(r.ctx, t)
 		if err != nil {
 			return err
 		}
@@ -164,13 +561,106 @@ func (r *Runner) exec(t *task.Task) error {
 		time.Sleep(1 * time.Second)
 	}
 
-	if !r.quiet {
+	// Fail tasks that exits successfully without producing their artifacts.
+	if missing := t.MissingArtifacts(); len(missing) > 0 {
+		return fmt.Errorf("max: task %s didn't produce artifacts: %s", t.ID(), strings.Join(missing, ", "))
+	}
+
+	// Store cache key after a successful run.
+	if r.cache != nil && len(t.CacheKey) > 0 {
+		if err := r.cache.Set(r.cacheKey(t), []byte(t.CacheKey)); err != nil && r.verbose {
+			r.log.Printf("max: can't store cache key: %s\n", err)
+		}
+	}
+
+	// Store fingerprint in the remote cache so other machines can skip the task.
+	if r.remote != nil && len(t.CacheKey) > 0 {
+		if err := r.remote.Set(r.fingerprint(t), []byte(t.CacheKey)); err != nil && r.verbose {
+			r.log.Printf("max: can't store remote cache key: %s\n", err)
+		}
+	}
+
+	if !quiet {
 		r.log.Printf("Finished task %s\n", color.GreenString(t.ID()))
 	}
 
 	return nil
 }
 
+// execTimeout executes the task in the engine and cancels the commands when
+// they runs longer than the task's timeout, each attempt has its own timeout.
+func (r *Runner) execTimeout(engine backend.Engine, t *task.Task) error {
+	if len(t.Timeout) == 0 {
+		return engine.Exec(r.ctx, t)
+	}
+
+	d, err := task.ParseDuration(t.Timeout)
+	if err != nil {
+		return fmt.Errorf("max: bad timeout %s", t.Timeout)
+	}
+
+	ctx, cancel := context.WithTimeout(r.ctx, d)
+	defer cancel()
+
+	if err := engine.Exec(ctx, t); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("max: task %s timed out after %s", t.ID(), t.Timeout)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// cacheKey returns the key used to store a task's cache key.
+func (r *Runner) cacheKey(t *task.Task) string {
+	wd, _ := os.Getwd()
+	return fmt.Sprintf("cache_key:%s:%s", wd, t.ID())
+}
+
+// fingerprint returns the remote cache key for a task's project, id and
+// cache key so projects with the same task ids can share a cache server.
+func (r *Runner) fingerprint(t *task.Task) string {
+	sum := sha256.Sum256([]byte(r.project() + "\x00" + t.ID() + "\x00" + t.CacheKey))
+	return "task-" + hex.EncodeToString(sum[:])
+}
+
+// project returns the name of the directory the config file is in, or the
+// working directory when the config wasn't read from a file. The name is
+// used instead of the path since the path differs between machines.
+func (r *Runner) project() string {
+	dir, _ := os.Getwd()
+
+	if r.config != nil && len(r.config.SourcePath()) > 0 {
+		dir = filepath.Dir(r.config.SourcePath())
+	}
+
+	return filepath.Base(dir)
+}
+
+// cached reports whether the task's cache key matches the last successful run
+// or if the task has run with the same cache key on any machine using the remote cache.
+func (r *Runner) cached(t *task.Task) bool {
+	if len(t.CacheKey) == 0 {
+		return false
+	}
+
+	if r.cache != nil {
+		if buf, err := r.cache.Get(r.cacheKey(t)); err == nil && string(buf) == t.CacheKey {
+			return true
+		}
+	}
+
+	if r.remote != nil {
+		if buf, err := r.remote.Get(r.fingerprint(t)); err == nil && string(buf) == t.CacheKey {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (r *Runner) execInterval(t *task.Task) error {
 	once := len(t.Interval) == 0 || r.once
 
@@ -181,24 +671,25 @@ func (r *Runner) execInterval(t *task.Task) error {
 		default:
 		}
 
-		if err := r.exec(t); err != nil {
-			if once {
-				err = errors.Wrap(err, "max")
-				status := 1
+		start := time.Now()
 
-				if strings.Contains(err.Error(), "exit status") {
-					s := strings.Split(err.Error(), " ")
-					if i, err := strconv.Atoi(s[len(s)-1]); err == nil {
-						status = i
-					}
-				} else {
-					log.Print(err)
-				}
+		if r.stream != nil {
+			r.stream.start(t.ID())
+		}
 
-				os.Exit(status)
-			} else {
-				return err
-			}
+		err := r.exec(t)
+
+		if r.metrics != nil {
+			r.metrics.Observe(t.ID(), time.Since(start), err)
+		}
+
+		if r.stream != nil {
+			r.stream.end(t.ID(), time.Since(start), err)
+		}
+
+		if err != nil {
+			r.onFailure(t, err)
+			return err
 		}
 
 		if once {
@@ -206,7 +697,11 @@ func (r *Runner) execInterval(t *task.Task) error {
 		}
 
 		// Wait until next time we should run the task.
-		nextTime := cronexpr.MustParse(t.Interval).Next(time.Now())
+		nextTime, err := t.NextRun(time.Now())
+		if err != nil {
+			return err
+		}
+
 		time.Sleep(time.Until(nextTime))
 	}
 
@@ -229,19 +724,33 @@ func (r *Runner) execAll(t *task.Task) <-chan error {
 	return done
 }
 
+// cleanEnv sets the global clean env value on tasks without a clean env value.
+func (r *Runner) cleanEnv(t *task.Task) {
+	if t.CleanEnv == nil && r.config.CleanEnv {
+		clean := true
+		t.CleanEnv = &clean
+	}
+}
+
 func (r *Runner) prepareTask(t *task.Task) *task.Task {
-	r.parseArgs()
+	args, vars := r.parseArgs()
 
 	t.Options(
-		task.Args(r.args),
+		task.Args(args),
 		task.Log(r.log),
-		task.Variables(r.config.Variables),
+		task.Resolvers(r.resolvers),
+		task.StrictTemplates(r.strict),
+		task.Variables(vars),
 	)
 
 	return t
 }
 
-func (r *Runner) parseArgs() {
+// parseArgs returns the config's args and variables with the arguments
+// given to max, --key value flags are args and other arguments are the
+// positional variables 1, 2 and so on and @ with all arguments. New maps
+// are returned so tasks can be prepared concurrently.
+func (r *Runner) parseArgs() (map[string]interface{}, map[string]interface{}) {
 	var input string
 
 	if len(os.Args) > 1 {
@@ -252,10 +761,14 @@ func (r *Runner) parseArgs() {
 
 	buff := bytes.NewBufferString(input)
 
-	r.args = r.config.Args
+	args := make(map[string]interface{}, len(r.config.Args))
+	for k, v := range r.config.Args {
+		args[k] = v
+	}
 
-	if r.args == nil {
-		r.args = make(map[string]interface{})
+	vars := make(map[string]interface{}, len(r.config.Variables))
+	for k, v := range r.config.Variables {
+		vars[k] = v
 	}
 
 	i := 0
@@ -302,15 +815,113 @@ func (r *Runner) parseArgs() {
 				key := strings.TrimSpace(arg)
 				key = strings.Replace(key, "-", "_", -1)
 
-				r.args[key] = strings.TrimSpace(val)
+				args[key] = strings.TrimSpace(val)
 			}
 		} else if val, err := buff.ReadString(' '); err == nil || err == io.EOF {
 			i++
-			r.config.Variables[fmt.Sprintf("%d", i)] = strings.TrimSpace(string(rn) + val)
+			vars[fmt.Sprintf("%d", i)] = strings.TrimSpace(string(rn) + val)
 		}
 	}
 
-	r.config.Variables["@"] = strings.TrimSpace(input)
+	vars["@"] = strings.TrimSpace(input)
+
+	// Parsed flags are typed and overrides the string values.
+	for k, v := range r.flags {
+		args[k] = v
+	}
+
+	return args, vars
+}
+
+// Match returns the task names matching a pattern in declaration order,
+// using filepath.Match syntax, e.g "test:*".
+func (r *Runner) Match(pattern string) ([]string, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("max: bad task pattern %s: %s", pattern, err)
+	}
+
+	var names []string
+
+	if r.config != nil {
+		for _, name := range r.config.List() {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("max: no tasks matching %s", pattern)
+	}
+
+	return names, nil
+}
+
+// expand replaces task patterns with the matching task names that
+// are not already requested and unique task name prefixes with the
+// task name.
+func (r *Runner) expand(ids []string) ([]string, error) {
+	var res []string
+	seen := make(map[string]bool)
+
+	for _, id := range ids {
+		if !isPattern(id) || r.Task(id) != nil {
+			if r.Task(id) == nil {
+				if name, err := ResolveTask(r.config, id); err == nil {
+					id = name
+				} else if _, ok := err.(*AmbiguousTaskError); ok {
+					return nil, err
+				}
+			}
+
+			res = append(res, id)
+			seen[id] = true
+			continue
+		}
+
+		names, err := r.Match(id)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, name := range names {
+			if !seen[name] {
+				res = append(res, name)
+				seen[name] = true
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// isPattern reports whether a task name contains pattern characters.
+func isPattern(id string) bool {
+	return strings.ContainsAny(id, "*?[")
+}
+
+// sort sorts independent tasks by priority, highest first, tasks with the
+// same priority keeps the order they are written in.
+func (r *Runner) sort(ids []string) []string {
+	if r.config == nil || len(ids) < 2 {
+		return ids
+	}
+
+	priority := func(id string) int {
+		if t := r.Task(id); t != nil {
+			return t.Priority
+		}
+
+		return 0
+	}
+
+	res := append([]string{}, ids...)
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return priority(res[i]) > priority(res[j])
+	})
+
+	return res
 }
 
 // Task returns a task by name if it exists.
@@ -2,11 +2,23 @@ package runner
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/frozzare/go/yaml2"
+	"github.com/frozzare/max/internal/cache"
 	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/exec"
+	"github.com/frozzare/max/internal/metrics"
 	"github.com/frozzare/max/internal/task"
 )
 
@@ -21,7 +33,7 @@ func TestRunner(t *testing.T) {
 					Commands: yaml2.NewList("echo Hello $NAME"),
 				},
 			},
-			Variables: map[string]string{
+			Variables: map[string]interface{}{
 				"NAME": "Fredrik",
 			},
 		}),
@@ -40,3 +52,1378 @@ func TestRunner(t *testing.T) {
 		t.Errorf("Expected: 'Hello Fredrik', got: %s", got)
 	}
 }
+
+func TestRunnerScript(t *testing.T) {
+	var buf bytes.Buffer
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"hello": {
+					Summary: "Hello task",
+					Script:  "NAME=Fredrik\nif [ -n \"$NAME\" ]; then\n  echo Hello $NAME\nfi",
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+	)
+
+	runner.Stdout = &buf
+
+	if err := runner.Run("hello"); err != nil {
+		t.Errorf("Expected: nil, got: %s", err)
+	}
+
+	got := strings.TrimSpace(buf.String())
+
+	if got != "Hello Fredrik" {
+		t.Errorf("Expected: 'Hello Fredrik', got: %s", got)
+	}
+}
+
+func TestRunnerSession(t *testing.T) {
+	run := func(session bool) string {
+		var buf bytes.Buffer
+
+		dir, err := ioutil.TempDir("", "max-session")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		runner := New(
+			Config(&config.Config{
+				Tasks: map[string]*task.Task{
+					"hello": {
+						Commands: yaml2.NewList([]string{"cd sub", "NAME=max", "basename $(pwd) $NAME"}),
+						Dir:      dir,
+						Session:  session,
+					},
+				},
+				Variables: map[string]interface{}{},
+			}),
+			Quiet(true),
+		)
+
+		runner.Stdout = &buf
+
+		if err := runner.Run("hello"); err != nil {
+			t.Fatalf("Expected: nil, got: %s", err)
+		}
+
+		return strings.TrimSpace(buf.String())
+	}
+
+	if got := run(true); got != "sub" {
+		t.Errorf("Expected: sub, got: %s", got)
+	}
+
+	if got := run(false); got == "sub" {
+		t.Errorf("Expected: task dir, got: %s", got)
+	}
+}
+
+func TestRunnerMode(t *testing.T) {
+	run := func(mode string) (string, error) {
+		var buf bytes.Buffer
+
+		runner := New(
+			Config(&config.Config{
+				Tasks: map[string]*task.Task{
+					"hello": {
+						Commands: yaml2.NewList([]string{"NAME=max", "false", "echo ${NAME:-none}"}),
+						Mode:     mode,
+					},
+				},
+				Variables: map[string]interface{}{},
+			}),
+			Log(log.New(ioutil.Discard, "", 0)),
+			Quiet(true),
+		)
+
+		runner.Stdout = &buf
+		err := runner.Run("hello")
+
+		return strings.TrimSpace(buf.String()), err
+	}
+
+	if got, err := run(""); err == nil || got != "" {
+		t.Errorf("Expected: error and no output, got: %q, %v", got, err)
+	}
+
+	if got, err := run(task.ModeChain); err == nil || got != "" {
+		t.Errorf("Expected: error and no output, got: %q, %v", got, err)
+	}
+
+	if got, err := run(task.ModeSeparate); err == nil || got != "none" {
+		t.Errorf("Expected: error and none, got: %q, %v", got, err)
+	}
+}
+
+func TestRunnerNoDeps(t *testing.T) {
+	var buf bytes.Buffer
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"dep": {
+					Commands: yaml2.NewList("echo Dep"),
+				},
+				"hello": {
+					Commands: yaml2.NewList("echo Hello"),
+					Deps:     []string{"dep"},
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		NoDeps(true),
+	)
+
+	runner.Stdout = &buf
+
+	if err := runner.Run("hello"); err != nil {
+		t.Errorf("Expected: nil, got: %s", err)
+	}
+
+	got := strings.TrimSpace(buf.String())
+
+	if got != "Hello" {
+		t.Errorf("Expected: 'Hello', got: %s", got)
+	}
+}
+
+func TestRunnerExitStatus(t *testing.T) {
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"fail": {
+					Commands: yaml2.NewList("exit 3"),
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		Quiet(true),
+	)
+
+	err := runner.Run("fail")
+
+	if !IsExitError(err) {
+		t.Fatalf("Expected: exit error, got: %v", err)
+	}
+
+	if s := ExitStatus(err); s != 3 {
+		t.Errorf("Expected: 3, got: %d", s)
+	}
+}
+
+func TestRunnerFailFast(t *testing.T) {
+	for _, failFast := range []bool{true, false} {
+		var buf bytes.Buffer
+
+		runner := New(
+			Config(&config.Config{
+				Tasks: map[string]*task.Task{
+					"a": {Commands: yaml2.NewList("exit 2")},
+					"b": {Commands: yaml2.NewList("echo b")},
+					"c": {Commands: yaml2.NewList("exit 3")},
+				},
+				Variables: map[string]interface{}{},
+			}),
+			FailFast(failFast),
+			Quiet(true),
+		)
+
+		runner.Stdout = &buf
+
+		err := runner.RunAll("a", "b", "c")
+
+		if s := ExitStatus(err); s != 2 {
+			t.Errorf("Expected: 2, got: %d", s)
+		}
+
+		got := strings.TrimSpace(buf.String())
+
+		if failFast {
+			if got != "" {
+				t.Errorf("Expected: no output, got: %s", got)
+			}
+
+			continue
+		}
+
+		if got != "b" {
+			t.Errorf("Expected: 'b', got: %s", got)
+		}
+
+		if errs, ok := err.(Errors); !ok || len(errs) != 2 || errs[1].ID != "c" {
+			t.Errorf("Expected: two errors, got: %v", err)
+		}
+	}
+}
+
+func TestRunnerPriority(t *testing.T) {
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"a": {},
+				"b": {Priority: 10},
+				"c": {},
+				"d": {Priority: -1},
+			},
+		}),
+	)
+
+	got := runner.sort([]string{"d", "c", "b", "a"})
+	exp := []string{"b", "c", "a", "d"}
+
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected: %v, got: %v", exp, got)
+	}
+}
+
+func TestRunnerDepsOrder(t *testing.T) {
+	var buf bytes.Buffer
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"a": {Commands: yaml2.NewList("echo a")},
+				"b": {Deps: []string{"c", "a"}},
+				"c": {Commands: yaml2.NewList("echo c")},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		Quiet(true),
+	)
+
+	runner.Stdout = &buf
+
+	// Deps without priorities runs in the order they are written.
+	if err := runner.Run("b"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if got := buf.String(); got != "c\na\n" {
+		t.Errorf("Expected: c then a, got: %q", got)
+	}
+}
+
+func TestRunnerCacheKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	c, err := cache.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer c.Close()
+
+	run := func(version string) string {
+		var buf bytes.Buffer
+
+		runner := New(
+			Cache(c),
+			Config(&config.Config{
+				Args: map[string]interface{}{"version": version},
+				Tasks: map[string]*task.Task{
+					"build": {
+						CacheKey: "{{ .version }}",
+						Commands: yaml2.NewList("echo build"),
+					},
+				},
+				Variables: map[string]interface{}{},
+			}),
+			Quiet(true),
+		)
+
+		runner.Stdout = &buf
+
+		if err := runner.Run("build"); err != nil {
+			t.Fatalf("Expected: nil, got: %s", err)
+		}
+
+		return strings.TrimSpace(buf.String())
+	}
+
+	if got := run("1.0"); got != "build" {
+		t.Errorf("Expected: 'build', got: %s", got)
+	}
+
+	if got := run("1.0"); got != "" {
+		t.Errorf("Expected: no output, got: %s", got)
+	}
+
+	if got := run("2.0"); got != "build" {
+		t.Errorf("Expected: 'build', got: %s", got)
+	}
+}
+
+type memoryStore map[string][]byte
+
+func (s memoryStore) Delete(key string) error {
+	delete(s, key)
+	return nil
+}
+
+func (s memoryStore) Get(key string) ([]byte, error) {
+	if v, ok := s[key]; ok {
+		return v, nil
+	}
+	return nil, errors.New("missing")
+}
+
+func (s memoryStore) Set(key string, value []byte) error {
+	s[key] = value
+	return nil
+}
+
+func TestRunnerRemoteCache(t *testing.T) {
+	remote := memoryStore{}
+
+	run := func(version string) string {
+		var buf bytes.Buffer
+
+		runner := New(
+			Cache(memoryStore{}),
+			Config(&config.Config{
+				Args: map[string]interface{}{"version": version},
+				Tasks: map[string]*task.Task{
+					"build": {
+						CacheKey: "{{ .version }}",
+						Commands: yaml2.NewList("echo build"),
+					},
+				},
+				Variables: map[string]interface{}{},
+			}),
+			Quiet(true),
+			RemoteCache(remote),
+		)
+
+		runner.Stdout = &buf
+
+		if err := runner.Run("build"); err != nil {
+			t.Fatalf("Expected: nil, got: %s", err)
+		}
+
+		return strings.TrimSpace(buf.String())
+	}
+
+	if got := run("1.0"); got != "build" {
+		t.Errorf("Expected: 'build', got: %s", got)
+	}
+
+	// A new local cache simulates another machine.
+	if got := run("1.0"); got != "" {
+		t.Errorf("Expected: no output, got: %s", got)
+	}
+
+	if got := run("2.0"); got != "build" {
+		t.Errorf("Expected: 'build', got: %s", got)
+	}
+
+	if got := run("1.0"); got != "" {
+		t.Errorf("Expected: no output, got: %s", got)
+	}
+}
+
+func TestRunnerRemoteCacheProject(t *testing.T) {
+	dir, err := ioutil.TempDir("", "max-remote")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	remote := memoryStore{}
+
+	run := func(project string) string {
+		path := filepath.Join(dir, project, "max.yml")
+		os.MkdirAll(filepath.Dir(path), 0755)
+		ioutil.WriteFile(path, []byte("cache_scope: project\ntasks:\n  build:\n    cache_key: '1.0'\n    commands: [echo build]\n"), 0644)
+
+		c, err := config.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		defer c.Close()
+
+		var buf bytes.Buffer
+
+		runner := New(Config(c), Quiet(true), RemoteCache(remote))
+		runner.Stdout = &buf
+
+		if err := runner.Run("build"); err != nil {
+			t.Fatalf("Expected: nil, got: %s", err)
+		}
+
+		return strings.TrimSpace(buf.String())
+	}
+
+	if got := run(filepath.Join("ci", "app")); got != "build" {
+		t.Errorf("Expected: 'build', got: %s", got)
+	}
+
+	// The same project on another machine is cached.
+	if got := run(filepath.Join("laptop", "app")); got != "" {
+		t.Errorf("Expected: no output, got: %s", got)
+	}
+
+	if got := run(filepath.Join("ci", "api")); got != "build" {
+		t.Errorf("Expected: 'build' for another project, got: %s", got)
+	}
+}
+
+func TestRunnerCapture(t *testing.T) {
+	var buf bytes.Buffer
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"version": {
+					Capture:  map[string]string{"version": "echo '  1.0.0 '"},
+					Commands: yaml2.NewList("echo version {{ .version }}"),
+				},
+				"release": {
+					Commands: yaml2.NewList("echo release $version"),
+					Deps:     []string{"version"},
+				},
+				"fail": {
+					Capture:  map[string]string{"version": "exit 3"},
+					Commands: yaml2.NewList("echo fail"),
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		Quiet(true),
+	)
+
+	runner.Stdout = &buf
+
+	if err := runner.Run("release"); err != nil {
+		t.Errorf("Expected: nil, got: %s", err)
+	}
+
+	if got := strings.TrimSpace(buf.String()); got != "version 1.0.0\nrelease 1.0.0" {
+		t.Errorf("Expected: 'version 1.0.0\nrelease 1.0.0', got: %s", got)
+	}
+
+	buf.Reset()
+
+	if err := runner.Run("fail"); err == nil {
+		t.Error("Expected: error, got: nil")
+	}
+
+	if got := buf.String(); got != "" {
+		t.Errorf("Expected: no output, got: %s", got)
+	}
+}
+
+func TestRunnerEnvFrom(t *testing.T) {
+	var buf bytes.Buffer
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"api": {
+					Capture: map[string]string{"version": "echo 1.0.0"},
+				},
+				"web": {
+					Capture: map[string]string{"version": "echo 2.0.0"},
+				},
+				"release": {
+					Commands: yaml2.NewList("echo release $version"),
+					Deps:     []string{"api", "web"},
+					EnvFrom:  []string{"api"},
+				},
+				"stage": {
+					Commands: yaml2.NewList("echo stage $version"),
+					EnvFrom:  []string{"web"},
+				},
+				"build": {
+					Commands: yaml2.NewList("echo build"),
+				},
+				"deploy": {
+					Commands: yaml2.NewList("echo deploy"),
+					EnvFrom:  []string{"build"},
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		Quiet(true),
+	)
+
+	runner.Stdout = &buf
+
+	// The env_from tasks runs before the task like deps.
+	if err := runner.Run("stage"); err != nil {
+		t.Errorf("Expected: nil, got: %s", err)
+	}
+
+	if got := strings.TrimSpace(buf.String()); got != "stage 2.0.0" {
+		t.Errorf("Expected: 'stage 2.0.0', got: %s", got)
+	}
+
+	buf.Reset()
+
+	if err := runner.Run("release"); err != nil {
+		t.Errorf("Expected: nil, got: %s", err)
+	}
+
+	if got := strings.TrimSpace(buf.String()); got != "release 1.0.0" {
+		t.Errorf("Expected: 'release 1.0.0', got: %s", got)
+	}
+
+	err := runner.Run("deploy")
+	if err == nil || !strings.Contains(err.Error(), "imports env from build") {
+		t.Errorf("Expected: env from error, got: %v", err)
+	}
+}
+
+func TestRunnerDeprecated(t *testing.T) {
+	var buf bytes.Buffer
+	var logs bytes.Buffer
+
+	tasks := map[string]*task.Task{
+		"old": {
+			Commands:   yaml2.NewList("echo old"),
+			Deprecated: "use new instead",
+		},
+	}
+
+	runner := New(
+		Config(&config.Config{Tasks: tasks, Variables: map[string]interface{}{}}),
+		Log(log.New(&logs, "", 0)),
+	)
+
+	runner.Stdout = &buf
+
+	if err := runner.Run("old"); err != nil {
+		t.Errorf("Expected: nil, got: %s", err)
+	}
+
+	if got := strings.TrimSpace(buf.String()); got != "old" {
+		t.Errorf("Expected: 'old', got: %s", got)
+	}
+
+	if !strings.Contains(logs.String(), "max: warning: task old is deprecated: use new instead") {
+		t.Errorf("Expected: deprecation warning, got: %s", logs.String())
+	}
+
+	runner = New(
+		Config(&config.Config{Tasks: tasks, Variables: map[string]interface{}{}}),
+		FailDeprecated(true),
+		Quiet(true),
+	)
+
+	if err := runner.Run("old"); err == nil {
+		t.Error("Expected: error, got: nil")
+	}
+}
+
+func TestRunnerPattern(t *testing.T) {
+	var buf bytes.Buffer
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"build":      {Commands: yaml2.NewList("echo build")},
+				"test:unit":  {Commands: yaml2.NewList("echo unit")},
+				"test:lint":  {Commands: yaml2.NewList("echo lint")},
+				"deploy:app": {Commands: yaml2.NewList("echo deploy")},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		Quiet(true),
+	)
+
+	runner.Stdout = &buf
+
+	if err := runner.RunAll("test:unit", "test:*"); err != nil {
+		t.Errorf("Expected: nil, got: %s", err)
+	}
+
+	if got := strings.TrimSpace(buf.String()); got != "unit\nlint" {
+		t.Errorf("Expected: 'unit\nlint', got: %s", got)
+	}
+
+	if err := runner.RunAll("missing:*"); err == nil {
+		t.Error("Expected: error, got: nil")
+	}
+}
+
+func TestRunnerBudget(t *testing.T) {
+	var buf bytes.Buffer
+	var logs bytes.Buffer
+
+	tasks := map[string]*task.Task{
+		"a": {Commands: yaml2.NewList([]string{"sleep 0.2", "echo a"})},
+		"b": {Commands: yaml2.NewList("echo b")},
+		"c": {Commands: yaml2.NewList("sleep 5")},
+	}
+
+	runner := New(
+		Budget(100*time.Millisecond),
+		Config(&config.Config{Tasks: tasks, Variables: map[string]interface{}{}}),
+		Log(log.New(&logs, "", 0)),
+		Quiet(true),
+	)
+
+	runner.Stdout = &buf
+
+	if err := runner.RunAll("a", "b"); err != nil {
+		t.Errorf("Expected: nil, got: %s", err)
+	}
+
+	if got := strings.TrimSpace(buf.String()); got != "a" {
+		t.Errorf("Expected: 'a', got: %s", got)
+	}
+
+	if !strings.Contains(logs.String(), "skipped tasks b") {
+		t.Errorf("Expected: skipped tasks b, got: %s", logs.String())
+	}
+
+	runner = New(
+		Budget(100*time.Millisecond),
+		BudgetCancel(true),
+		Config(&config.Config{Tasks: tasks, Variables: map[string]interface{}{}}),
+		Log(log.New(&logs, "", 0)),
+		Quiet(true),
+	)
+
+	start := time.Now()
+
+	if err := runner.RunAll("c"); err == nil {
+		t.Error("Expected: error, got: nil")
+	}
+
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("Expected: task to be cancelled, took: %s", time.Since(start))
+	}
+}
+
+func TestRunnerParallel(t *testing.T) {
+	var buf bytes.Buffer
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"a": {Commands: yaml2.NewList("sleep 0.3"), Weight: 2},
+				"b": {Commands: yaml2.NewList("sleep 0.3")},
+				"c": {Commands: yaml2.NewList("sleep 0.3")},
+				"d": {Commands: yaml2.NewList("exit 1")},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		Parallel(true),
+		Quiet(true),
+		Resources(2),
+	)
+
+	runner.Stdout = &buf
+	start := time.Now()
+
+	if err := runner.RunAll("a", "b", "c"); err != nil {
+		t.Errorf("Expected: nil, got: %s", err)
+	}
+
+	// a runs alone and b and c runs together.
+	if d := time.Since(start); d < 550*time.Millisecond || d > 850*time.Millisecond {
+		t.Errorf("Expected: about 600ms, got: %s", d)
+	}
+
+	runner = New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"b": {Commands: yaml2.NewList("sleep 0.1")},
+				"d": {Commands: yaml2.NewList("exit 1")},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		FailFast(false),
+		Parallel(true),
+		Quiet(true),
+	)
+
+	errs, ok := runner.RunAll("b", "d").(Errors)
+	if !ok || len(errs) != 1 || errs[0].ID != "d" {
+		t.Errorf("Expected: d to fail, got: %v", errs)
+	}
+}
+
+func TestRunnerPrefix(t *testing.T) {
+	var buf bytes.Buffer
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"hello": {
+					Commands: yaml2.NewList([]string{"echo hello", "printf world"}),
+				},
+				"build": {
+					Deps:     []string{"hello"},
+					Commands: yaml2.NewList("echo build"),
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		Prefix(true),
+		Quiet(true),
+	)
+
+	runner.Stdout = &buf
+
+	if err := runner.Run("build"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	expected := "[hello] hello\n[hello] world\n[build] build\n"
+	if buf.String() != expected {
+		t.Errorf("Expected: %q, got: %q", expected, buf.String())
+	}
+}
+
+func TestRunnerTaskQuiet(t *testing.T) {
+	yes, no := true, false
+
+	run := func(quiet bool, q *bool) string {
+		var buf bytes.Buffer
+
+		runner := New(
+			Config(&config.Config{
+				Tasks: map[string]*task.Task{
+					"hello": {
+						Commands: yaml2.NewList("echo hello"),
+						Quiet:    q,
+					},
+				},
+				Variables: map[string]interface{}{},
+			}),
+			Log(log.New(&buf, "", 0)),
+			Quiet(quiet),
+		)
+
+		runner.Stdout = ioutil.Discard
+
+		if err := runner.Run("hello"); err != nil {
+			t.Fatalf("Expected: nil, got: %s", err)
+		}
+
+		return buf.String()
+	}
+
+	if out := run(false, &yes); len(out) != 0 {
+		t.Errorf("Expected: no logs, got: %q", out)
+	}
+
+	if out := run(true, &no); !strings.Contains(out, "Starting task") {
+		t.Errorf("Expected: task logs, got: %q", out)
+	}
+
+	if out := run(true, nil); len(out) != 0 {
+		t.Errorf("Expected: no logs, got: %q", out)
+	}
+}
+
+func TestRunnerTimeout(t *testing.T) {
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"a": {
+					Commands: yaml2.NewList("sleep 5"),
+				},
+				"b": {
+					Commands: yaml2.NewList("echo hello"),
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		FailFast(false),
+		Quiet(true),
+		Timeout(200*time.Millisecond),
+	)
+
+	var buf bytes.Buffer
+	runner.Stdout = &buf
+	runner.Stderr = ioutil.Discard
+
+	start := time.Now()
+	err := runner.RunAll("a", "b")
+
+	if _, ok := err.(*TimeoutError); !ok {
+		t.Fatalf("Expected: TimeoutError, got: %v", err)
+	}
+
+	if time.Since(start) > 3*time.Second {
+		t.Errorf("Expected: tasks to be cancelled, took: %s", time.Since(start))
+	}
+
+	if ExitStatus(err) != TimeoutExitStatus {
+		t.Errorf("Expected: %d, got: %d", TimeoutExitStatus, ExitStatus(err))
+	}
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected: no output, got: %q", buf.String())
+	}
+}
+
+func TestRunnerConcurrency(t *testing.T) {
+	run := func(group string) string {
+		var buf bytes.Buffer
+
+		tasks := make(map[string]*task.Task)
+		for _, id := range []string{"a", "b"} {
+			tasks[id] = &task.Task{
+				Commands:    yaml2.NewList([]string{"echo start", "sleep 0.2", "echo end"}),
+				Concurrency: group,
+			}
+		}
+
+		runner := New(
+			Config(&config.Config{Tasks: tasks, Variables: map[string]interface{}{}}),
+			Parallel(true),
+			Quiet(true),
+			Resources(2),
+		)
+
+		runner.Stdout = &buf
+
+		if err := runner.RunAll("a", "b"); err != nil {
+			t.Fatalf("Expected: nil, got: %s", err)
+		}
+
+		return strings.Replace(buf.String(), "\n", " ", -1)
+	}
+
+	if out := run("db"); out != "start end start end " {
+		t.Errorf("Expected: tasks to run one at a time, got: %s", out)
+	}
+
+	if out := run(""); out != "start start end end " {
+		t.Errorf("Expected: tasks to run at the same time, got: %s", out)
+	}
+}
+
+func TestRunnerRetryOn(t *testing.T) {
+	run := func(code int) (int, error) {
+		dir, err := ioutil.TempDir("", "max-retry")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		runner := New(
+			Config(&config.Config{
+				Tasks: map[string]*task.Task{
+					"flaky": {
+						Commands:   yaml2.NewList(fmt.Sprintf("echo attempt >> attempts && exit %d", code)),
+						Dir:        dir,
+						Retries:    2,
+						RetryDelay: "10ms",
+						RetryOn:    []int{75},
+					},
+				},
+				Variables: map[string]interface{}{},
+			}),
+			Quiet(true),
+		)
+
+		err = runner.Run("flaky")
+
+		buf, _ := ioutil.ReadFile(filepath.Join(dir, "attempts"))
+
+		return strings.Count(string(buf), "attempt"), err
+	}
+
+	if n, err := run(75); err == nil || n != 3 {
+		t.Errorf("Expected: 3 attempts and a error, got: %d attempts and %v", n, err)
+	}
+
+	if n, err := run(1); err == nil || n != 1 {
+		t.Errorf("Expected: 1 attempt and a error, got: %d attempts and %v", n, err)
+	}
+}
+
+func TestRunnerRetryIfOutput(t *testing.T) {
+	run := func(msg string) (int, string, error) {
+		dir, err := ioutil.TempDir("", "max-retry")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		var stderr bytes.Buffer
+
+		runner := New(
+			Config(&config.Config{
+				Tasks: map[string]*task.Task{
+					"flaky": {
+						Commands:   yaml2.NewList(fmt.Sprintf("echo attempt >> attempts && echo %s >&2 && exit 1", msg)),
+						Dir:        dir,
+						Retries:    2,
+						RetryDelay: "10ms",
+						RetryIf:    "connection (reset|refused)",
+					},
+				},
+				Variables: map[string]interface{}{},
+			}),
+			Quiet(true),
+		)
+		runner.Stderr = &stderr
+
+		err = runner.Run("flaky")
+
+		buf, _ := ioutil.ReadFile(filepath.Join(dir, "attempts"))
+
+		return strings.Count(string(buf), "attempt"), stderr.String(), err
+	}
+
+	if n, out, err := run("connection reset"); err == nil || n != 3 || strings.Count(out, "connection reset") != 3 {
+		t.Errorf("Expected: 3 attempts, output and a error, got: %d attempts, %q and %v", n, out, err)
+	}
+
+	if n, _, err := run("permission denied"); err == nil || n != 1 {
+		t.Errorf("Expected: 1 attempt and a error, got: %d attempts and %v", n, err)
+	}
+}
+
+func TestRunnerTaskTimeout(t *testing.T) {
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"slow": {
+					Commands: yaml2.NewList("sleep 5"),
+					Timeout:  "100ms",
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		Quiet(true),
+	)
+
+	start := time.Now()
+	err := runner.Run("slow")
+
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("Expected: timed out error, got: %v", err)
+	}
+
+	if d := time.Since(start); d > 3*time.Second {
+		t.Errorf("Expected: task to be cancelled, got: %s", d)
+	}
+}
+
+func TestRunnerExpect(t *testing.T) {
+	exit := 3
+
+	tasks := map[string]*task.Task{
+		"ok": {
+			Commands: yaml2.NewList("echo status: OK"),
+			Expect:   &task.Expect{Contains: "OK"},
+		},
+		"fail": {
+			Commands: yaml2.NewList("echo status: FAIL"),
+			Expect:   &task.Expect{Contains: "OK"},
+		},
+		"exit": {
+			Commands: yaml2.NewList("echo version 2 >&2 && exit 3"),
+			Expect:   &task.Expect{Exit: &exit, Matches: `version \d+`},
+		},
+	}
+
+	for id, ok := range map[string]bool{"ok": true, "fail": false, "exit": true} {
+		runner := New(Config(&config.Config{Tasks: tasks, Variables: map[string]interface{}{}}), Quiet(true))
+		runner.Stdout, runner.Stderr = ioutil.Discard, ioutil.Discard
+
+		err := runner.Run(id)
+
+		if ok && err != nil {
+			t.Errorf("Expected: nil for %s, got: %s", id, err)
+		}
+
+		if _, isExpect := err.(*task.ExpectError); !ok && !isExpect {
+			t.Errorf("Expected: ExpectError for %s, got: %v", id, err)
+		}
+	}
+
+	// Each run writes to its own output, e.g intervals.
+	runner := New(Config(&config.Config{Tasks: tasks, Variables: map[string]interface{}{}}), Quiet(true))
+	runner.Stdout, runner.Stderr = ioutil.Discard, ioutil.Discard
+
+	for i := 0; i < 2; i++ {
+		if err := runner.Run("ok"); err != nil {
+			t.Errorf("Expected: nil for run %d, got: %s", i+1, err)
+		}
+	}
+}
+
+func TestRunnerOnFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "max-failure")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	run := func(id string) string {
+		os.Remove(filepath.Join(dir, "failures"))
+
+		runner := New(
+			Config(&config.Config{
+				OnFailure: yaml2.NewList("notify"),
+				Tasks: map[string]*task.Task{
+					"build": {
+						Commands: yaml2.NewList("exit 3"),
+						Dir:      dir,
+					},
+					"deploy": {
+						Deps:     []string{"build"},
+						Commands: yaml2.NewList("echo deploy"),
+						Dir:      dir,
+					},
+					"lint": {
+						Commands:  yaml2.NewList("exit 2"),
+						Dir:       dir,
+						OnFailure: yaml2.NewList("echo lint $MAX_FAILED_TASK $MAX_EXIT_STATUS >> failures"),
+					},
+					"notify": {
+						Commands: yaml2.NewList("echo notify $MAX_FAILED_TASK $MAX_EXIT_STATUS >> failures && exit 1"),
+						Dir:      dir,
+					},
+				},
+				Variables: map[string]interface{}{},
+			}),
+			Log(log.New(ioutil.Discard, "", 0)),
+			Quiet(true),
+		)
+		runner.Stdout, runner.Stderr = ioutil.Discard, ioutil.Discard
+
+		if err := runner.RunAll(id); err == nil {
+			t.Errorf("Expected: error for %s, got: nil", id)
+		}
+
+		buf, _ := ioutil.ReadFile(filepath.Join(dir, "failures"))
+
+		return string(buf)
+	}
+
+	if out := run("deploy"); out != "notify build 3\n" {
+		t.Errorf("Expected: global handler to run once for build, got: %q", out)
+	}
+
+	if out := run("lint"); out != "lint lint 2\n" {
+		t.Errorf("Expected: task handler to run, got: %q", out)
+	}
+
+	if out := run("notify"); out != "notify\nnotify notify 1\n" {
+		t.Errorf("Expected: handler to run once without triggering itself, got: %q", out)
+	}
+}
+
+func TestRunnerBench(t *testing.T) {
+	var buf bytes.Buffer
+
+	m := metrics.New()
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"build": {
+					Commands: yaml2.NewList([]string{"true", "sleep 0.1"}),
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		Bench(true),
+		Log(log.New(&buf, "", 0)),
+		Metrics(m),
+		Quiet(true),
+	)
+
+	if err := runner.RunAll("build"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	for _, exp := range []string{"Bench task build", "$ true", "$ sleep 0.1"} {
+		if !strings.Contains(buf.String(), exp) {
+			t.Errorf("Expected: %s in output, got: %s", exp, buf.String())
+		}
+	}
+
+	c := m.Results()[0].Commands
+	if len(c) != 2 || c[1].Duration < 100*time.Millisecond {
+		t.Errorf("Expected: two timed commands, got: %v", c)
+	}
+}
+
+func TestRunnerMaxOutput(t *testing.T) {
+	var buf bytes.Buffer
+
+	runner := New(
+		Config(&config.Config{
+			MaxOutput: "8B",
+			Tasks: map[string]*task.Task{
+				"hello": {
+					Commands: yaml2.NewList([]string{"echo hello world", "echo done > /dev/null"}),
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		Quiet(true),
+	)
+
+	runner.Stdout = &buf
+
+	if err := runner.Run("hello"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if got := buf.String(); got != "hello wo\n[output truncated]\n" {
+		t.Errorf("Expected: truncated output, got: %q", got)
+	}
+}
+
+func TestRunnerCommandError(t *testing.T) {
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"hello": {
+					Commands: yaml2.NewList([]string{"true", "test $NAME = $API_TOKEN"}),
+					Dir:      os.TempDir(),
+					Variables: map[string]interface{}{
+						"API_TOKEN": "secret",
+						"NAME":      "max",
+					},
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		Quiet(true),
+	)
+
+	err := runner.Run("hello")
+
+	var ce *exec.CommandError
+	if !errors.As(err, &ce) {
+		t.Fatalf("Expected: command error, got: %v", err)
+	}
+
+	if ce.Command != "test max = ****" || ce.Dir != os.TempDir() {
+		t.Errorf("Expected: failed command and dir, got: %s in %s", ce.Command, ce.Dir)
+	}
+
+	if ExitStatus(err) != 1 {
+		t.Errorf("Expected: 1, got: %d", ExitStatus(err))
+	}
+}
+
+func TestRunnerArtifacts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "max-artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"build": {
+					Artifacts: yaml2.NewList([]string{"app", "app.sha256"}),
+					Commands:  yaml2.NewList("touch app"),
+					Dir:       dir,
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		Quiet(true),
+	)
+
+	err = runner.Run("build")
+	if err == nil || err.Error() != "max: task build didn't produce artifacts: app.sha256" {
+		t.Errorf("Expected: missing artifacts error, got: %v", err)
+	}
+}
+
+func TestRunnerRequires(t *testing.T) {
+	var buf bytes.Buffer
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"generate": {
+					Commands: yaml2.NewList("echo generate"),
+				},
+				"build": {
+					Commands: yaml2.NewList("echo build"),
+					Deps:     []string{"generate"},
+					Requires: yaml2.NewList([]string{"sh", "{{ .bin }}"}),
+					Variables: map[string]interface{}{
+						"bin": "max-missing-binary",
+					},
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		Quiet(true),
+	)
+
+	runner.Stdout = &buf
+
+	err := runner.Run("build")
+	if err == nil || err.Error() != "max: task build requires max-missing-binary, install them or add them to PATH" {
+		t.Errorf("Expected: missing requirements error, got: %v", err)
+	}
+
+	// Requirements are checked before deps runs.
+	if buf.Len() > 0 {
+		t.Errorf("Expected: no output, got: %s", buf.String())
+	}
+}
+
+func TestRunnerFlags(t *testing.T) {
+	var buf bytes.Buffer
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"deploy": {
+					Args: map[string]interface{}{
+						"dry_run":  map[interface{}]interface{}{"type": "bool"},
+						"replicas": map[interface{}]interface{}{"type": "int", "default": 2},
+					},
+					Commands: yaml2.NewList("echo {{ .replicas }} {{ if .dry_run }}dry run{{ end }}"),
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		Flags(map[string]interface{}{"dry_run": true}),
+		Quiet(true),
+	)
+
+	runner.Stdout = &buf
+
+	if err := runner.Run("deploy"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if got := strings.TrimSpace(buf.String()); got != "2 dry run" {
+		t.Errorf("Expected: 2 dry run, got: %s", got)
+	}
+}
+
+func TestRunnerPost(t *testing.T) {
+	var buf bytes.Buffer
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"db": {
+					Commands: yaml2.NewList("echo db up"),
+					Post:     yaml2.NewList("echo down $MAX_STATUS"),
+				},
+				"seed": {
+					Commands: yaml2.NewList([]string{"echo seed", "exit 1"}),
+					Post:     yaml2.NewList("echo unseed"),
+				},
+				"test": {
+					Commands: yaml2.NewList("echo test"),
+					Deps:     []string{"db", "seed"},
+					Post:     yaml2.NewList("echo report $MAX_STATUS"),
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		Quiet(true),
+	)
+
+	runner.Stdout = &buf
+
+	if err := runner.RunAll("test"); err == nil {
+		t.Fatal("Expected: error, got: nil")
+	}
+
+	exp := "db up\nseed\nunseed\ndown failure\nreport failure\n"
+	if got := buf.String(); got != exp {
+		t.Errorf("Expected: %q, got: %q", exp, got)
+	}
+
+	// Post commands only runs once.
+	buf.Reset()
+	runner.Cleanup(nil)
+
+	if buf.Len() > 0 {
+		t.Errorf("Expected: no output, got: %q", buf.String())
+	}
+}
+
+func TestResolveTask(t *testing.T) {
+	c := &config.Config{
+		Tasks: map[string]*task.Task{
+			"build":         {},
+			"build_windows": {},
+			"deploy":        {},
+			"deploy-eu":     {},
+			"dev":           {},
+			"test":          {},
+		},
+	}
+
+	for name, exp := range map[string]string{
+		"build":  "build",
+		"bu":     "build",
+		"deploy": "deploy",
+		"t":      "test",
+	} {
+		if id, err := ResolveTask(c, name); err != nil || id != exp {
+			t.Errorf("Expected: %s for %s, got: %s, %v", exp, name, id, err)
+		}
+	}
+
+	_, err := ResolveTask(c, "de")
+	if e, ok := err.(*AmbiguousTaskError); !ok || strings.Join(e.Matches, ",") != "deploy,deploy-eu,dev" {
+		t.Errorf("Expected: ambiguous task error, got: %v", err)
+	}
+
+	if _, err := ResolveTask(c, "lint"); err == nil {
+		t.Error("Expected: missing task error, got: nil")
+	}
+
+	runner := New(Config(c), Quiet(true))
+
+	if ids, err := runner.expand([]string{"bu", "t"}); err != nil || strings.Join(ids, ",") != "build,test" {
+		t.Errorf("Expected: build and test, got: %v, %v", ids, err)
+	}
+}
+
+func TestRunnerContextCause(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cause := errors.New("max: interrupted by interrupt")
+
+	runner := New(
+		Config(&config.Config{
+			Tasks:     map[string]*task.Task{"slow": {Commands: yaml2.NewList("sleep 5")}},
+			Variables: map[string]interface{}{},
+		}),
+		Context(ctx),
+		Quiet(true),
+	)
+
+	time.AfterFunc(100*time.Millisecond, func() { cancel(cause) })
+
+	start := time.Now()
+	if err := runner.RunAll("slow"); err != cause {
+		t.Errorf("Expected: %v, got: %v", cause, err)
+	}
+
+	if d := time.Since(start); d > 3*time.Second {
+		t.Errorf("Expected: cancelled task, took %s", d)
+	}
+}
@@ -0,0 +1,109 @@
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	osexec "os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// changedFiles returns the absolute paths of files changed since the git
+// ref, uncommitted changes to tracked files included.
+func (r *Runner) changedFiles(ref string) ([]string, error) {
+	root, err := r.git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := r.git("diff", "--name-only", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+
+	for _, name := range strings.Split(out, "\n") {
+		if name = strings.TrimSpace(name); len(name) > 0 {
+			files = append(files, filepath.Join(root, filepath.FromSlash(name)))
+		}
+	}
+
+	return files, nil
+}
+
+func (r *Runner) git(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := osexec.CommandContext(r.ctx, "git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("max: git %s failed: %s", strings.Join(args, " "), strings.TrimSpace(stderr.String()))
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// filterChanged returns the tasks that are affected by files changed since
+// the git ref given with the Since option, other tasks are skipped.
+func (r *Runner) filterChanged(ids []string) ([]string, error) {
+	files, err := r.changedFiles(r.since)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+
+	for _, id := range ids {
+		if ok, reason, changed := r.affected(id, files, make(map[string]bool)); ok {
+			r.whyf(id, "runs, %s", reason)
+			r.whyFiles(changed)
+			res = append(res, id)
+		} else {
+			r.whyf(id, "is skipped, no sources changed since %s", r.since)
+
+			if !r.quiet {
+				r.log.Printf("Skipping task %s, no changes since %s\n", color.GreenString(id), r.since)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// affected reports whether the task's sources matches any of the files or
+// if any of the tasks it depends on or runs are affected. Tasks without
+// sources are affected unless the SkipNoSources option is used. The reason
+// and the changed files that matched the sources are returned.
+func (r *Runner) affected(id string, files []string, seen map[string]bool) (bool, string, []string) {
+	if seen[id] {
+		return false, "", nil
+	}
+
+	seen[id] = true
+
+	t := r.Task(id)
+	if t == nil {
+		return true, "task is missing", nil
+	}
+
+	if len(t.Sources.Values) == 0 && !r.skipNoSources {
+		return true, "task has no sources", nil
+	}
+
+	if changed := t.ChangedSources(files); len(changed) > 0 {
+		return true, fmt.Sprintf("sources changed since %s:", r.since), changed
+	}
+
+	for _, other := range append(t.AllDeps(), t.Tasks.Values...) {
+		if ok, _, changed := r.affected(other, files, seen); ok {
+			return true, fmt.Sprintf("%s is affected by changes since %s", other, r.since), changed
+		}
+	}
+
+	return false, "", nil
+}
@@ -0,0 +1,103 @@
+package runner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/task"
+)
+
+func TestRunnerSince(t *testing.T) {
+	if _, err := osexec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	dir, _ = filepath.EvalSymlinks(dir)
+
+	git := func(args ...string) {
+		cmd := osexec.Command("git", append([]string{"-c", "user.name=max", "-c", "user.email=max@example.com"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %s", strings.Join(args, " "), out)
+		}
+	}
+
+	os.MkdirAll(filepath.Join(dir, "api"), 0755)
+	os.MkdirAll(filepath.Join(dir, "web"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "api", "main.go"), []byte("package main"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "web", "index.js"), []byte(""), 0644)
+
+	git("init", "-q")
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+
+	ioutil.WriteFile(filepath.Join(dir, "api", "main.go"), []byte("package main\n"), 0644)
+
+	run := func(skip bool) string {
+		var buf bytes.Buffer
+
+		runner := New(
+			Config(&config.Config{
+				Tasks: map[string]*task.Task{
+					"api": {
+						Commands: yaml2.NewList("echo api"),
+						Dir:      filepath.Join(dir, "api"),
+						Sources:  yaml2.NewList("**/*.go"),
+					},
+					"web": {
+						Commands: yaml2.NewList("echo web"),
+						Dir:      filepath.Join(dir, "web"),
+						Sources:  yaml2.NewList("**/*.js"),
+					},
+					"deploy": {
+						Commands: yaml2.NewList("echo deploy"),
+						Deps:     []string{"api"},
+						Sources:  yaml2.NewList("deploy.yml"),
+					},
+					"lint": {
+						Commands: yaml2.NewList("echo lint"),
+						Dir:      dir,
+					},
+				},
+				Variables: map[string]interface{}{},
+			}),
+			Quiet(true),
+			Since("HEAD"),
+			SkipNoSources(skip),
+		)
+
+		runner.Stdout = &buf
+
+		wd, _ := os.Getwd()
+		os.Chdir(dir)
+		defer os.Chdir(wd)
+
+		if err := runner.RunAll("api", "deploy", "lint", "web"); err != nil {
+			t.Fatalf("Expected: nil, got: %s", err)
+		}
+
+		return buf.String()
+	}
+
+	if out := run(false); out != "api\napi\ndeploy\nlint\n" {
+		t.Errorf("Expected: api, deploy and lint, got: %q", out)
+	}
+
+	if out := run(true); out != "api\napi\ndeploy\n" {
+		t.Errorf("Expected: api and deploy, got: %q", out)
+	}
+}
@@ -0,0 +1,132 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frozzare/max/internal/config"
+)
+
+// Event types written by streams.
+const (
+	EventStart = "start"
+	EventLine  = "line"
+	EventEnd   = "end"
+)
+
+// Event represents a output event written as a json line when streaming,
+// a start event is written when a task starts, a line event for each line
+// the task's commands writes to stdout or stderr and a end event with the
+// status when the task is done.
+type Event struct {
+	Type     string    `json:"type"`
+	Task     string    `json:"task"`
+	Time     time.Time `json:"time"`
+	Stream   string    `json:"stream,omitempty"`
+	Line     string    `json:"line,omitempty"`
+	Status   string    `json:"status,omitempty"`
+	Duration float64   `json:"duration_seconds,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// stream writes events from tasks running concurrently to a writer.
+type stream struct {
+	enc *json.Encoder
+	mu  sync.Mutex
+}
+
+func newStream(w io.Writer) *stream {
+	return &stream{enc: json.NewEncoder(w)}
+}
+
+// RunStream runs tasks like RunAll and writes their output as json line
+// events to the writer instead of stdout and stderr, e.g to forward live
+// output to a web ui with server-sent events or websockets.
+func RunStream(ctx context.Context, c *config.Config, out io.Writer, ids ...string) error {
+	r := New(Config(c), Quiet(true), Stream(out))
+	r.ctx = ctx
+
+	return r.RunAll(ids...)
+}
+
+func (s *stream) write(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	s.enc.Encode(e)
+}
+
+func (s *stream) start(id string) {
+	s.write(Event{Type: EventStart, Task: id})
+}
+
+func (s *stream) end(id string, d time.Duration, err error) {
+	e := Event{Type: EventEnd, Task: id, Status: "success", Duration: d.Seconds()}
+
+	if err != nil {
+		e.Status = "failure"
+		e.Error = err.Error()
+	}
+
+	s.write(e)
+}
+
+// writer returns a writer that writes a line event for each line written.
+func (s *stream) writer(id, name string) *streamWriter {
+	return &streamWriter{id: id, name: name, s: s}
+}
+
+// streamWriter buffers partial lines until a newline is written or the
+// writer is flushed.
+type streamWriter struct {
+	buf  []byte
+	id   string
+	mu   sync.Mutex
+	name string
+	s    *stream
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i == -1 {
+			break
+		}
+
+		w.line(w.buf[:i])
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush writes a buffered partial line.
+func (w *streamWriter) Flush() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.line(w.buf)
+		w.buf = nil
+	}
+
+	return nil
+}
+
+func (w *streamWriter) line(line []byte) {
+	w.s.write(Event{Type: EventLine, Task: w.id, Stream: w.name, Line: strings.TrimSuffix(string(line), "\r")})
+}
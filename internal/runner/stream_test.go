@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/task"
+)
+
+func TestRunStream(t *testing.T) {
+	var buf bytes.Buffer
+
+	c := &config.Config{
+		Tasks: map[string]*task.Task{
+			"build": {
+				Commands: yaml2.NewList([]string{"echo building", "echo failed >&2", "exit 1"}),
+				Deps:     []string{"lint"},
+			},
+			"lint": {
+				Commands: yaml2.NewList("echo linting"),
+			},
+		},
+		Variables: map[string]interface{}{},
+	}
+
+	if err := RunStream(context.Background(), c, &buf, "build"); err == nil {
+		t.Fatal("Expected: error, got: nil")
+	}
+
+	var got []string
+
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e Event
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+
+		if e.Time.IsZero() {
+			t.Errorf("Expected: event time, got: zero time")
+		}
+
+		got = append(got, e.Type+" "+e.Task+" "+e.Stream+" "+e.Line+" "+e.Status)
+	}
+
+	exp := []string{
+		"start build   ",
+		"start lint   ",
+		"line lint stdout linting ",
+		"end lint   success",
+		"line build stdout building ",
+		"line build stderr failed ",
+		"end build   failure",
+	}
+
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected: %q, got: %q", exp, got)
+	}
+}
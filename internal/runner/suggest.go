@@ -0,0 +1,98 @@
+package runner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the max number of suggested task names.
+const maxSuggestions = 3
+
+// missing returns a task missing error with suggested task names.
+func (r *Runner) missing(id string) error {
+	if names := r.suggest(id); len(names) > 0 {
+		return fmt.Errorf("task missing: %s, did you mean %s?", id, strings.Join(names, " or "))
+	}
+
+	return fmt.Errorf("task missing: %s", id)
+}
+
+// suggest returns the task names closest to a missing task name.
+func (r *Runner) suggest(name string) []string {
+	if r.config == nil {
+		return nil
+	}
+
+	type match struct {
+		name string
+		dist int
+	}
+
+	var matches []match
+	lower := strings.ToLower(name)
+
+	for _, n := range r.config.List() {
+		d := levenshtein(lower, strings.ToLower(n))
+
+		// Allow about one edit for every third character.
+		if d <= len(name)/3+1 {
+			matches = append(matches, match{n, d})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].dist < matches[j].dist
+	})
+
+	var names []string
+	for i, m := range matches {
+		if i == maxSuggestions {
+			break
+		}
+
+		names = append(names, m.name)
+	}
+
+	return names
+}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+
+	return m
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/task"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		exp  int
+	}{
+		{"", "", 0},
+		{"build", "build", 0},
+		{"biuld", "build", 2},
+		{"test", "tests", 1},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, test := range tests {
+		if got := levenshtein(test.a, test.b); got != test.exp {
+			t.Errorf("Expected: %d for %s and %s, got: %d", test.exp, test.a, test.b, got)
+		}
+	}
+}
+
+func TestRunnerSuggest(t *testing.T) {
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"build":  {},
+				"deploy": {},
+				"test":   {},
+			},
+		}),
+	)
+
+	if got := runner.suggest("BUILD"); !reflect.DeepEqual(got, []string{"build"}) {
+		t.Errorf("Expected: [build], got: %v", got)
+	}
+
+	if got := runner.suggest("xyz"); got != nil {
+		t.Errorf("Expected: no suggestions, got: %v", got)
+	}
+
+	if err := runner.Run("biuld"); err == nil || err.Error() != "task missing: biuld, did you mean build?" {
+		t.Errorf("Expected: suggestion error, got: %v", err)
+	}
+}
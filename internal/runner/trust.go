@@ -0,0 +1,19 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/frozzare/max/internal/task"
+)
+
+// trusted returns a error if the task is included from a remote host that
+// is not trusted with the Trust option. Local tasks and remote tasks pinned
+// by a checksum are always trusted.
+func (r *Runner) trusted(t *task.Task) error {
+	host := t.Origin()
+	if len(host) == 0 || r.trust[host] {
+		return nil
+	}
+
+	return fmt.Errorf("max: task %s is included from %s and is not trusted, use --trust %s or pin the include with a checksum", t.ID(), host, host)
+}
@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"bytes"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/task"
+)
+
+func TestRunnerTrust(t *testing.T) {
+	remote := &task.Task{Commands: yaml2.NewList("echo remote")}
+	remote.Origin("example.com")
+
+	run := func(hosts ...string) (string, error) {
+		var buf bytes.Buffer
+
+		runner := New(
+			Config(&config.Config{
+				Tasks: map[string]*task.Task{
+					"local":  {Commands: yaml2.NewList("echo local")},
+					"remote": remote,
+				},
+				Variables: map[string]interface{}{},
+			}),
+			Quiet(true),
+			Trust(hosts),
+		)
+
+		runner.Stdout = &buf
+		err := runner.RunAll("local", "remote")
+
+		return buf.String(), err
+	}
+
+	out, err := run()
+	if err == nil || !strings.Contains(err.Error(), "--trust example.com") {
+		t.Errorf("Expected: untrusted error, got: %v", err)
+	}
+
+	if out != "local\n" {
+		t.Errorf("Expected: %q, got: %q", "local\n", out)
+	}
+
+	if out, err := run("example.com"); err != nil || out != "local\nremote\n" {
+		t.Errorf("Expected: local and remote, got: %q, %v", out, err)
+	}
+}
+
+func TestRunnerTrustHandlers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "max-trust")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	remote := &task.Task{
+		Commands:  yaml2.NewList("echo remote"),
+		Dir:       dir,
+		OnFailure: yaml2.NewList("touch failure"),
+		Post:      yaml2.NewList("touch post"),
+	}
+	remote.Origin("example.com")
+
+	runner := New(
+		Config(&config.Config{
+			Tasks:     map[string]*task.Task{"remote": remote},
+			Variables: map[string]interface{}{},
+		}),
+		Log(log.New(ioutil.Discard, "", 0)),
+		Quiet(true),
+	)
+
+	err = runner.RunAll("remote")
+	if err == nil || !strings.Contains(err.Error(), "not trusted") {
+		t.Errorf("Expected: untrusted error, got: %v", err)
+	}
+
+	for _, name := range []string{"failure", "post"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("Expected: %s commands of untrusted task to not run", name)
+		}
+	}
+}
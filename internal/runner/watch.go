@@ -0,0 +1,92 @@
+package runner
+
+import (
+	"fmt"
+
+	"github.com/frozzare/max/internal/task"
+)
+
+// WatchFiles returns the files to watch for the tasks, the config file, the
+// local files tasks are included from and the files matching the sources of
+// the tasks that would run.
+func (r *Runner) WatchFiles(ids ...string) ([]string, error) {
+	ids, err := r.expand(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+
+	add := func(paths []string) {
+		for _, path := range paths {
+			if !seen[path] {
+				files = append(files, path)
+				seen[path] = true
+			}
+		}
+	}
+
+	if r.config != nil {
+		add(r.config.Files())
+	}
+
+	for _, id := range r.sort(ids) {
+		var plan []string
+		r.plan(id, true, &plan)
+
+		for _, id := range plan {
+			t, err := r.watchTask(id)
+			if err != nil {
+				return nil, err
+			}
+
+			if t == nil {
+				continue
+			}
+
+			paths, err := t.SourceFiles()
+			if err != nil {
+				return nil, err
+			}
+
+			add(paths)
+		}
+	}
+
+	return files, nil
+}
+
+// watchTask returns the task prepared like when it runs so sources and dir
+// that uses args and variables are watched, nil when the task is missing.
+// Shell variables are not run, tasks that uses them in sources or dir
+// watches the unresolved paths.
+func (r *Runner) watchTask(id string) (*task.Task, error) {
+	orig := r.Task(id)
+	if orig == nil {
+		return nil, nil
+	}
+
+	t := r.prepareTask(orig.Copy())
+	t.ID(id)
+
+	if err := t.ExpandPaths(); err != nil {
+		return nil, err
+	}
+
+	if err := t.LoadEnvFiles(); err != nil {
+		return nil, err
+	}
+
+	t.Options(task.Variables(r.captured.get()))
+
+	for k, command := range t.ShellVariables() {
+		t.Variables[k] = fmt.Sprintf("$(%s)", command)
+	}
+
+	if err := t.Prepare(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
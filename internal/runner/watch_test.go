@@ -0,0 +1,60 @@
+package runner
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/frozzare/max/internal/config"
+)
+
+func TestRunnerWatchFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "max-watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "max.yml")
+	ioutil.WriteFile(path, []byte(`cache_scope: project
+variables:
+  proto: "*.proto"
+  root: `+dir+`
+tasks:
+  build:
+    deps: [generate]
+    dir: "{{ .root }}"
+    sources: [main.go]
+  generate:
+    dir: `+dir+`
+    sources: ["{{ .proto }}"]
+  lint:
+    dir: `+dir+`
+    sources: [lint.yml]
+`), 0644)
+
+	for _, name := range []string{"main.go", "api.proto", "lint.yml"} {
+		ioutil.WriteFile(filepath.Join(dir, name), []byte(""), 0644)
+	}
+
+	c, err := config.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer c.Close()
+
+	got, err := New(Config(c)).WatchFiles("build")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []string{path, filepath.Join(dir, "api.proto"), filepath.Join(dir, "main.go")}
+
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected: %v, got: %v", exp, got)
+	}
+}
@@ -0,0 +1,54 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/frozzare/max/internal/task"
+)
+
+// whyf logs why a task runs or is skipped when using the Why option.
+func (r *Runner) whyf(id, format string, args ...interface{}) {
+	if !r.why {
+		return
+	}
+
+	r.log.Printf("%s task %s %s\n", color.CyanString("why:"), color.GreenString(id), fmt.Sprintf(format, args...))
+}
+
+// whyFiles logs the changed files that made a task run, relative to the
+// working directory when possible.
+func (r *Runner) whyFiles(files []string) {
+	if !r.why {
+		return
+	}
+
+	wd, _ := os.Getwd()
+
+	for _, file := range files {
+		if rel, err := filepath.Rel(wd, file); err == nil && len(wd) > 0 {
+			file = rel
+		}
+
+		r.log.Printf("  %s\n", color.YellowString("M %s", file))
+	}
+}
+
+// whyCache logs if a task with a cache key runs because the key changed
+// or because the task has not run before.
+func (r *Runner) whyCache(t *task.Task) {
+	if !r.why || len(t.CacheKey) == 0 {
+		return
+	}
+
+	if r.cache != nil {
+		if buf, err := r.cache.Get(r.cacheKey(t)); err == nil && len(buf) > 0 {
+			r.whyf(t.ID(), "runs, cache key changed from %s to %s", color.RedString(string(buf)), color.GreenString(t.CacheKey))
+			return
+		}
+	}
+
+	r.whyf(t.ID(), "runs, cache key %s has not run before", t.CacheKey)
+}
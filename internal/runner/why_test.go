@@ -0,0 +1,52 @@
+package runner
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+	"github.com/frozzare/max/internal/config"
+	"github.com/frozzare/max/internal/task"
+)
+
+func TestRunnerWhy(t *testing.T) {
+	var buf bytes.Buffer
+
+	runner := New(
+		Config(&config.Config{
+			Tasks: map[string]*task.Task{
+				"build": {
+					Commands: yaml2.NewList("true"),
+					Status:   yaml2.NewList([]string{"true", "false"}),
+				},
+				"vendor": {
+					Commands: yaml2.NewList("true"),
+					Status:   yaml2.NewList("true"),
+				},
+			},
+			Variables: map[string]interface{}{},
+		}),
+		Log(log.New(&buf, "", 0)),
+		Quiet(true),
+		Why(true),
+	)
+
+	if err := runner.Run("build"); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if err := runner.Run("vendor"); err == nil {
+		t.Fatal("Expected: up to date error, got: nil")
+	}
+
+	for _, exp := range []string{
+		"task build runs, status command false fails",
+		"task vendor is skipped, status commands passes",
+	} {
+		if !strings.Contains(buf.String(), exp) {
+			t.Errorf("Expected: %q in %q", exp, buf.String())
+		}
+	}
+}
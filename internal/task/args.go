@@ -0,0 +1,207 @@
+package task
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// resolveArgs returns a copy of the arguments where arguments declared with
+// an environment variable binding are replaced with the environment
+// variable's value, or the static default when the variable isn't set.
+// Typed arguments are replaced with their default value.
+func resolveArgs(args map[string]interface{}) map[string]interface{} {
+	if args == nil {
+		return nil
+	}
+
+	res := make(map[string]interface{}, len(args))
+
+	for k, v := range args {
+		if a, ok := parseTypedArg(v); ok {
+			v = a.value()
+		} else if name, def, ok := envArg(v); ok {
+			if val, ok := os.LookupEnv(name); ok {
+				v = val
+			} else {
+				v = def
+			}
+		}
+
+		res[k] = v
+	}
+
+	return res
+}
+
+// envArg reports if the value is a argument declaration bound to a
+// environment variable, e.g {env: MAX_REGION, default: eu-west-1}.
+// Only maps with a string env key and a optional default key are declarations.
+func envArg(v interface{}) (string, interface{}, bool) {
+	m, ok := toStringMap(v)
+	if !ok {
+		return "", nil, false
+	}
+
+	name, ok := m["env"].(string)
+	if !ok || len(name) == 0 {
+		return "", nil, false
+	}
+
+	def, hasDefault := m["default"]
+
+	if len(m) > 2 || (len(m) == 2 && !hasDefault) {
+		return "", nil, false
+	}
+
+	if !hasDefault {
+		def = ""
+	}
+
+	return name, def, true
+}
+
+// renderArgs returns a copy of the resolved arguments where string values
+// with templates are rendered, e.g a tag defaulting to {{.version}}-{{.env}}.
+// Arguments are rendered after the arguments they reference and cycles
+// between arguments are errors.
+func renderArgs(args, vars map[string]interface{}, strict bool) (map[string]interface{}, error) {
+	res := resolveArgs(args)
+	if res == nil {
+		return nil, nil
+	}
+
+	templates := make(map[string]string)
+	for k, v := range res {
+		if s, ok := v.(string); ok && strings.Contains(s, "{{") {
+			templates[k] = s
+		}
+	}
+
+	if len(templates) == 0 {
+		return res, nil
+	}
+
+	keys := make([]string, 0, len(templates))
+	for k := range templates {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	done := make(map[string]bool, len(templates))
+
+	var render func(k string, chain []string) error
+	render = func(k string, chain []string) error {
+		if done[k] {
+			return nil
+		}
+
+		for i, c := range chain {
+			if c == k {
+				return fmt.Errorf("max: args cycle: %s", strings.Join(append(chain[i:], k), " -> "))
+			}
+		}
+
+		refs, err := argRefs(templates[k])
+		if err != nil {
+			return fmt.Errorf("max: can't render arg %s: %s", k, err)
+		}
+
+		for _, ref := range refs {
+			if _, ok := templates[ref]; ok {
+				if err := render(ref, append(chain, k)); err != nil {
+					return err
+				}
+			}
+		}
+
+		v, err := renderCommand(templates[k], templateData(res, vars), strict)
+		if err != nil {
+			return fmt.Errorf("max: can't render arg %s: %s", k, err)
+		}
+
+		res[k] = v
+		done[k] = true
+
+		return nil
+	}
+
+	for _, k := range keys {
+		if err := render(k, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// argRefs returns the names of the fields a template references at the top
+// level or under .args, e.g version for {{ .version }} and {{ .args.version }}.
+// Fields under .vars and text outside actions are not references.
+func argRefs(s string) ([]string, error) {
+	tmpl, err := template.New("main").Funcs(TemplateFuncs()).Parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	var refs []string
+
+	var walk func(n parse.Node)
+	walk = func(n parse.Node) {
+		switch n := n.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+
+			for _, c := range n.Nodes {
+				walk(c)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walk(&n.BranchNode)
+		case *parse.RangeNode:
+			walk(&n.BranchNode)
+		case *parse.WithNode:
+			walk(&n.BranchNode)
+		case *parse.BranchNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+
+			for _, c := range n.Cmds {
+				walk(c)
+			}
+		case *parse.CommandNode:
+			for _, a := range n.Args {
+				walk(a)
+			}
+		case *parse.ChainNode:
+			walk(n.Node)
+		case *parse.FieldNode:
+			switch {
+			case len(n.Ident) > 1 && n.Ident[0] == "args":
+				refs = append(refs, n.Ident[1])
+			case len(n.Ident) > 0 && n.Ident[0] != "args" && n.Ident[0] != "vars":
+				refs = append(refs, n.Ident[0])
+			}
+		}
+	}
+
+	if tmpl.Tree != nil {
+		walk(tmpl.Tree.Root)
+	}
+
+	return refs, nil
+}
@@ -0,0 +1,136 @@
+package task
+
+import (
+	"os"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+)
+
+func TestResolveArgs(t *testing.T) {
+	os.Setenv("MAX_TEST_REGION", "us-east-1")
+	defer os.Unsetenv("MAX_TEST_REGION")
+
+	args := resolveArgs(map[string]interface{}{
+		"region": map[interface{}]interface{}{"env": "MAX_TEST_REGION", "default": "eu-west-1"},
+		"zone":   map[interface{}]interface{}{"env": "MAX_TEST_ZONE", "default": "a"},
+		"empty":  map[interface{}]interface{}{"env": "MAX_TEST_ZONE"},
+		"db":     map[interface{}]interface{}{"env": "MAX_TEST_REGION", "host": "localhost"},
+		"name":   "max",
+	})
+
+	if args["region"] != "us-east-1" {
+		t.Errorf("Expected: 'us-east-1', got: %v", args["region"])
+	}
+
+	if args["zone"] != "a" {
+		t.Errorf("Expected: 'a', got: %v", args["zone"])
+	}
+
+	if args["empty"] != "" {
+		t.Errorf("Expected: empty string, got: %v", args["empty"])
+	}
+
+	if _, ok := args["db"].(map[interface{}]interface{}); !ok {
+		t.Errorf("Expected: map, got: %v", args["db"])
+	}
+
+	if args["name"] != "max" {
+		t.Errorf("Expected: 'max', got: %v", args["name"])
+	}
+}
+
+func TestResolveArgsFlag(t *testing.T) {
+	os.Setenv("MAX_TEST_REGION", "us-east-1")
+	defer os.Unsetenv("MAX_TEST_REGION")
+
+	task := &Task{
+		Args: map[string]interface{}{
+			"region": map[interface{}]interface{}{"env": "MAX_TEST_REGION", "default": "eu-west-1"},
+		},
+	}
+
+	task.Options(Args(map[string]interface{}{"region": "eu-north-1"}))
+
+	data, err := TemplateData(task.Args, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if data["region"] != "eu-north-1" {
+		t.Errorf("Expected: 'eu-north-1', got: %v", data["region"])
+	}
+}
+
+func TestRenderArgs(t *testing.T) {
+	args, err := renderArgs(map[string]interface{}{
+		"a":       "{{ .b }}-a",
+		"b":       "{{ .args.c }}-b",
+		"c":       "c",
+		"env":     map[interface{}]interface{}{"env": "MAX_TEST_ENV", "default": "prod"},
+		"tag":     "{{ .version }}-{{ .env }}",
+		"version": "1.0.0",
+	}, nil, true)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if args["tag"] != "1.0.0-prod" {
+		t.Errorf("Expected: '1.0.0-prod', got: %v", args["tag"])
+	}
+
+	if args["a"] != "c-b-a" {
+		t.Errorf("Expected: 'c-b-a', got: %v", args["a"])
+	}
+
+	_, err = renderArgs(map[string]interface{}{
+		"a": "{{ .b }}",
+		"b": "{{ .a }}",
+	}, nil, false)
+
+	if err == nil || err.Error() != "max: args cycle: a -> b -> a" {
+		t.Errorf("Expected: args cycle error, got: %v", err)
+	}
+
+	if _, err := TemplateData(map[string]interface{}{"a": "{{ .b }}", "b": "{{ .a }}"}, nil); err == nil {
+		t.Error("Expected: args cycle error, got: nil")
+	}
+
+	// Variables and text outside actions are not references.
+	args, err = renderArgs(map[string]interface{}{
+		"a":   "{{ .b }}",
+		"b":   "file.a {{ .c }}",
+		"c":   "c",
+		"tag": "{{ .vars.tag }}-x",
+	}, map[string]interface{}{"tag": "v1"}, true)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if args["a"] != "file.a c" || args["tag"] != "v1-x" {
+		t.Errorf("Expected: rendered args, got: %v", args)
+	}
+}
+
+func TestPrepareRenderArgs(t *testing.T) {
+	task := &Task{
+		Args: map[string]interface{}{
+			"env":     "prod",
+			"tag":     "{{ .version }}-{{ .env }}",
+			"version": "1.0.0",
+		},
+		Commands: yaml2.NewList("docker build -t max:{{ .tag }} ."),
+	}
+
+	task.Options(Args(map[string]interface{}{"env": "dev"}))
+
+	if err := task.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := task.Commands.Values[0]; v != "docker build -t max:1.0.0-dev ." {
+		t.Errorf("Expected: 'docker build -t max:1.0.0-dev .', got: %v", v)
+	}
+}
@@ -0,0 +1,36 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// MissingArtifacts returns the task's artifacts that don't exist. Artifacts
+// are relative to the task directory, or the working directory when the
+// task has no directory, and can be globs that must match at least one file.
+func (t *Task) MissingArtifacts() []string {
+	dir := t.Dir
+	if len(dir) == 0 {
+		dir, _ = os.Getwd()
+	}
+
+	var missing []string
+
+	for _, artifact := range t.Artifacts.Values {
+		path, err := ExpandPath(artifact)
+		if err != nil {
+			missing = append(missing, artifact)
+			continue
+		}
+
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+
+		if matches, err := filepath.Glob(path); err != nil || len(matches) == 0 {
+			missing = append(missing, artifact)
+		}
+	}
+
+	return missing
+}
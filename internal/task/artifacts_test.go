@@ -0,0 +1,33 @@
+package task
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+)
+
+func TestMissingArtifacts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "bin"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "bin", "app"), nil, 0755)
+	ioutil.WriteFile(filepath.Join(dir, "app.tar.gz"), nil, 0644)
+
+	task := &Task{
+		Artifacts: yaml2.NewList([]string{"bin/app", "*.tar.gz", "dist/app", "*.zip"}),
+		Dir:       dir,
+	}
+
+	if got := task.MissingArtifacts(); !reflect.DeepEqual(got, []string{"dist/app", "*.zip"}) {
+		t.Errorf("Expected: [dist/app *.zip], got: %v", got)
+	}
+}
@@ -0,0 +1,75 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/frozzare/max/internal/exec"
+)
+
+// CaptureVariables runs the capture commands in key order and stores their trimmed output
+// as task variables. The captured variables are returned so they can be used by
+// other tasks.
+func (t *Task) CaptureVariables(ctx context.Context, stderr io.Writer) (map[string]interface{}, error) {
+	if len(t.Capture) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(t.Capture))
+	for k := range t.Capture {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	if t.Variables == nil {
+		t.Variables = make(map[string]interface{})
+	}
+
+	limit, err := t.OutputLimit()
+	if err != nil {
+		return nil, err
+	}
+
+	vars := make(map[string]interface{}, len(keys))
+
+	for _, k := range keys {
+		var buf bytes.Buffer
+
+		env := t.Env()
+
+		data, err := TemplateData(t.Args, t.Variables)
+		if err != nil {
+			return nil, err
+		}
+
+		command, err := renderCommand(renderEnvVariables(t.Capture[k], env), data, t.strictTemplates)
+		if err != nil {
+			return nil, err
+		}
+
+		opts := &exec.Options{
+			Context:  ctx,
+			CleanEnv: t.CleanEnvEnabled(),
+			Dir:      t.Dir,
+			Env:      toEnv(env),
+			Command:  command,
+			Shell:    t.Shell,
+			Stdout:   truncateWriter(&buf, limit),
+			Stderr:   stderr,
+		}
+
+		if err := exec.Exec(opts); err != nil {
+			return nil, fmt.Errorf("max: capture %s failed: %s", k, err)
+		}
+
+		vars[k] = strings.TrimSpace(buf.String())
+		t.Variables[k] = vars[k]
+	}
+
+	return vars, nil
+}
@@ -1,5 +1,38 @@
 package task
 
+import (
+	"fmt"
+	"strings"
+)
+
+// StringVariables converts variables to strings, lists are joined with a space.
+func StringVariables(vars map[string]interface{}) map[string]string {
+	res := make(map[string]string, len(vars))
+
+	for k, v := range vars {
+		res[k] = toString(v)
+	}
+
+	return res
+}
+
+func toString(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case []interface{}:
+		s := make([]string, len(x))
+		for i, v := range x {
+			s[i] = toString(v)
+		}
+		return strings.Join(s, " ")
+	default:
+		return fmt.Sprintf("%v", x)
+	}
+}
+
 func toEnv(env map[string]string) []string {
 	var envs []string
 	for k, v := range env {
@@ -16,3 +16,23 @@ func TestToEnv(t *testing.T) {
 		t.Fatalf("Expected slice to be the same")
 	}
 }
+
+func TestStringVariables(t *testing.T) {
+	vars := StringVariables(map[string]interface{}{
+		"NAME":  "max",
+		"COUNT": 3,
+		"LIST":  []interface{}{"a", 1, true},
+		"EMPTY": nil,
+	})
+
+	exp := map[string]string{
+		"NAME":  "max",
+		"COUNT": "3",
+		"LIST":  "a 1 true",
+		"EMPTY": "",
+	}
+
+	if !reflect.DeepEqual(exp, vars) {
+		t.Fatalf("Expected: %v, got: %v", exp, vars)
+	}
+}
@@ -0,0 +1,116 @@
+package task
+
+import "github.com/frozzare/go/yaml2"
+
+// Copy returns a copy of the task that can be prepared and run without
+// modifying the original task.
+func (t *Task) Copy() *Task {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+	c.Args = copyMap(t.Args)
+	c.Artifacts = copyList(t.Artifacts)
+	c.Capture = copyStringMap(t.Capture)
+	c.Commands = copyList(t.Commands)
+	c.EnvFile = copyList(t.EnvFile)
+	c.OnFailure = copyList(t.OnFailure)
+	c.Post = copyList(t.Post)
+	c.Requires = copyList(t.Requires)
+	c.Sources = copyList(t.Sources)
+	c.Status = copyList(t.Status)
+	c.Tasks = copyList(t.Tasks)
+	c.Variables = copyMap(t.Variables)
+
+	if t.Deps != nil {
+		c.Deps = append([]string{}, t.Deps...)
+	}
+
+	if t.EnvFrom != nil {
+		c.EnvFrom = append([]string{}, t.EnvFrom...)
+	}
+
+	if t.RetryOn != nil {
+		c.RetryOn = append([]int{}, t.RetryOn...)
+	}
+
+	if t.Docker != nil {
+		d := *t.Docker
+		d.Volumes = copyList(t.Docker.Volumes)
+
+		if t.Docker.Auth != nil {
+			a := *t.Docker.Auth
+			d.Auth = &a
+		}
+
+		c.Docker = &d
+	}
+
+	if t.CleanEnv != nil {
+		e := *t.CleanEnv
+		c.CleanEnv = &e
+	}
+
+	if t.Quiet != nil {
+		q := *t.Quiet
+		c.Quiet = &q
+	}
+
+	if t.Expect != nil {
+		e := *t.Expect
+
+		if t.Expect.Exit != nil {
+			exit := *t.Expect.Exit
+			e.Exit = &exit
+		}
+
+		c.Expect = &e
+	}
+
+	if t.Lock != nil {
+		l := *t.Lock
+		c.Lock = &l
+	}
+
+	if t.Wait != nil {
+		w := *t.Wait
+		c.Wait = &w
+	}
+
+	return &c
+}
+
+func copyList(l yaml2.List) yaml2.List {
+	if l.Values == nil {
+		return l
+	}
+
+	return yaml2.List{Values: append([]string{}, l.Values...)}
+}
+
+func copyStringMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+
+	res := make(map[string]string, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+
+	return res
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+
+	res := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+
+	return res
+}
@@ -0,0 +1,32 @@
+package task
+
+import (
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+	"github.com/frozzare/max/internal/backend/config"
+)
+
+func TestCopy(t *testing.T) {
+	orig := &Task{
+		Args:     map[string]interface{}{"name": "world"},
+		Commands: yaml2.NewList("echo {{ .name }}"),
+		Docker:   &config.Docker{Volumes: yaml2.NewList(".:/app")},
+	}
+
+	c := orig.Copy()
+	c.Args["name"] = "max"
+	c.Docker.Volumes.Values[0] = "/tmp:/app"
+
+	if err := c.Prepare(); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Commands.Values[0] != "echo max" {
+		t.Errorf("Expected: 'echo max', got: %s", c.Commands.Values[0])
+	}
+
+	if orig.Commands.Values[0] != "echo {{ .name }}" || orig.Args["name"] != "world" || orig.Docker.Volumes.Values[0] != ".:/app" {
+		t.Errorf("Expected: original task to be unchanged, got: %v", orig)
+	}
+}
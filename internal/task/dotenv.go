@@ -0,0 +1,150 @@
+package task
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// LoadEnvFiles reads the task's env files in order and adds their values as
+// variables, variables defined in the task takes precedence.
+func (t *Task) LoadEnvFiles() error {
+	if len(t.EnvFile.Values) == 0 {
+		return nil
+	}
+
+	vars := make(map[string]string)
+
+	for _, path := range t.EnvFile.Values {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("max: can't read env file %s: %s", path, err)
+		}
+
+		err = parseDotenv(f, vars)
+		f.Close()
+
+		if err != nil {
+			return fmt.Errorf("max: can't parse env file %s: %s", path, err)
+		}
+	}
+
+	if t.Variables == nil {
+		t.Variables = make(map[string]interface{})
+	}
+
+	for k, v := range vars {
+		if _, ok := t.Variables[k]; !ok {
+			t.Variables[k] = v
+		}
+	}
+
+	return nil
+}
+
+// parseDotenv parses dotenv entries into vars. Entries can reference earlier
+// entries and environment variables, e.g BIN=${BASE}/bin, export prefixes
+// are ignored, single quoted values are literal and double quoted values
+// handles escape sequences.
+func parseDotenv(r io.Reader, vars map[string]string) error {
+	lookup := func(k string) string {
+		// $$ is a escaped dollar sign.
+		if k == "$" {
+			return "$"
+		}
+
+		if v, ok := vars[k]; ok {
+			return v
+		}
+
+		return os.Getenv(k)
+	}
+
+	scanner := bufio.NewScanner(r)
+	n := 0
+
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimSpace(strings.TrimPrefix(line, "export "))
+
+		i := strings.Index(line, "=")
+		if i < 1 {
+			return fmt.Errorf("line %d: missing =", n)
+		}
+
+		key := strings.TrimSpace(line[:i])
+		val := strings.TrimSpace(line[i+1:])
+
+		switch {
+		case strings.HasPrefix(val, "'"):
+			end := strings.Index(val[1:], "'")
+			if end == -1 {
+				return fmt.Errorf("line %d: missing closing quote", n)
+			}
+
+			val = val[1 : end+1]
+		case strings.HasPrefix(val, `"`):
+			s, err := unquoteDotenv(val[1:])
+			if err != nil {
+				return fmt.Errorf("line %d: %s", n, err)
+			}
+
+			val = os.Expand(s, lookup)
+		default:
+			if j := strings.Index(val, " #"); j != -1 {
+				val = strings.TrimSpace(val[:j])
+			}
+
+			val = os.Expand(val, lookup)
+		}
+
+		vars[key] = val
+	}
+
+	return scanner.Err()
+}
+
+// unquoteDotenv returns the value until the closing double quote with escape sequences handled.
+func unquoteDotenv(s string) (string, error) {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+
+		if c == '"' {
+			return b.String(), nil
+		}
+
+		if c == '\\' && i+1 < len(s) {
+			i++
+
+			switch s[i] {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '$':
+				// Keep escaped dollar signs from being expanded.
+				b.WriteString("$$")
+			default:
+				b.WriteByte(s[i])
+			}
+
+			continue
+		}
+
+		b.WriteByte(c)
+	}
+
+	return "", fmt.Errorf("missing closing quote")
+}
@@ -0,0 +1,81 @@
+package task
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	os.Setenv("MAX_DOTENV_HOME", "/home/max")
+	defer os.Unsetenv("MAX_DOTENV_HOME")
+
+	content := `# comment
+BASE=/opt
+export BIN=${BASE}/bin
+HOME_BIN=$MAX_DOTENV_HOME/bin # inline comment
+SINGLE='${BASE} literal'
+DOUBLE="line\nnext \"quoted\" \$BASE $BASE"
+
+EMPTY=
+`
+
+	vars := make(map[string]string)
+
+	if err := parseDotenv(strings.NewReader(content), vars); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	exp := map[string]string{
+		"BASE":     "/opt",
+		"BIN":      "/opt/bin",
+		"HOME_BIN": "/home/max/bin",
+		"SINGLE":   "${BASE} literal",
+		"DOUBLE":   "line\nnext \"quoted\" $BASE /opt",
+		"EMPTY":    "",
+	}
+
+	if !reflect.DeepEqual(vars, exp) {
+		t.Errorf("Expected: %v, got: %v", exp, vars)
+	}
+
+	for _, bad := range []string{"NOVALUE", `A="open`, "B='open"} {
+		if err := parseDotenv(strings.NewReader(bad), make(map[string]string)); err == nil {
+			t.Errorf("Expected: error for %s, got: nil", bad)
+		}
+	}
+}
+
+func TestLoadEnvFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, ".env")
+	if err := ioutil.WriteFile(file, []byte("NAME=file\nREGION=eu\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	task := &Task{Variables: map[string]interface{}{"NAME": "task"}}
+	task.EnvFile.Values = []string{file}
+
+	if err := task.LoadEnvFiles(); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if task.Variables["NAME"] != "task" || task.Variables["REGION"] != "eu" {
+		t.Errorf("Expected: task variables to take precedence, got: %v", task.Variables)
+	}
+
+	task.EnvFile.Values = []string{filepath.Join(dir, "missing")}
+
+	if err := task.LoadEnvFiles(); err == nil {
+		t.Error("Expected: error, got: nil")
+	}
+}
@@ -0,0 +1,96 @@
+package task
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Expect represents assertions on the output and exit status of a task's
+// commands, e.g {contains: OK} or {exit: 0, matches: "version \d+"}. The
+// task fails when a assertion isn't satisfied.
+type Expect struct {
+	Contains string `yaml:"contains"`
+	Exit     *int   `yaml:"exit"`
+	Matches  string `yaml:"matches"`
+}
+
+// ExpectError is returned when the output or exit status of a task's
+// commands don't satisfy the task's expectations.
+type ExpectError struct {
+	Failures []string
+	ID       string
+	Output   string
+}
+
+// Error returns the failed expectations followed by the output.
+func (e *ExpectError) Error() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "max: task %s failed expectations:", e.ID)
+
+	for _, f := range e.Failures {
+		b.WriteString("\n  - " + f)
+	}
+
+	b.WriteString("\n  output:")
+
+	output := strings.TrimRight(e.Output, "\n")
+	if len(output) == 0 {
+		b.WriteString(" (empty)")
+	}
+
+	for _, l := range strings.Split(output, "\n") {
+		if len(output) > 0 {
+			b.WriteString("\n  + " + l)
+		}
+	}
+
+	return b.String()
+}
+
+// Validate returns a error when the matches expression or exit status is bad.
+func (e *Expect) Validate() error {
+	if _, err := regexp.Compile(e.Matches); err != nil {
+		return fmt.Errorf("max: bad expect matches %q: %s", e.Matches, err)
+	}
+
+	if e.Exit != nil && (*e.Exit < 0 || *e.Exit > 255) {
+		return fmt.Errorf("max: expect exit must be between 0 and 255, got %d", *e.Exit)
+	}
+
+	return nil
+}
+
+// Check returns the failed expectations for a output and exit status.
+// Without a exit expectation any exit status other than zero fails. A bad
+// matches expression, e.g rendered from a argument, is returned as a error.
+func (e *Expect) Check(output string, status int) ([]string, error) {
+	var failures []string
+
+	exit := 0
+	if e.Exit != nil {
+		exit = *e.Exit
+	}
+
+	if status != exit {
+		failures = append(failures, fmt.Sprintf("exit status is %d, expected %d", status, exit))
+	}
+
+	if len(e.Contains) > 0 && !strings.Contains(output, e.Contains) {
+		failures = append(failures, fmt.Sprintf("output doesn't contain %q", e.Contains))
+	}
+
+	if len(e.Matches) > 0 {
+		re, err := regexp.Compile(e.Matches)
+		if err != nil {
+			return nil, fmt.Errorf("max: bad expect matches %q: %s", e.Matches, err)
+		}
+
+		if !re.MatchString(output) {
+			failures = append(failures, fmt.Sprintf("output doesn't match %q", e.Matches))
+		}
+	}
+
+	return failures, nil
+}
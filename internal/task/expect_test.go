@@ -0,0 +1,58 @@
+package task
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+)
+
+func TestExpect(t *testing.T) {
+	one := 1
+
+	for _, test := range []struct {
+		expect   Expect
+		output   string
+		status   int
+		failures int
+	}{
+		{Expect{Contains: "OK"}, "status: OK\n", 0, 0},
+		{Expect{Contains: "OK"}, "status: FAIL\n", 0, 1},
+		{Expect{Contains: "OK"}, "status: OK\n", 2, 1},
+		{Expect{Matches: `version \d+`}, "max version 2\n", 0, 0},
+		{Expect{Matches: `version \d+`, Contains: "max"}, "version x\n", 0, 2},
+		{Expect{Exit: &one}, "", 1, 0},
+		{Expect{Exit: &one}, "", 0, 1},
+	} {
+		if failures, err := test.expect.Check(test.output, test.status); err != nil || len(failures) != test.failures {
+			t.Errorf("Expected: %d failures for %+v, got: %v, %v", test.failures, test.expect, failures, err)
+		}
+	}
+
+	if _, err := (&Expect{Matches: "("}).Check("", 0); err == nil || !strings.Contains(err.Error(), "bad expect matches") {
+		t.Errorf("Expected: bad expect matches error, got: %v", err)
+	}
+
+	task := &Task{
+		Args:     map[string]interface{}{"pattern": "("},
+		Commands: yaml2.NewList("echo hello"),
+		Expect:   &Expect{Matches: "{{ .pattern }}"},
+	}
+
+	if err := task.Prepare(); err == nil || !strings.Contains(err.Error(), "bad expect matches") {
+		t.Errorf("Expected: bad expect matches error, got: %v", err)
+	}
+
+	bad := 256
+
+	for _, e := range []*Expect{{Matches: "version ("}, {Exit: &bad}} {
+		if err := e.Validate(); err == nil {
+			t.Errorf("Expected: error for %+v, got: nil", e)
+		}
+	}
+
+	err := &ExpectError{Failures: []string{`output doesn't contain "OK"`}, ID: "smoke", Output: "FAIL\n"}
+	if msg := err.Error(); !strings.Contains(msg, "task smoke failed expectations") || !strings.Contains(msg, "\n  + FAIL") {
+		t.Errorf("Expected: failures and output, got: %s", msg)
+	}
+}
@@ -0,0 +1,68 @@
+package task
+
+import "reflect"
+
+// Extend returns a copy of the parent task with the task's fields merged
+// over it. Fields that are set in the task overrides the parent's fields,
+// args and variables are deep merged and capture commands are merged by name.
+func (t *Task) Extend(parent *Task) *Task {
+	res := parent.Copy()
+	child := t.Copy()
+
+	rv := reflect.ValueOf(res).Elem()
+	cv := reflect.ValueOf(child).Elem()
+
+	for i := 0; i < cv.NumField(); i++ {
+		f := cv.Type().Field(i)
+		v := cv.Field(i)
+
+		if len(f.PkgPath) > 0 || v.IsZero() {
+			continue
+		}
+
+		switch f.Name {
+		case "Args":
+			res.Args = mergeMap(res.Args, child.Args)
+		case "Capture":
+			if res.Capture == nil {
+				res.Capture = make(map[string]string, len(child.Capture))
+			}
+
+			for k, v := range child.Capture {
+				res.Capture[k] = v
+			}
+		case "Variables":
+			res.Variables = mergeMap(res.Variables, child.Variables)
+		default:
+			rv.Field(i).Set(v)
+		}
+	}
+
+	res.Extends = ""
+	res.base = t.base
+	res.id = t.id
+	res.log = t.log
+
+	// Tasks extending remote tasks runs the remote task's commands.
+	if len(t.origin) > 0 {
+		res.origin = t.origin
+	}
+
+	return res
+}
+
+func mergeMap(dst, src map[string]interface{}) map[string]interface{} {
+	if dst == nil {
+		dst = make(map[string]interface{}, len(src))
+	}
+
+	for k, v := range src {
+		if old, ok := dst[k]; ok {
+			dst[k] = MergeValue(old, v)
+		} else {
+			dst[k] = v
+		}
+	}
+
+	return dst
+}
@@ -0,0 +1,48 @@
+package task
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+)
+
+func TestExtend(t *testing.T) {
+	parent := &Task{
+		Args:     map[string]interface{}{"db": map[string]interface{}{"host": "localhost", "port": 5432}},
+		Commands: yaml2.NewList("deploy"),
+		Summary:  "Deploy",
+		Variables: map[string]interface{}{
+			"ENV":    "production",
+			"REGION": "eu",
+		},
+	}
+
+	child := &Task{
+		Args:      map[string]interface{}{"db": map[string]interface{}{"host": "staging"}},
+		Extends:   "deploy",
+		Variables: map[string]interface{}{"ENV": "staging"},
+	}
+
+	res := child.Extend(parent)
+
+	if res.Summary != "Deploy" || !reflect.DeepEqual(res.Commands.Values, []string{"deploy"}) {
+		t.Errorf("Expected: parent fields, got: %+v", res)
+	}
+
+	if !reflect.DeepEqual(res.Variables, map[string]interface{}{"ENV": "staging", "REGION": "eu"}) {
+		t.Errorf("Expected: merged variables, got: %v", res.Variables)
+	}
+
+	if !reflect.DeepEqual(res.Args["db"], map[string]interface{}{"host": "staging", "port": 5432}) {
+		t.Errorf("Expected: deep merged args, got: %v", res.Args["db"])
+	}
+
+	if len(res.Extends) != 0 {
+		t.Errorf("Expected: empty extends, got: %s", res.Extends)
+	}
+
+	if parent.Variables["ENV"] != "production" {
+		t.Errorf("Expected: parent to be unchanged, got: %v", parent.Variables["ENV"])
+	}
+}
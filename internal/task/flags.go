@@ -0,0 +1,242 @@
+package task
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// argTypes contains the types typed arguments can be declared with.
+var argTypes = map[string]bool{
+	"bool":   true,
+	"float":  true,
+	"int":    true,
+	"string": true,
+}
+
+// typedArg represents a argument declared with a type, e.g
+// {type: int, default: 8080, usage: port to listen on}.
+type typedArg struct {
+	def   interface{}
+	env   string
+	typ   string
+	usage string
+}
+
+// parseTypedArg reports if the value is a typed argument declaration.
+// Only maps with a known type key and optional default, env and usage
+// keys are declarations.
+func parseTypedArg(v interface{}) (*typedArg, bool) {
+	m, ok := toStringMap(v)
+	if !ok {
+		return nil, false
+	}
+
+	typ, ok := m["type"].(string)
+	if !ok || !argTypes[typ] {
+		return nil, false
+	}
+
+	a := &typedArg{def: m["default"], typ: typ}
+
+	for k, v := range m {
+		switch k {
+		case "type", "default":
+		case "env":
+			if a.env, ok = v.(string); !ok {
+				return nil, false
+			}
+		case "usage":
+			if a.usage, ok = v.(string); !ok {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+	}
+
+	return a, true
+}
+
+// value returns the environment variable's value when it's set and can be
+// converted to the argument's type, otherwise the default value or the
+// type's zero value.
+func (a *typedArg) value() interface{} {
+	if len(a.env) > 0 {
+		if s, ok := os.LookupEnv(a.env); ok {
+			if v, err := a.convert(s); err == nil {
+				return v
+			}
+		}
+	}
+
+	if a.def == nil {
+		v, _ := a.convert("")
+		return v
+	}
+
+	return a.def
+}
+
+// convert converts a string to the argument's type, empty strings are
+// converted to the zero value.
+func (a *typedArg) convert(s string) (interface{}, error) {
+	switch a.typ {
+	case "bool":
+		if len(s) == 0 {
+			return false, nil
+		}
+
+		return strconv.ParseBool(s)
+	case "float":
+		if len(s) == 0 {
+			return float64(0), nil
+		}
+
+		return strconv.ParseFloat(s, 64)
+	case "int":
+		if len(s) == 0 {
+			return 0, nil
+		}
+
+		return strconv.Atoi(s)
+	default:
+		return s, nil
+	}
+}
+
+// ParseFlags parses the arguments given to a task as flags when the task,
+// or the global arguments, declares typed arguments. Typed arguments are
+// converted to their type and other arguments are strings, e.g
+// --port 8080 --dry-run --name max. Argument names with underscores are
+// written with dashes, e.g --dry-run for dry_run. Unknown flags and values
+// that can't be converted are errors that includes the task's flags.
+// The returned values only contains the flags that were given.
+func (t *Task) ParseFlags(global map[string]interface{}, args []string) (map[string]interface{}, error) {
+	fs, values := t.flagSet(global)
+	if fs == nil {
+		return nil, nil
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("max: task %s: %s\n\nFlags:\n%s", t.ID(), err, strings.TrimSuffix(fs.FlagUsages(), "\n"))
+	}
+
+	res := make(map[string]interface{})
+
+	for k, v := range values {
+		if fs.Changed(flagName(k)) {
+			res[k] = v()
+		}
+	}
+
+	return res, nil
+}
+
+// FlagNames returns the flag names of the task's arguments, empty when no
+// argument is typed.
+func (t *Task) FlagNames(global map[string]interface{}) []string {
+	fs, _ := t.flagSet(global)
+	if fs == nil {
+		return nil
+	}
+
+	var names []string
+
+	fs.VisitAll(func(f *pflag.Flag) {
+		names = append(names, f.Name)
+	})
+
+	return names
+}
+
+// flagSet returns a flag set for the task's arguments, nil when no argument
+// is typed. The returned functions returns the parsed flag values.
+func (t *Task) flagSet(global map[string]interface{}) (*pflag.FlagSet, map[string]func() interface{}) {
+	args := make(map[string]interface{}, len(t.Args)+len(global))
+	typed := false
+
+	for _, m := range []map[string]interface{}{t.Args, global} {
+		for k, v := range m {
+			if old, ok := args[k]; ok {
+				v = MergeValue(old, v)
+			}
+
+			args[k] = v
+		}
+	}
+
+	keys := make([]string, 0, len(args))
+	for k, v := range args {
+		if _, ok := parseTypedArg(v); ok {
+			typed = true
+		}
+
+		keys = append(keys, k)
+	}
+
+	if !typed {
+		return nil, nil
+	}
+
+	sort.Strings(keys)
+
+	fs := pflag.NewFlagSet(t.ID(), pflag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+
+	values := make(map[string]func() interface{}, len(keys))
+
+	for _, k := range keys {
+		name := flagName(k)
+		a, ok := parseTypedArg(args[k])
+
+		if !ok {
+			v := fs.String(name, fmt.Sprintf("%v", resolveArgs(map[string]interface{}{k: args[k]})[k]), "")
+			values[k] = func() interface{} { return *v }
+			continue
+		}
+
+		switch def := a.value(); a.typ {
+		case "bool":
+			b, _ := def.(bool)
+			v := fs.Bool(name, b, a.usage)
+			values[k] = func() interface{} { return *v }
+		case "float":
+			f, _ := toFloat(def)
+			v := fs.Float64(name, f, a.usage)
+			values[k] = func() interface{} { return *v }
+		case "int":
+			f, _ := toFloat(def)
+			v := fs.Int(name, int(f), a.usage)
+			values[k] = func() interface{} { return *v }
+		default:
+			v := fs.String(name, fmt.Sprintf("%v", def), a.usage)
+			values[k] = func() interface{} { return *v }
+		}
+	}
+
+	return fs, values
+}
+
+// flagName returns the flag name of a argument.
+func flagName(k string) string {
+	return strings.Replace(k, "_", "-", -1)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,55 @@
+package task
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseFlags(t *testing.T) {
+	os.Setenv("MAX_TEST_REPLICAS", "3")
+	defer os.Unsetenv("MAX_TEST_REPLICAS")
+
+	task := &Task{
+		Args: map[string]interface{}{
+			"dry_run":  map[interface{}]interface{}{"type": "bool", "usage": "print without deploying"},
+			"name":     "app",
+			"region":   map[interface{}]interface{}{"type": "string", "default": "us"},
+			"replicas": map[interface{}]interface{}{"type": "int", "env": "MAX_TEST_REPLICAS"},
+		},
+	}
+	task.ID("deploy")
+
+	got, err := task.ParseFlags(nil, []string{"--region", "eu", "--dry-run", "--name=web", "first"})
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	exp := map[string]interface{}{"dry_run": true, "name": "web", "region": "eu"}
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected: %v, got: %v", exp, got)
+	}
+
+	data, err := TemplateData(task.Args, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["replicas"] != 3 || data["region"] != "us" || data["dry_run"] != false {
+		t.Errorf("Expected: typed defaults, got: %v", data)
+	}
+
+	if _, err := task.ParseFlags(nil, []string{"--replicas", "many"}); err == nil || !strings.Contains(err.Error(), "--region string") {
+		t.Errorf("Expected: error with flags, got: %v", err)
+	}
+
+	if _, err := task.ParseFlags(nil, []string{"--bogus", "1"}); err == nil || !strings.Contains(err.Error(), "unknown flag: --bogus") {
+		t.Errorf("Expected: unknown flag error, got: %v", err)
+	}
+
+	task = &Task{Args: map[string]interface{}{"name": "app"}}
+
+	if got, err := task.ParseFlags(nil, []string{"--bogus", "1"}); err != nil || got != nil {
+		t.Errorf("Expected: untyped args to be ignored, got: %v, %v", got, err)
+	}
+}
@@ -0,0 +1,168 @@
+package task
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// startTime is the time returned by now, so all templates in a run, and a
+// dry run, renders the same time.
+var startTime = time.Now()
+
+// TemplateFuncs returns the functions available in templates.
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"add":       func(a, b interface{}) int64 { return toInt64(a) + toInt64(b) },
+		"arch":      func() string { return runtime.GOARCH },
+		"b64dec":    b64dec,
+		"b64enc":    func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"date":      date,
+		"default":   defaultValue,
+		"div":       div,
+		"join":      join,
+		"lower":     strings.ToLower,
+		"mod":       mod,
+		"mul":       func(a, b interface{}) int64 { return toInt64(a) * toInt64(b) },
+		"now":       now,
+		"os":        func() string { return runtime.GOOS },
+		"quote":     quote,
+		"replace":   func(old, new, s string) string { return strings.Replace(s, old, new, -1) },
+		"sha256sum": sha256sum,
+		"splitList": func(sep, s string) []string { return strings.Split(s, sep) },
+		"sub":       func(a, b interface{}) int64 { return toInt64(a) - toInt64(b) },
+		"trim":      strings.TrimSpace,
+		"upper":     strings.ToUpper,
+	}
+}
+
+// defaultValue returns the value or the default value when the value is
+// missing or empty, e.g {{ .port | default 8080 }}.
+func defaultValue(def, v interface{}) interface{} {
+	if isEmpty(v) {
+		return def
+	}
+
+	return v
+}
+
+func isEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	default:
+		return rv.IsZero()
+	}
+}
+
+// now returns the time the run started, or the SOURCE_DATE_EPOCH unix
+// time when it's set so builds can be reproduced.
+func now() time.Time {
+	if epoch, err := strconv.ParseInt(os.Getenv("SOURCE_DATE_EPOCH"), 10, 64); err == nil {
+		return time.Unix(epoch, 0).UTC()
+	}
+
+	return startTime
+}
+
+// date formats a time or unix time with a go time layout, e.g
+// {{ now | date "2006-01-02" }}.
+func date(layout string, v interface{}) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format(layout)
+	case *time.Time:
+		return t.Format(layout)
+	default:
+		return time.Unix(toInt64(v), 0).Format(layout)
+	}
+}
+
+// join joins a list with a separator, other values are formatted as is,
+// e.g {{ .hosts | join "," }}.
+func join(sep string, v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return fmt.Sprintf("%v", v)
+	}
+
+	parts := make([]string, rv.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprintf("%v", rv.Index(i).Interface())
+	}
+
+	return strings.Join(parts, sep)
+}
+
+// quote returns the values as double quoted strings separated by a space.
+func quote(v ...interface{}) string {
+	parts := make([]string, len(v))
+	for i, s := range v {
+		parts[i] = strconv.Quote(fmt.Sprintf("%v", s))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func b64dec(s string) (string, error) {
+	buf, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf), nil
+}
+
+func sha256sum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func div(a, b interface{}) (int64, error) {
+	if toInt64(b) == 0 {
+		return 0, fmt.Errorf("division by zero")
+	}
+
+	return toInt64(a) / toInt64(b), nil
+}
+
+func mod(a, b interface{}) (int64, error) {
+	if toInt64(b) == 0 {
+		return 0, fmt.Errorf("division by zero")
+	}
+
+	return toInt64(a) % toInt64(b), nil
+}
+
+// toInt64 converts numbers and numeric strings to a int64, other values are 0.
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int:
+		return int64(n)
+	case int64:
+		return n
+	case float64:
+		return int64(n)
+	case string:
+		i, _ := strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+		return i
+	default:
+		return 0
+	}
+}
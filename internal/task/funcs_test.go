@@ -0,0 +1,93 @@
+package task
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+)
+
+func TestDefaultFunc(t *testing.T) {
+	data := map[string]interface{}{"host": "db", "empty": ""}
+
+	tests := []struct {
+		tmpl string
+		exp  string
+	}{
+		{"{{ .port | default 8080 }}", "8080"},
+		{"{{ .host | default \"localhost\" }}", "db"},
+		{"{{ .empty | default \"none\" }}", "none"},
+		{"{{ default 1 .args.missing }}", "1"},
+	}
+
+	for _, test := range tests {
+		got, err := renderCommand(test.tmpl, templateData(data, nil), false)
+		if err != nil {
+			t.Fatalf("Expected: nil, got: %s", err)
+		}
+
+		if got != test.exp {
+			t.Errorf("Expected: %s, got: %s", test.exp, got)
+		}
+	}
+}
+
+func TestStrictTemplates(t *testing.T) {
+	if _, err := renderCommand("echo {{ .port }}", map[string]interface{}{}, true); err == nil || !strings.Contains(err.Error(), "port") {
+		t.Errorf("Expected: missing key error, got: %v", err)
+	}
+
+	got, err := renderCommand("echo {{ .port }}", map[string]interface{}{}, false)
+	if err != nil || got != "echo <no value>" {
+		t.Errorf("Expected: 'echo <no value>', got: %s, %v", got, err)
+	}
+
+	task := &Task{Commands: yaml2.NewList("echo {{ .port }}")}
+	task.Options(StrictTemplates(true))
+
+	if err := task.Prepare(); err == nil {
+		t.Errorf("Expected: missing key error, got: nil")
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	os.Setenv("SOURCE_DATE_EPOCH", "1577934245")
+	defer os.Unsetenv("SOURCE_DATE_EPOCH")
+
+	data := map[string]interface{}{
+		"hosts": []interface{}{"a", "b"},
+		"csv":   "x,y",
+		"port":  8080,
+	}
+
+	tests := []struct {
+		tmpl string
+		exp  string
+	}{
+		{`{{ .hosts | join "," }}`, "a,b"},
+		{`{{ .csv | splitList "," | join " " }}`, "x y"},
+		{`{{ quote .port "a b" }}`, `"8080" "a b"`},
+		{`{{ "max" | b64enc }}`, "bWF4"},
+		{`{{ "bWF4" | b64dec }}`, "max"},
+		{`{{ "max" | sha256sum }}`, "9baf3a40312f39849f46dad1040f2f039f1cffa1238c41e9db675315cfad39b6"},
+		{`{{ now | date "2006-01-02" }}`, "2020-01-02"},
+		{`{{ add .port 1 }} {{ sub 5 2 }} {{ mul 2 "3" }} {{ div 7 2 }} {{ mod 7 2 }}`, "8081 3 6 3 1"},
+		{`{{ " Max " | trim | upper }} {{ "MAX" | lower }} {{ "a-b" | replace "-" "_" }}`, "MAX max a_b"},
+	}
+
+	for _, test := range tests {
+		got, err := renderCommand(test.tmpl, templateData(nil, data), false)
+		if err != nil {
+			t.Fatalf("Expected: nil for %s, got: %s", test.tmpl, err)
+		}
+
+		if got != test.exp {
+			t.Errorf("Expected: %s, got: %s", test.exp, got)
+		}
+	}
+
+	if _, err := renderCommand("{{ div 1 0 }}", nil, false); err == nil {
+		t.Error("Expected: division by zero error, got: nil")
+	}
+}
@@ -0,0 +1,174 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/frozzare/max/internal/exec"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	refRegexp         = regexp.MustCompile(`\.([a-zA-Z_][a-zA-Z0-9_]*)`)
+	templateRegexp    = regexp.MustCompile(`{{[^}]*}}`)
+	variableRefRegexp = regexp.MustCompile(`\$\{?([a-zA-Z_][a-zA-Z0-9_]*)`)
+)
+
+// shellValues caches shell variable output by directory, shell, command,
+// environment and clean_env so a command is only run once when many tasks
+// references it with the same environment.
+var shellValues = struct {
+	sync.Mutex
+	values map[string]string
+}{values: make(map[string]string)}
+
+// ShellVariables returns the commands of variables declared as shell
+// commands, e.g git_sha: {sh: git rev-parse HEAD}.
+func (t *Task) ShellVariables() map[string]string {
+	res := make(map[string]string)
+
+	for k, v := range t.Variables {
+		if command, ok := shellVar(v); ok {
+			res[k] = command
+		}
+	}
+
+	return res
+}
+
+// ResolveShellVariables runs the commands of shell variables that are
+// referenced by the task and stores their trimmed output as variables.
+// Shell variables that are not referenced are removed without running the command.
+func (t *Task) ResolveShellVariables(ctx context.Context, stderr io.Writer) error {
+	commands := t.ShellVariables()
+	if len(commands) == 0 {
+		return nil
+	}
+
+	// Remove shell variables before looking for references so the
+	// commands don't count as references.
+	for k := range commands {
+		delete(t.Variables, k)
+	}
+
+	refs, err := t.references()
+	if err != nil {
+		return err
+	}
+
+	keys := make([]string, 0, len(commands))
+	for k := range commands {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		if !refs[k] {
+			continue
+		}
+
+		env := t.Env()
+
+		data, err := TemplateData(t.Args, t.Variables)
+		if err != nil {
+			return err
+		}
+
+		command, err := renderCommand(renderEnvVariables(commands[k], env), data, t.strictTemplates)
+		if err != nil {
+			return err
+		}
+
+		value, err := t.shellValue(ctx, command, env, stderr)
+		if err != nil {
+			return fmt.Errorf("max: variable %s failed: %s", k, err)
+		}
+
+		t.Variables[k] = value
+	}
+
+	return nil
+}
+
+func (t *Task) shellValue(ctx context.Context, command string, env map[string]string, stderr io.Writer) (string, error) {
+	envs := toEnv(env)
+	sort.Strings(envs)
+
+	key := strings.Join(append([]string{t.Dir, t.Shell, command, fmt.Sprint(t.CleanEnvEnabled())}, envs...), "\x00")
+
+	shellValues.Lock()
+	defer shellValues.Unlock()
+
+	if v, ok := shellValues.values[key]; ok {
+		return v, nil
+	}
+
+	limit, err := t.OutputLimit()
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+
+	err = exec.Exec(&exec.Options{
+		Context:  ctx,
+		CleanEnv: t.CleanEnvEnabled(),
+		Dir:      t.Dir,
+		Env:      envs,
+		Command:  command,
+		Shell:    t.Shell,
+		Stdout:   truncateWriter(&buf, limit),
+		Stderr:   stderr,
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	v := strings.TrimSpace(buf.String())
+	shellValues.values[key] = v
+
+	return v, nil
+}
+
+// references returns all template and environment variable names referenced by the task.
+func (t *Task) references() (map[string]bool, error) {
+	buf, err := yaml.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string]bool)
+
+	for _, tmpl := range templateRegexp.FindAll(buf, -1) {
+		for _, m := range refRegexp.FindAllSubmatch(tmpl, -1) {
+			refs[string(m[1])] = true
+		}
+	}
+
+	for _, m := range variableRefRegexp.FindAllSubmatch(buf, -1) {
+		refs[string(m[1])] = true
+	}
+
+	return refs, nil
+}
+
+// shellVar reports if the value is a shell variable declaration, a map
+// with a single sh key, and returns the command.
+func shellVar(v interface{}) (string, bool) {
+	m, ok := toStringMap(v)
+	if !ok || len(m) != 1 {
+		return "", false
+	}
+
+	command, ok := m["sh"].(string)
+
+	return command, ok && len(command) > 0
+}
@@ -0,0 +1,107 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+)
+
+func TestResolveShellVariables(t *testing.T) {
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	count := filepath.Join(dir, "count")
+
+	newTask := func() *Task {
+		return &Task{
+			Commands: yaml2.NewList("echo {{ .sha }} $TAG"),
+			Dir:      dir,
+			Variables: map[string]interface{}{
+				"sha":    map[interface{}]interface{}{"sh": "echo run >> " + count + " && echo abc"},
+				"TAG":    "v-{{ .sha }}",
+				"unused": map[interface{}]interface{}{"sh": "exit 1"},
+				"map":    map[interface{}]interface{}{"sh": "not a command", "other": "value"},
+			},
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		task := newTask()
+
+		if err := task.ResolveShellVariables(context.Background(), &bytes.Buffer{}); err != nil {
+			t.Fatalf("Expected: nil, got: %s", err)
+		}
+
+		if task.Variables["sha"] != "abc" {
+			t.Errorf("Expected: 'abc', got: %v", task.Variables["sha"])
+		}
+
+		if _, ok := task.Variables["unused"]; ok {
+			t.Errorf("Expected: unused shell variable to be removed")
+		}
+
+		if _, ok := task.Variables["map"]; !ok {
+			t.Errorf("Expected: map variable to be kept")
+		}
+	}
+
+	buf, _ := ioutil.ReadFile(count)
+	if strings.Count(string(buf), "run") != 1 {
+		t.Errorf("Expected: command to run once, got: %q", string(buf))
+	}
+
+	// The same command runs again with another environment or clean_env.
+	os.Setenv("MAX_LAZY_OUTER", "outer")
+	defer os.Unsetenv("MAX_LAZY_OUTER")
+
+	clean := true
+
+	for _, test := range []struct {
+		name     string
+		cleanEnv *bool
+		exp      string
+	}{
+		{"a", nil, "a outer"},
+		{"b", nil, "b outer"},
+		{"b", &clean, "b"},
+	} {
+		task := &Task{
+			CleanEnv: test.cleanEnv,
+			Commands: yaml2.NewList("echo $value"),
+			Dir:      dir,
+			Variables: map[string]interface{}{
+				"NAME":  test.name,
+				"value": map[interface{}]interface{}{"sh": "echo ${NAME} ${MAX_LAZY_OUTER}"},
+			},
+		}
+
+		if err := task.ResolveShellVariables(context.Background(), &bytes.Buffer{}); err != nil {
+			t.Fatalf("Expected: nil, got: %s", err)
+		}
+
+		if got := task.Variables["value"]; got != test.exp {
+			t.Errorf("Expected: %q, got: %v", test.exp, got)
+		}
+	}
+
+	task := &Task{
+		Commands:  yaml2.NewList("echo $FAIL"),
+		Dir:       dir,
+		Variables: map[string]interface{}{"FAIL": map[interface{}]interface{}{"sh": "exit 3"}},
+	}
+
+	err = task.ResolveShellVariables(context.Background(), &bytes.Buffer{})
+	if err == nil || !strings.HasPrefix(err.Error(), "max: variable FAIL failed:") {
+		t.Errorf("Expected: variable error, got: %v", err)
+	}
+}
@@ -0,0 +1,99 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const maxLockBackoff = time.Second
+
+// ErrLocked is returned when a file lock is held by another process.
+var ErrLocked = errors.New("max: file is locked")
+
+// Lock represents a file lock that is held while the task runs, e.g to
+// prevent two max processes from deploying at the same time. Without a
+// timeout the task waits until the lock is released.
+type Lock struct {
+	Path    string `yaml:"path"`
+	Timeout string `yaml:"timeout"`
+}
+
+// UnmarshalYAML implements yaml packages interface to unmarshal custom values,
+// a lock can be a path or a map with path and timeout.
+func (l *Lock) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var path string
+	if err := unmarshal(&path); err == nil {
+		l.Path = path
+		return nil
+	}
+
+	type plain Lock
+
+	return unmarshal((*plain)(l))
+}
+
+// Acquire acquires the exclusive file lock, relative paths are relative to
+// the directory. The returned function releases the lock.
+func (l *Lock) Acquire(ctx context.Context, dir string) (func(), error) {
+	if len(l.Path) == 0 {
+		return nil, errors.New("max: lock requires a path")
+	}
+
+	path := l.Path
+	if !filepath.IsAbs(path) && len(dir) > 0 {
+		path = filepath.Join(dir, path)
+	}
+
+	if len(l.Timeout) > 0 {
+		d, err := ParseDuration(l.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("max: bad lock timeout %s", l.Timeout)
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	backoff := 50 * time.Millisecond
+
+	for {
+		err := lockFile(f)
+		if err == nil {
+			return func() {
+				unlockFile(f)
+				f.Close()
+			}, nil
+		}
+
+		if err != ErrLocked {
+			f.Close()
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			f.Close()
+
+			if ctx.Err() == context.DeadlineExceeded {
+				return nil, fmt.Errorf("max: timeout waiting for lock %s after %s", path, l.Timeout)
+			}
+
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > maxLockBackoff {
+			backoff = maxLockBackoff
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+
+package task
+
+import (
+	"errors"
+	"os"
+)
+
+const locksSupported = false
+
+func lockFile(f *os.File) error {
+	return errors.New("max: file locks are not supported on this platform")
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
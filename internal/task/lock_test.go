@@ -0,0 +1,73 @@
+package task
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestLockUnmarshal(t *testing.T) {
+	var task *Task
+
+	if err := yaml.Unmarshal([]byte("lock: deploy.lock"), &task); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if task.Lock.Path != "deploy.lock" {
+		t.Errorf("Expected: deploy.lock, got: %s", task.Lock.Path)
+	}
+
+	if err := yaml.Unmarshal([]byte("lock: {path: deploy.lock, timeout: 5m}"), &task); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if task.Lock.Path != "deploy.lock" || task.Lock.Timeout != "5m" {
+		t.Errorf("Expected: deploy.lock and 5m, got: %+v", task.Lock)
+	}
+
+	if err := yaml.Unmarshal([]byte("lock: {path: deploy.lock, timeout: soon}"), &task); err == nil {
+		t.Error("Expected: bad timeout error, got: nil")
+	}
+}
+
+func TestLockAcquire(t *testing.T) {
+	if !locksSupported {
+		t.Skip("file locks are not supported on this platform")
+	}
+
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	l := &Lock{Path: "deploy.lock", Timeout: "200ms"}
+
+	unlock, err := l.Acquire(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "deploy.lock")); err != nil {
+		t.Errorf("Expected: lock file, got: %s", err)
+	}
+
+	if _, err := l.Acquire(context.Background(), dir); err == nil || !strings.Contains(err.Error(), "timeout waiting for lock") {
+		t.Errorf("Expected: timeout error, got: %v", err)
+	}
+
+	unlock()
+
+	unlock, err = l.Acquire(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	unlock()
+}
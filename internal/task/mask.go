@@ -0,0 +1,32 @@
+package task
+
+import (
+	"regexp"
+	"strings"
+)
+
+// secretRegexp matches variable names that are masked when tasks are printed.
+var secretRegexp = regexp.MustCompile(`(?i)(secret|token|password|passwd|credential|auth|key)`)
+
+// Mask masks values of variables that looks like secrets.
+func Mask(k, v string) string {
+	if len(v) > 0 && secretRegexp.MatchString(k) {
+		return "****"
+	}
+
+	return v
+}
+
+// MaskSecrets masks values of the task's variables that looks like secrets
+// in s, e.g in interpolated commands.
+func (t *Task) MaskSecrets(s string) string {
+	var secrets []string
+
+	for k, v := range t.Env() {
+		if Mask(k, v) != v {
+			secrets = append(secrets, v, "****")
+		}
+	}
+
+	return strings.NewReplacer(secrets...).Replace(s)
+}
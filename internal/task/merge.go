@@ -0,0 +1,47 @@
+package task
+
+import "fmt"
+
+// MergeValue merges src into dst when both are maps, otherwise src overrides dst.
+func MergeValue(dst, src interface{}) interface{} {
+	d, ok := toStringMap(dst)
+	if !ok {
+		return src
+	}
+
+	s, ok := toStringMap(src)
+	if !ok {
+		return src
+	}
+
+	res := make(map[string]interface{}, len(d)+len(s))
+
+	for k, v := range d {
+		res[k] = v
+	}
+
+	for k, v := range s {
+		if old, ok := res[k]; ok {
+			res[k] = MergeValue(old, v)
+		} else {
+			res[k] = v
+		}
+	}
+
+	return res
+}
+
+func toStringMap(v interface{}) (map[string]interface{}, bool) {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m, true
+	case map[interface{}]interface{}:
+		res := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			res[fmt.Sprintf("%v", k)] = v
+		}
+		return res, true
+	default:
+		return nil, false
+	}
+}
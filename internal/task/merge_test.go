@@ -0,0 +1,70 @@
+package task
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeValue(t *testing.T) {
+	dst := map[interface{}]interface{}{
+		"image": map[interface{}]interface{}{
+			"name": "app",
+			"tag":  "latest",
+			"build": map[interface{}]interface{}{
+				"target": "dev",
+				"cache":  true,
+			},
+		},
+	}
+
+	src := map[string]interface{}{
+		"image": map[string]interface{}{
+			"tag": "1.0",
+			"build": map[string]interface{}{
+				"target": "prod",
+			},
+		},
+	}
+
+	exp := map[string]interface{}{
+		"image": map[string]interface{}{
+			"name": "app",
+			"tag":  "1.0",
+			"build": map[string]interface{}{
+				"target": "prod",
+				"cache":  true,
+			},
+		},
+	}
+
+	if v := MergeValue(dst, src); !reflect.DeepEqual(v, exp) {
+		t.Fatalf("Expected merged maps, got: %v", v)
+	}
+
+	if v := MergeValue(dst, "scalar"); v != "scalar" {
+		t.Fatalf("Expected scalar to override map, got: %v", v)
+	}
+}
+
+func TestArgsMerge(t *testing.T) {
+	task := &Task{
+		Args: map[string]interface{}{
+			"db": map[interface{}]interface{}{
+				"host": "localhost",
+				"port": 5432,
+			},
+		},
+	}
+
+	task.Options(Args(map[string]interface{}{
+		"db": map[interface{}]interface{}{
+			"host": "db",
+		},
+	}))
+
+	db := task.Args["db"].(map[string]interface{})
+
+	if db["host"] != "db" || db["port"] != 5432 {
+		t.Fatalf("Expected db args to be merged, got: %v", db)
+	}
+}
@@ -15,7 +15,11 @@ func Args(args map[string]interface{}) Option {
 		}
 
 		for k, v := range args {
-			t.Args[k] = v
+			if old, ok := t.Args[k]; ok {
+				t.Args[k] = MergeValue(old, v)
+			} else {
+				t.Args[k] = v
+			}
 		}
 	}
 }
@@ -27,15 +31,34 @@ func Log(log *log.Logger) Option {
 	}
 }
 
+// Resolvers returns an option configured with variable resolvers by scheme.
+func Resolvers(resolvers map[string]VarResolver) Option {
+	return func(t *Task) {
+		t.resolvers = resolvers
+	}
+}
+
 // Variables returns an option configured with a variables value.
-func Variables(vars map[string]string) Option {
+func Variables(vars map[string]interface{}) Option {
 	return func(t *Task) {
 		if t.Variables == nil {
-			t.Variables = make(map[string]string)
+			t.Variables = make(map[string]interface{})
 		}
 
 		for k, v := range vars {
-			t.Variables[k] = v
+			if old, ok := t.Variables[k]; ok {
+				t.Variables[k] = MergeValue(old, v)
+			} else {
+				t.Variables[k] = v
+			}
 		}
 	}
 }
+
+// StrictTemplates returns an option configured with a strict templates value,
+// missing keys in templates are errors instead of "<no value>".
+func StrictTemplates(strict bool) Option {
+	return func(t *Task) {
+		t.strictTemplates = strict
+	}
+}
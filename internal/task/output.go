@@ -0,0 +1,149 @@
+package task
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// truncatedNotice is written when output is truncated.
+const truncatedNotice = "\n[output truncated]\n"
+
+var sizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize parses a size with a optional unit, e.g 512, 100KB or 10MB.
+func ParseSize(s string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(s))
+	unit := int64(1)
+
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(v, u.suffix) {
+			v, unit = strings.TrimSpace(strings.TrimSuffix(v, u.suffix)), u.size
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("max: bad size %s", s)
+	}
+
+	return int64(n * float64(unit)), nil
+}
+
+// FormatSize formats a size in bytes with the largest unit it fills,
+// e.g 512B, 1.5KB or 10MB.
+func FormatSize(n int64) string {
+	for _, u := range sizeUnits {
+		if n >= u.size && u.size > 1 {
+			v := strconv.FormatFloat(float64(n)/float64(u.size), 'f', 1, 64)
+			return strings.TrimSuffix(v, ".0") + u.suffix
+		}
+	}
+
+	return fmt.Sprintf("%dB", n)
+}
+
+// OutputLimit returns the max output size in bytes, zero means no limit.
+func (t *Task) OutputLimit() (int64, error) {
+	if len(t.MaxOutput) == 0 {
+		return 0, nil
+	}
+
+	n, err := ParseSize(t.MaxOutput)
+	if err != nil {
+		return 0, fmt.Errorf("max: bad max output %s", t.MaxOutput)
+	}
+
+	return n, nil
+}
+
+// LimitWriter returns a writer that writes up to n bytes to w and a
+// truncated notice when the limit is exceeded. Writes beyond the limit
+// are discarded so commands can run to completion. Writers with a limit
+// of zero or less are returned as is.
+func LimitWriter(w io.Writer, n int64) io.Writer {
+	if n <= 0 {
+		return w
+	}
+
+	return &limitWriter{w: w, budget: &outputBudget{n: n}, notice: true}
+}
+
+// LimitWriters returns writers like LimitWriter that shares the limit, e.g
+// so a task's stdout and stderr together writes up to n bytes. The notice is
+// written to the writer that exceeds the limit.
+func LimitWriters(stdout, stderr io.Writer, n int64) (io.Writer, io.Writer) {
+	if n <= 0 {
+		return stdout, stderr
+	}
+
+	budget := &outputBudget{n: n}
+
+	return &limitWriter{w: stdout, budget: budget, notice: true}, &limitWriter{w: stderr, budget: budget, notice: true}
+}
+
+// truncateWriter returns a writer like LimitWriter without the truncated
+// notice, e.g for captured output that is stored in variables.
+func truncateWriter(w io.Writer, n int64) io.Writer {
+	if n <= 0 {
+		return w
+	}
+
+	return &limitWriter{w: w, budget: &outputBudget{n: n}}
+}
+
+// outputBudget is the bytes left to write by the writers that shares it.
+type outputBudget struct {
+	n         int64
+	truncated bool
+
+	sync.Mutex
+}
+
+type limitWriter struct {
+	w      io.Writer
+	budget *outputBudget
+	notice bool
+}
+
+// Write writes p to the underlying writer until the limit is reached.
+func (l *limitWriter) Write(p []byte) (int, error) {
+	b := l.budget
+
+	b.Lock()
+	defer b.Unlock()
+
+	if b.truncated {
+		return len(p), nil
+	}
+
+	if int64(len(p)) <= b.n {
+		b.n -= int64(len(p))
+		return l.w.Write(p)
+	}
+
+	if _, err := l.w.Write(p[:b.n]); err != nil {
+		return 0, err
+	}
+
+	b.n, b.truncated = 0, true
+
+	if l.notice {
+		if _, err := io.WriteString(l.w, truncatedNotice); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
@@ -0,0 +1,102 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	for s, exp := range map[string]int64{
+		"512":   512,
+		"100B":  100,
+		"2kb":   2048,
+		"10MB":  10 << 20,
+		"1.5GB": 3 << 29,
+	} {
+		n, err := ParseSize(s)
+		if err != nil {
+			t.Errorf("Expected: nil for %s, got: %s", s, err)
+		}
+
+		if n != exp {
+			t.Errorf("Expected: %d for %s, got: %d", exp, s, n)
+		}
+	}
+
+	for _, s := range []string{"", "MB", "ten", "-1KB"} {
+		if _, err := ParseSize(s); err == nil {
+			t.Errorf("Expected: error for %q, got: nil", s)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for n, exp := range map[int64]string{
+		0:         "0B",
+		512:       "512B",
+		1536:      "1.5KB",
+		10 << 20:  "10MB",
+		3 << 30:   "3GB",
+		1<<20 + 1: "1MB",
+	} {
+		if s := FormatSize(n); s != exp {
+			t.Errorf("Expected: %s for %d, got: %s", exp, n, s)
+		}
+	}
+}
+
+func TestLimitWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := LimitWriter(&buf, 5)
+
+	for _, s := range []string{"abc", "defgh", "ijk"} {
+		if n, err := fmt.Fprint(w, s); err != nil || n != len(s) {
+			t.Errorf("Expected: %d bytes written, got: %d, %v", len(s), n, err)
+		}
+	}
+
+	if got := buf.String(); got != "abcde"+truncatedNotice {
+		t.Errorf("Expected: truncated output, got: %q", got)
+	}
+
+	if w := LimitWriter(&buf, 0); w != &buf {
+		t.Error("Expected: writer without limit to be returned as is")
+	}
+}
+
+func TestLimitWriters(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	out, err := LimitWriters(&stdout, &stderr, 5)
+
+	fmt.Fprint(out, "abc")
+	fmt.Fprint(err, "defgh")
+	fmt.Fprint(out, "ijk")
+
+	if got := stdout.String(); got != "abc" {
+		t.Errorf("Expected: 'abc', got: %q", got)
+	}
+
+	if got := stderr.String(); got != "de"+truncatedNotice {
+		t.Errorf("Expected: truncated stderr, got: %q", got)
+	}
+}
+
+func TestCaptureMaxOutput(t *testing.T) {
+	task := &Task{
+		Capture:   map[string]string{"out": "echo 0123456789"},
+		MaxOutput: "4B",
+	}
+
+	vars, err := task.CaptureVariables(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if vars["out"] != "0123" {
+		t.Errorf("Expected: 0123, got: %v", vars["out"])
+	}
+}
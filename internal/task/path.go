@@ -0,0 +1,60 @@
+package task
+
+import (
+	"fmt"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/go-homedir"
+)
+
+// ExpandPath expands a leading ~ to the current user's home directory
+// and ~user to the user's home directory, other paths are returned as is.
+func ExpandPath(path string) (string, error) {
+	if !strings.HasPrefix(path, "~") {
+		return path, nil
+	}
+
+	name := path[1:]
+	rest := ""
+
+	if i := strings.IndexAny(name, `/\`); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+
+	if len(name) == 0 {
+		return homedir.Expand(path)
+	}
+
+	u, err := user.Lookup(name)
+	if err != nil {
+		return "", fmt.Errorf("max: can't expand %s: %s", path, err)
+	}
+
+	return filepath.Join(u.HomeDir, rest), nil
+}
+
+// ExpandPaths expands ~ in the task's directory and env files.
+func (t *Task) ExpandPaths() error {
+	dir, err := ExpandPath(t.Dir)
+	if err != nil {
+		return err
+	}
+
+	t.Dir = dir
+
+	if t.Lock != nil {
+		if t.Lock.Path, err = ExpandPath(t.Lock.Path); err != nil {
+			return err
+		}
+	}
+
+	for i, path := range t.EnvFile.Values {
+		if t.EnvFile.Values[i], err = ExpandPath(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package task
+
+import (
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+	"github.com/mitchellh/go-homedir"
+)
+
+func TestExpandPath(t *testing.T) {
+	home := os.Getenv("HOME")
+	homedir.DisableCache = true
+	os.Setenv("HOME", "/home/max")
+
+	defer func() {
+		os.Setenv("HOME", home)
+		homedir.DisableCache = false
+	}()
+
+	u, err := user.Current()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, exp := range map[string]string{
+		"":                      "",
+		"~":                     "/home/max",
+		"~/project":             "/home/max/project",
+		"./project":             "./project",
+		"/tmp/~":                "/tmp/~",
+		"~" + u.Username:        u.HomeDir,
+		"~" + u.Username + "/x": filepath.Join(u.HomeDir, "x"),
+	} {
+		got, err := ExpandPath(path)
+		if err != nil {
+			t.Errorf("Expected: nil for %s, got: %s", path, err)
+		}
+
+		if got != exp {
+			t.Errorf("Expected: %s for %s, got: %s", exp, path, got)
+		}
+	}
+
+	if _, err := ExpandPath("~max-missing-user/project"); err == nil {
+		t.Error("Expected: error for missing user, got: nil")
+	}
+}
+
+func TestExpandPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "max")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	home := os.Getenv("HOME")
+	homedir.DisableCache = true
+	os.Setenv("HOME", dir)
+
+	defer func() {
+		os.Setenv("HOME", home)
+		homedir.DisableCache = false
+	}()
+
+	if err := ioutil.WriteFile(filepath.Join(dir, ".env"), []byte("NAME=max\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	task := &Task{Dir: "~", EnvFile: yaml2.NewList("~/.env")}
+
+	if err := task.ExpandPaths(); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if task.Dir != dir {
+		t.Errorf("Expected: %s, got: %s", dir, task.Dir)
+	}
+
+	if err := task.LoadEnvFiles(); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if task.Variables["NAME"] != "max" {
+		t.Errorf("Expected: max, got: %v", task.Variables["NAME"])
+	}
+}
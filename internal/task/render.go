@@ -2,6 +2,7 @@ package task
 
 import (
 	"bytes"
+	"fmt"
 	"reflect"
 	"regexp"
 	"strings"
@@ -33,8 +34,16 @@ func renderEnvVariables(v string, variables map[string]string) string {
 	return v
 }
 
-func renderCommand(c string, args map[string]interface{}) (string, error) {
-	tmpl, err := template.New("main").Parse(c)
+// renderCommand renders a go template string, missing keys are errors in
+// strict mode and are rendered as "<no value>" or handled with the default function otherwise.
+func renderCommand(c string, args map[string]interface{}, strict bool) (string, error) {
+	tmpl := template.New("main").Funcs(TemplateFuncs())
+
+	if strict {
+		tmpl = tmpl.Option("missingkey=error")
+	}
+
+	tmpl, err := tmpl.Parse(c)
 	if err != nil {
 		return "", err
 	}
@@ -47,7 +56,77 @@ func renderCommand(c string, args map[string]interface{}) (string, error) {
 	return buf.String(), nil
 }
 
-func renderStruct(s interface{}, args map[string]interface{}, vars map[string]string) (interface{}, error) {
+func renderVariables(vars map[string]interface{}, args map[string]interface{}, strict bool) (map[string]interface{}, error) {
+	res := make(map[string]interface{}, len(vars))
+	env := StringVariables(vars)
+
+	for k, v := range vars {
+		key, err := renderCommand(renderEnvVariables(k, env), args, strict)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only string values are rendered, other types are kept as is.
+		val := v
+		if s, ok := v.(string); ok {
+			val, err = renderCommand(renderEnvVariables(s, env), args, strict)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if _, ok := res[key]; ok {
+			return nil, fmt.Errorf("max: duplicate variable %s after interpolation", key)
+		}
+
+		res[key] = val
+	}
+
+	return res, nil
+}
+
+// TemplateData returns the data used to render templates. Arguments are
+// available under .args and variables under .vars, e.g {{ .args.name }}.
+// Both are also available at the top level where arguments takes
+// precedence over variables with the same name, e.g {{ .name }}.
+// Arguments bound to environment variables are resolved and arguments
+// with templates are rendered, cycles between arguments and arguments
+// that can't be rendered are errors.
+func TemplateData(args, vars map[string]interface{}) (map[string]interface{}, error) {
+	rendered, err := renderArgs(args, vars, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return templateData(rendered, vars), nil
+}
+
+func templateData(args, vars map[string]interface{}) map[string]interface{} {
+	data := make(map[string]interface{}, len(args)+len(vars)+2)
+
+	for k, v := range vars {
+		data[k] = v
+	}
+
+	for k, v := range args {
+		data[k] = v
+	}
+
+	if args == nil {
+		args = make(map[string]interface{})
+	}
+
+	if vars == nil {
+		vars = make(map[string]interface{})
+	}
+
+	data["args"] = args
+	data["vars"] = vars
+
+	return data
+}
+
+func renderStruct(s interface{}, args map[string]interface{}, vars map[string]string, strict bool) (interface{}, error) {
 	fs, err := structs.Fields(s)
 	if err != nil {
 		return nil, err
@@ -62,7 +141,7 @@ func renderStruct(s interface{}, args map[string]interface{}, vars map[string]st
 
 		switch v := f.Value().(type) {
 		case string:
-			v, err = renderCommand(renderEnvVariables(v, vars), args)
+			v, err = renderCommand(renderEnvVariables(v, vars), args, strict)
 			if err != nil {
 				return nil, err
 			}
@@ -74,7 +153,7 @@ func renderStruct(s interface{}, args map[string]interface{}, vars map[string]st
 			skipStruct = true
 
 			for i, k := range v.Values {
-				k, err = renderCommand(renderEnvVariables(k, vars), args)
+				k, err = renderCommand(renderEnvVariables(k, vars), args, strict)
 				if err != nil {
 					return nil, err
 				}
@@ -84,9 +163,11 @@ func renderStruct(s interface{}, args map[string]interface{}, vars map[string]st
 			if err := f.Set(v); err != nil {
 				return nil, err
 			}
+		case *bool, *int:
+			skipStruct = true
 		case []string:
 			for i, k := range v {
-				k, err = renderCommand(renderEnvVariables(k, vars), args)
+				k, err = renderCommand(renderEnvVariables(k, vars), args, strict)
 				if err != nil {
 					return nil, err
 				}
@@ -99,7 +180,7 @@ func renderStruct(s interface{}, args map[string]interface{}, vars map[string]st
 		}
 
 		if !skipStruct && (f.Kind() == reflect.Struct || f.Kind() == reflect.Ptr) {
-			v, err := renderStruct(f.Value(), args, vars)
+			v, err := renderStruct(f.Value(), args, vars, strict)
 			if err != nil {
 				return nil, err
 			}
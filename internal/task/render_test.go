@@ -41,7 +41,7 @@ func TestRenderEnvVariables(t *testing.T) {
 func TestRenderCommand(t *testing.T) {
 	c, err := renderCommand("echo Hello {{ .name }}", map[string]interface{}{
 		"name": "Fredrik",
-	})
+	}, false)
 
 	if err != nil {
 		t.Fatal("Expected error to be nil")
@@ -65,12 +65,12 @@ func TestRenderStruct(t *testing.T) {
 			},
 			Image: "$NAME",
 		},
-		Variables: map[string]string{
+		Variables: map[string]interface{}{
 			"NAME": "Fredrik",
 		},
 	}
 
-	v, err := renderStruct(task, task.Args, task.Variables)
+	v, err := renderStruct(task, task.Args, task.Env(), false)
 
 	if err != nil {
 		t.Fatal("Expected error to be nil")
@@ -90,3 +90,31 @@ func TestRenderStruct(t *testing.T) {
 		t.Fatal("Expected image value to be 'Fredrik'")
 	}
 }
+
+func TestRenderVariables(t *testing.T) {
+	vars, err := renderVariables(map[string]interface{}{
+		"{{ .prefix }}_TOKEN": "{{ .token }}",
+	}, map[string]interface{}{
+		"prefix": "APP",
+		"token":  "secret",
+	}, false)
+
+	if err != nil {
+		t.Fatalf("Expected error to be nil, got: %s", err)
+	}
+
+	if vars["APP_TOKEN"] != "secret" {
+		t.Fatalf("Expected APP_TOKEN to be 'secret', got: %s", vars["APP_TOKEN"])
+	}
+
+	_, err = renderVariables(map[string]interface{}{
+		"APP_TOKEN":           "a",
+		"{{ .prefix }}_TOKEN": "b",
+	}, map[string]interface{}{
+		"prefix": "APP",
+	}, false)
+
+	if err == nil {
+		t.Fatal("Expected duplicate variable error")
+	}
+}
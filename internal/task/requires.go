@@ -0,0 +1,71 @@
+package task
+
+import (
+	"errors"
+	osexec "os/exec"
+	"strings"
+)
+
+// ErrEmptyRequirement is returned when a required binary is empty, e.g
+// when a template renders to a empty string.
+var ErrEmptyRequirement = errors.New("max: task requires a empty binary name")
+
+// Requirements returns the task's required binaries rendered with the task's
+// args and variables, e.g {{ if eq os "windows" }}python{{ else }}python3{{ end }}.
+// The task don't have to be prepared so requirements can be checked before
+// deps and capture commands runs, variables from resolvers and capture
+// commands are not available.
+func (t *Task) Requirements() ([]string, error) {
+	if len(t.Requires.Values) == 0 {
+		return nil, nil
+	}
+
+	args, err := renderArgs(t.Args, t.Variables, t.strictTemplates)
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := renderVariables(t.Variables, templateData(args, t.Variables), t.strictTemplates)
+	if err != nil {
+		return nil, err
+	}
+
+	data := templateData(args, vars)
+	env := StringVariables(vars)
+	names := make([]string, 0, len(t.Requires.Values))
+
+	for _, name := range t.Requires.Values {
+		name, err := renderCommand(renderEnvVariables(name, env), data, t.strictTemplates)
+		if err != nil {
+			return nil, err
+		}
+
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			return nil, ErrEmptyRequirement
+		}
+
+		names = append(names, name)
+	}
+
+	return names, nil
+}
+
+// MissingRequirements returns the task's required binaries that are not
+// found on PATH.
+func (t *Task) MissingRequirements() ([]string, error) {
+	names, err := t.Requirements()
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+
+	for _, name := range names {
+		if _, err := osexec.LookPath(name); err != nil {
+			missing = append(missing, name)
+		}
+	}
+
+	return missing, nil
+}
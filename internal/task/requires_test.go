@@ -0,0 +1,30 @@
+package task
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+)
+
+func TestMissingRequirements(t *testing.T) {
+	task := &Task{
+		Requires: yaml2.NewList([]string{"{{ if eq os \"none\" }}sh{{ else }}{{ .bin }}{{ end }}", "max-missing-binary"}),
+		Args:     map[string]interface{}{"bin": "sh"},
+	}
+
+	got, err := task.MissingRequirements()
+	if err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if !reflect.DeepEqual(got, []string{"max-missing-binary"}) {
+		t.Errorf("Expected: [max-missing-binary], got: %v", got)
+	}
+
+	task = &Task{Requires: yaml2.NewList([]string{"{{ .bin }}"}), Args: map[string]interface{}{"bin": ""}}
+
+	if _, err := task.MissingRequirements(); err != ErrEmptyRequirement {
+		t.Errorf("Expected: %s, got: %v", ErrEmptyRequirement, err)
+	}
+}
@@ -0,0 +1,48 @@
+package task
+
+import (
+	"fmt"
+	"strings"
+)
+
+// VarResolver resolves variable values from other sources, e.g a keychain,
+// vault or aws ssm. Resolvers are dispatched by the scheme prefix of variable
+// values, e.g vault:secret/db#password is resolved by the vault resolver
+// with secret/db#password as ref.
+type VarResolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// resolveVariables resolves string variable values with the scheme prefix
+// of a resolver, values with unknown schemes are literals.
+func resolveVariables(vars map[string]interface{}, resolvers map[string]VarResolver) error {
+	if len(resolvers) == 0 {
+		return nil
+	}
+
+	for k, v := range vars {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		i := strings.Index(s, ":")
+		if i < 1 {
+			continue
+		}
+
+		r, ok := resolvers[s[:i]]
+		if !ok {
+			continue
+		}
+
+		value, err := r.Resolve(s[i+1:])
+		if err != nil {
+			return fmt.Errorf("max: can't resolve variable %s with %s: %s", k, s[:i], err)
+		}
+
+		vars[k] = value
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package task
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+)
+
+type testResolver map[string]string
+
+func (r testResolver) Resolve(ref string) (string, error) {
+	if v, ok := r[ref]; ok {
+		return v, nil
+	}
+
+	return "", errors.New("not found")
+}
+
+func TestPrepareResolvers(t *testing.T) {
+	task := &Task{
+		Commands: yaml2.NewList("echo $DB_PASSWORD"),
+		Variables: map[string]interface{}{
+			"DB_PASSWORD": "vault:secret/{{ .name }}",
+			"URL":         "http://example.com",
+			"PORT":        8080,
+		},
+	}
+
+	task.Options(Args(map[string]interface{}{"name": "db"}), Resolvers(map[string]VarResolver{"vault": testResolver{"secret/db": "hunter2"}}))
+
+	if err := task.Prepare(); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	env := task.Env()
+
+	if env["DB_PASSWORD"] != "hunter2" {
+		t.Errorf("Expected: hunter2, got: %s", env["DB_PASSWORD"])
+	}
+
+	if env["URL"] != "http://example.com" {
+		t.Errorf("Expected: http://example.com, got: %s", env["URL"])
+	}
+
+	// Resolved values are used in templates and commands.
+	task = &Task{
+		Commands:  yaml2.NewList("echo {{ .vars.db }} {{ .db }} $db"),
+		Shell:     "sh -c $db",
+		Variables: map[string]interface{}{"db": "fake:x"},
+	}
+
+	task.Options(Resolvers(map[string]VarResolver{"fake": testResolver{"x": "secret"}}))
+
+	if err := task.Prepare(); err != nil {
+		t.Fatalf("Expected: nil, got: %s", err)
+	}
+
+	if v := task.Commands.Values[0]; v != "echo secret secret secret" {
+		t.Errorf("Expected: resolved command, got: %s", v)
+	}
+
+	if task.Shell != "sh -c secret" || task.Env()["db"] != "secret" {
+		t.Errorf("Expected: resolved shell and env, got: %s, %s", task.Shell, task.Env()["db"])
+	}
+
+	task = &Task{Variables: map[string]interface{}{"TOKEN": "vault:missing"}}
+	task.Options(Resolvers(map[string]VarResolver{"vault": testResolver{}}))
+
+	if err := task.Prepare(); err == nil {
+		t.Error("Expected: resolve error, got: nil")
+	}
+}
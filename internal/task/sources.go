@@ -0,0 +1,137 @@
+package task
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Changed reports whether any of the files matches the task's source globs.
+// Globs are relative to the task directory, or the working directory when
+// the task has no directory, and ** matches any number of directories.
+// Files must be absolute paths.
+func (t *Task) Changed(files []string) bool {
+	return len(t.ChangedSources(files)) > 0
+}
+
+// ChangedSources returns the files that matches the task's sources in the
+// order they are given, each file is only returned once.
+func (t *Task) ChangedSources(files []string) []string {
+	dir := t.Dir
+	if len(dir) == 0 {
+		dir, _ = os.Getwd()
+	}
+
+	var res []string
+	seen := make(map[string]bool)
+
+	for _, file := range files {
+		for _, glob := range t.Sources.Values {
+			if !filepath.IsAbs(glob) {
+				glob = filepath.Join(dir, glob)
+			}
+
+			if !seen[file] && matchGlob(filepath.ToSlash(glob), filepath.ToSlash(file)) {
+				res = append(res, file)
+				seen[file] = true
+			}
+		}
+	}
+
+	return res
+}
+
+// SourceFiles returns the existing files that matches the task's sources,
+// the directories before the first segment with a pattern are walked.
+func (t *Task) SourceFiles() ([]string, error) {
+	dir := t.Dir
+	if len(dir) == 0 {
+		dir, _ = os.Getwd()
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+
+	for _, glob := range t.Sources.Values {
+		if !filepath.IsAbs(glob) {
+			glob = filepath.Join(dir, glob)
+		}
+
+		root := globRoot(filepath.ToSlash(glob))
+
+		err := filepath.Walk(filepath.FromSlash(root), func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+
+				return err
+			}
+
+			if !fi.IsDir() && !seen[path] && matchGlob(filepath.ToSlash(glob), filepath.ToSlash(path)) {
+				files = append(files, path)
+				seen[path] = true
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// globRoot returns the segments of a slash separated glob before the first
+// segment with a pattern.
+func globRoot(glob string) string {
+	segments := strings.Split(glob, "/")
+
+	for i, s := range segments {
+		if !strings.ContainsAny(s, "*?[") {
+			continue
+		}
+
+		if root := strings.Join(segments[:i], "/"); len(root) > 0 {
+			return root
+		}
+
+		return "/"
+	}
+
+	return glob
+}
+
+// matchGlob matches a slash separated path against a glob where ** matches
+// any number of path segments and other segments uses filepath.Match syntax.
+func matchGlob(pattern, path string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(path, "/"))
+}
+
+func matchSegments(pattern, path []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(path); i++ {
+				if matchSegments(pattern[1:], path[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(path) == 0 {
+			return false
+		}
+
+		if ok, err := filepath.Match(pattern[0], path[0]); err != nil || !ok {
+			return false
+		}
+
+		pattern, path = pattern[1:], path[1:]
+	}
+
+	return len(path) == 0
+}
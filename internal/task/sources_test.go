@@ -0,0 +1,93 @@
+package task
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/frozzare/go/yaml2"
+)
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"/app/*.go", "/app/main.go", true},
+		{"/app/*.go", "/app/cmd/main.go", false},
+		{"/app/**/*.go", "/app/main.go", true},
+		{"/app/**/*.go", "/app/cmd/max/main.go", true},
+		{"/app/**", "/app/readme.md", true},
+		{"/app/**/*.go", "/other/main.go", false},
+		{"/app/go.mod", "/app/go.sum", false},
+	}
+
+	for _, test := range tests {
+		if got := matchGlob(test.pattern, test.path); got != test.match {
+			t.Errorf("Expected: %v for %s and %s, got: %v", test.match, test.pattern, test.path, got)
+		}
+	}
+}
+
+func TestChanged(t *testing.T) {
+	task := &Task{
+		Dir:     "/app/api",
+		Sources: yaml2.NewList([]string{"**/*.go", "go.mod"}),
+	}
+
+	if !task.Changed([]string{"/app/web/index.js", "/app/api/server/main.go"}) {
+		t.Errorf("Expected: true, got: false")
+	}
+
+	if task.Changed([]string{"/app/web/index.js", "/app/go.mod"}) {
+		t.Errorf("Expected: false, got: true")
+	}
+}
+
+func TestChangedSources(t *testing.T) {
+	task := &Task{
+		Dir:     "/app/api",
+		Sources: yaml2.NewList([]string{"**/*.go", "main.go"}),
+	}
+
+	got := task.ChangedSources([]string{"/app/api/main.go", "/app/web/index.js", "/app/api/server/server.go"})
+	exp := []string{"/app/api/main.go", "/app/api/server/server.go"}
+
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected: %v, got: %v", exp, got)
+	}
+}
+
+func TestSourceFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "max-sources")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.RemoveAll(dir)
+
+	os.MkdirAll(filepath.Join(dir, "server"), 0755)
+
+	for _, name := range []string{"main.go", "readme.md", "server/server.go"} {
+		ioutil.WriteFile(filepath.Join(dir, name), []byte(""), 0644)
+	}
+
+	task := &Task{
+		Dir:     dir,
+		Sources: yaml2.NewList([]string{"**/*.go", "main.go", "missing/*.go"}),
+	}
+
+	got, err := task.SourceFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := []string{filepath.Join(dir, "main.go"), filepath.Join(dir, "server", "server.go")}
+
+	if !reflect.DeepEqual(got, exp) {
+		t.Errorf("Expected: %v, got: %v", exp, got)
+	}
+}
@@ -2,8 +2,11 @@ package task
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
+	"regexp"
 	"strings"
 
 	"github.com/frozzare/go/yaml2"
@@ -11,22 +14,86 @@ import (
 	"github.com/frozzare/max/internal/exec"
 )
 
+// ErrScriptAndCommands is returned when a task has both a script and commands.
+var ErrScriptAndCommands = errors.New("max: task can't have both script and commands")
+
+// Command modes.
+const (
+	ModeChain    = "chain"
+	ModeSeparate = "separate"
+)
+
 // Task represents a task.
 type Task struct {
-	Args      map[string]interface{}
-	Commands  yaml2.List
-	Deps      []string
-	Dir       string
-	Docker    *config.Docker
-	Interval  string
-	Summary   string
-	Status    yaml2.List
-	Tasks     yaml2.List
-	Usage     string
-	Variables map[string]string
-
-	id  string      `structs:"-"`
-	log *log.Logger `structs:"-"`
+	Args        map[string]interface{}
+	Artifacts   yaml2.List
+	CacheKey    string `yaml:"cache_key"`
+	Capture     map[string]string
+	CleanEnv    *bool `yaml:"clean_env"`
+	Commands    yaml2.List
+	Concurrency string
+	Deps        []string
+	Deprecated  string
+	Dir         string
+	Docker      *config.Docker
+	EnvFile     yaml2.List `yaml:"env_file"`
+	EnvFrom     []string   `yaml:"env_from"`
+	Expect      *Expect
+	Extends     string
+	Interval    string
+	Lock        *Lock
+	MaxOutput   string `yaml:"max_output"`
+	Mode        string
+	Nice        int
+	OnFailure   yaml2.List `yaml:"on_failure"`
+	Post        yaml2.List
+	Priority    int
+	Quiet       *bool
+	Requires    yaml2.List
+	Retries     int
+	RetryDelay  string `yaml:"retry_delay"`
+	RetryIf     string `yaml:"retry_if_output"`
+	RetryOn     []int  `yaml:"retry_on"`
+	Script      string
+	Session     bool
+	Shell       string
+	Sources     yaml2.List
+	Summary     string
+	Status      yaml2.List
+	Strict      bool
+	Tasks       yaml2.List
+	Timeout     string
+	TTY         bool
+	Usage       string
+	Variables   map[string]interface{}
+	Wait        *Wait
+	Weight      int
+
+	base            string                 `structs:"-"`
+	id              string                 `structs:"-"`
+	log             *log.Logger            `structs:"-"`
+	origin          string                 `structs:"-"`
+	resolvers       map[string]VarResolver `structs:"-"`
+	strictTemplates bool                   `structs:"-"`
+}
+
+// Base returns the file or url the task was included from.
+func (t *Task) Base(base ...string) string {
+	if len(base) > 0 {
+		t.base = base[0]
+	}
+
+	return t.base
+}
+
+// Origin returns the host of the url the task was included from, empty for
+// local tasks and remote tasks pinned by a checksum.
+func (t *Task) Origin(origin ...string) string {
+	if len(origin) > 0 {
+		t.origin = origin[0]
+	}
+
+	return t.origin
 }
 
 // ID returns the task id.
@@ -38,6 +105,40 @@ func (t *Task) ID(id ...string) string {
 	return t.id
 }
 
+// CleanEnvEnabled reports whether the task's commands runs with a clean
+// environment, without the process environment other than PATH and HOME.
+func (t *Task) CleanEnvEnabled() bool {
+	return t.CleanEnv != nil && *t.CleanEnv
+}
+
+// AllDeps returns the deps followed by the env_from tasks that are not deps,
+// env_from tasks runs before the task like deps so their captured variables
+// can be imported.
+func (t *Task) AllDeps() []string {
+	deps := append([]string{}, t.Deps...)
+
+	for _, id := range t.EnvFrom {
+		found := false
+		for _, dep := range deps {
+			if dep == id {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			deps = append(deps, id)
+		}
+	}
+
+	return deps
+}
+
+// Env returns the task variables as strings, e.g for environment variables.
+func (t *Task) Env() map[string]string {
+	return StringVariables(t.Variables)
+}
+
 // Options sets task options.
 func (t *Task) Options(opts ...Option) {
 	for _, opts := range opts {
@@ -60,20 +161,89 @@ func (t *Task) PrintUsage(id string) {
 	if len(t.Summary) != 0 {
 		t.log.Printf("Summary:\n\n  %s", t.Summary)
 	}
+
+	if fs, _ := t.flagSet(nil); fs != nil {
+		t.log.Printf("\nFlags:\n\n%s", strings.TrimSuffix(fs.FlagUsages(), "\n"))
+	}
 }
 
 // Prepare prepares the command and directory.
 func (t *Task) Prepare() error {
-	v, err := renderStruct(t, t.Args, t.Variables)
+	// Render arguments that references other arguments, e.g {{.version}}-{{.env}}.
+	args, err := renderArgs(t.Args, t.Variables, t.strictTemplates)
+	if err != nil {
+		return err
+	}
+
+	// Render variable names and values.
+	vars, err := renderVariables(t.Variables, templateData(args, t.Variables), t.strictTemplates)
+	if err != nil {
+		return err
+	}
+
+	// Resolve variable values from registered sources, e.g vault:secret/db,
+	// before they are used in templates and commands.
+	if err := resolveVariables(vars, t.resolvers); err != nil {
+		return err
+	}
+
+	t.Variables = vars
+
+	v, err := renderStruct(t, templateData(args, vars), t.Env(), t.strictTemplates)
 	if err != nil {
 		return err
 	}
 
 	t = v.(*Task)
+	all := t.Env()["@"]
 
 	// Replace special stuff in commands manually.
 	for i, c := range t.Commands.Values {
-		t.Commands.Values[i] = strings.Replace(c, "$@", t.Variables["@"], -1)
+		t.Commands.Values[i] = strings.Replace(c, "$@", all, -1)
+	}
+
+	t.Script = strings.Replace(t.Script, "$@", all, -1)
+
+	// Expectations can be rendered from arguments, e.g matches: "{{ .pattern }}".
+	if t.Expect != nil {
+		if err := t.Expect.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Validate validates the task configuration.
+func (t *Task) Validate() error {
+	if len(t.Script) > 0 && len(t.Commands.Values) > 0 {
+		return ErrScriptAndCommands
+	}
+
+	switch t.Mode {
+	case "", ModeChain, ModeSeparate:
+	default:
+		return fmt.Errorf("max: unknown mode %s, use %s or %s", t.Mode, ModeChain, ModeSeparate)
+	}
+
+	if t.Session && len(t.Mode) > 0 {
+		return errors.New("max: task can't have both session and mode")
+	}
+
+	if t.Nice < -20 || t.Nice > 19 {
+		return fmt.Errorf("max: nice must be between -20 and 19, got %d", t.Nice)
+	}
+
+	if t.Expect != nil {
+		if err := t.Expect.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if len(t.RetryIf) > 0 {
+		if _, err := regexp.Compile(t.RetryIf); err != nil {
+			return fmt.Errorf("max: bad retry_if_output %q: %s", t.RetryIf, err)
+		}
 	}
 
 	return nil
@@ -85,18 +255,28 @@ func (t *Task) UpToDate(ctx context.Context) bool {
 		return false
 	}
 
+	_, failed := t.FailedStatus(ctx)
+
+	return !failed
+}
+
+// FailedStatus returns the first status command that fails, false when
+// all status commands passes.
+func (t *Task) FailedStatus(ctx context.Context) (string, bool) {
 	for _, c := range t.Status.Values {
 		opts := &exec.Options{
-			Context: ctx,
-			Dir:     t.Dir,
-			Env:     toEnv(t.Variables),
-			Command: c,
+			Context:  ctx,
+			CleanEnv: t.CleanEnvEnabled(),
+			Dir:      t.Dir,
+			Env:      toEnv(t.Env()),
+			Command:  c,
+			Shell:    t.Shell,
 		}
 
 		if err := exec.Exec(opts); err != nil {
-			return false
+			return c, true
 		}
 	}
 
-	return true
+	return "", false
 }
@@ -47,3 +47,83 @@ func TestUpToDate(t *testing.T) {
 		t.Fatal("Expected task to be up to date")
 	}
 }
+
+func TestValidate(t *testing.T) {
+	task := &Task{
+		Script: "echo Hello",
+	}
+
+	if err := task.Validate(); err != nil {
+		t.Fatalf("Expected error to be nil, got: %s", err)
+	}
+
+	task.Commands = yaml2.NewList("echo Hello")
+
+	if err := task.Validate(); err != ErrScriptAndCommands {
+		t.Fatalf("Expected error to be ErrScriptAndCommands, got: %v", err)
+	}
+
+	task = &Task{Commands: yaml2.NewList("echo Hello"), Mode: ModeChain}
+
+	if err := task.Validate(); err != nil {
+		t.Fatalf("Expected error to be nil, got: %s", err)
+	}
+
+	task.Mode = "parallel"
+
+	if err := task.Validate(); err == nil {
+		t.Fatal("Expected unknown mode error, got: nil")
+	}
+
+	task.Mode, task.Session = ModeSeparate, true
+
+	if err := task.Validate(); err == nil {
+		t.Fatal("Expected session and mode error, got: nil")
+	}
+
+	task = &Task{Commands: yaml2.NewList("echo Hello"), RetryIf: "connection ("}
+
+	if err := task.Validate(); err == nil {
+		t.Fatal("Expected bad retry_if_output error, got: nil")
+	}
+}
+
+func TestPrepareVariableTypes(t *testing.T) {
+	task := &Task{
+		Commands: yaml2.NewList("echo {{ range .hosts }}{{ . }},{{ end }} $PORT"),
+		Variables: map[string]interface{}{
+			"hosts": []interface{}{"a", "b"},
+			"PORT":  8080,
+		},
+	}
+
+	if err := task.Prepare(); err != nil {
+		t.Fatalf("Expected error to be nil, got: %s", err)
+	}
+
+	if task.Commands.Values[0] != "echo a,b, 8080" {
+		t.Fatalf("Expected command value to be 'echo a,b, 8080', got: %s", task.Commands.Values[0])
+	}
+
+	if task.Env()["hosts"] != "a b" {
+		t.Fatalf("Expected hosts env to be 'a b', got: %s", task.Env()["hosts"])
+	}
+}
+
+func TestPrepareNamespaces(t *testing.T) {
+	task := &Task{
+		Args:     map[string]interface{}{"name": "arg"},
+		Commands: yaml2.NewList("echo {{ .name }} {{ .args.name }} {{ .vars.name }}"),
+		Variables: map[string]interface{}{
+			"name": "var",
+		},
+	}
+
+	if err := task.Prepare(); err != nil {
+		t.Fatalf("Expected error to be nil, got: %s", err)
+	}
+
+	if task.Commands.Values[0] != "echo arg arg var" {
+		t.Fatalf("Expected command value to be 'echo arg arg var', got: %s", task.Commands.Values[0])
+	}
+}
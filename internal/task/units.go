@@ -0,0 +1,171 @@
+package task
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorhill/cronexpr"
+)
+
+var daysRegexp = regexp.MustCompile(`^(\d+(?:\.\d+)?)d`)
+
+// Hints used in unit errors.
+const (
+	durationHint = "a duration like 30s, 5m, 1h or 2d"
+	intervalHint = "a duration like 5m or a cron expression"
+	sizeHint     = "a size like 512KB or 10MB"
+)
+
+// UnitError is returned when a duration or size field has a bad value.
+type UnitError struct {
+	Task  string
+	Field string
+	Value string
+	Hint  string
+}
+
+// Error returns the error message with the field, the value and a example value.
+func (e *UnitError) Error() string {
+	msg := fmt.Sprintf("bad %s %q, use %s", e.Field, e.Value, e.Hint)
+
+	if len(e.Task) > 0 {
+		return fmt.Sprintf("max: task %s: %s", e.Task, msg)
+	}
+
+	return "max: " + msg
+}
+
+// ParseDuration parses a duration like time.ParseDuration but also accepts
+// days and spaces between the number and the unit, e.g 30s, 5 m, 1h30m or 2d.
+func ParseDuration(s string) (time.Duration, error) {
+	v := strings.Replace(strings.TrimSpace(s), " ", "", -1)
+
+	var days time.Duration
+
+	if m := daysRegexp.FindStringSubmatch(v); m != nil {
+		n, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("max: bad duration %s", s)
+		}
+
+		days, v = time.Duration(n*24*float64(time.Hour)), v[len(m[0]):]
+
+		if len(v) == 0 {
+			return days, nil
+		}
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("max: bad duration %s", s)
+	}
+
+	return days + d, nil
+}
+
+// ValidateDuration returns a UnitError when a duration field has a bad value.
+// Values with templates or variables are validated when they are used.
+func ValidateDuration(field, s string) error {
+	if len(s) == 0 || dynamic(s) {
+		return nil
+	}
+
+	if _, err := ParseDuration(s); err != nil {
+		return &UnitError{Field: field, Value: s, Hint: durationHint}
+	}
+
+	return nil
+}
+
+// ValidateSize returns a UnitError when a size field has a bad value.
+// Values with templates or variables are validated when they are used.
+func ValidateSize(field, s string) error {
+	if len(s) == 0 || dynamic(s) {
+		return nil
+	}
+
+	if _, err := ParseSize(s); err != nil {
+		return &UnitError{Field: field, Value: s, Hint: sizeHint}
+	}
+
+	return nil
+}
+
+// intervalDuration returns the interval as a duration, false when the
+// interval is a cron expression.
+func intervalDuration(s string) (time.Duration, bool) {
+	d, err := ParseDuration(s)
+	return d, err == nil && d > 0
+}
+
+// NextRun returns the next time a task with a interval should run, the
+// interval is a duration, e.g 5m, or a cron expression.
+func (t *Task) NextRun(now time.Time) (time.Time, error) {
+	if d, ok := intervalDuration(t.Interval); ok {
+		return now.Add(d), nil
+	}
+
+	expr, err := cronexpr.Parse(t.Interval)
+	if err != nil {
+		return time.Time{}, &UnitError{Task: t.ID(), Field: "interval", Value: t.Interval, Hint: intervalHint}
+	}
+
+	return expr.Next(now), nil
+}
+
+// UnmarshalYAML implements yaml packages interface to unmarshal custom values,
+// durations and sizes are validated so bad values are reported when the
+// config is read.
+func (t *Task) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain Task
+
+	if err := unmarshal((*plain)(t)); err != nil {
+		return err
+	}
+
+	return t.validateUnits()
+}
+
+func (t *Task) validateUnits() error {
+	if err := ValidateDuration("retry_delay", t.RetryDelay); err != nil {
+		return err
+	}
+
+	if err := ValidateSize("max_output", t.MaxOutput); err != nil {
+		return err
+	}
+
+	if err := ValidateDuration("timeout", t.Timeout); err != nil {
+		return err
+	}
+
+	if t.Lock != nil {
+		if err := ValidateDuration("lock timeout", t.Lock.Timeout); err != nil {
+			return err
+		}
+	}
+
+	if t.Wait != nil {
+		if err := ValidateDuration("wait timeout", t.Wait.Timeout); err != nil {
+			return err
+		}
+	}
+
+	if len(t.Interval) > 0 && !dynamic(t.Interval) {
+		if _, ok := intervalDuration(t.Interval); !ok {
+			if _, err := cronexpr.Parse(t.Interval); err != nil {
+				return &UnitError{Field: "interval", Value: t.Interval, Hint: intervalHint}
+			}
+		}
+	}
+
+	return nil
+}
+
+// dynamic reports whether a value is rendered when the task runs.
+func dynamic(s string) bool {
+	return strings.Contains(s, "{{") || strings.Contains(s, "$")
+}
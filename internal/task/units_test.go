@@ -0,0 +1,73 @@
+package task
+
+import (
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in  string
+		out time.Duration
+		err bool
+	}{
+		{"30s", 30 * time.Second, false},
+		{"5 m", 5 * time.Minute, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"2d", 48 * time.Hour, false},
+		{"1d12h", 36 * time.Hour, false},
+		{"5 bananas", 0, true},
+		{"", 0, true},
+	}
+
+	for _, test := range tests {
+		d, err := ParseDuration(test.in)
+
+		if (err != nil) != test.err || d != test.out {
+			t.Errorf("Expected: %s, %v for %q, got: %s, %v", test.out, test.err, test.in, d, err)
+		}
+	}
+}
+
+func TestUnmarshalUnits(t *testing.T) {
+	tests := []struct {
+		in  string
+		err string
+	}{
+		{"retry_delay: 5s\nmax_output: 10 MB\ninterval: 5m\nwait:\n  timeout: 1m\n", ""},
+		{"retry_delay: '{{ .delay }}'\ninterval: '*/5 * * * *'\n", ""},
+		{"retry_delay: 5 bananas\n", `max: bad retry_delay "5 bananas", use a duration like 30s, 5m, 1h or 2d`},
+		{"max_output: lots\n", `max: bad max_output "lots", use a size like 512KB or 10MB`},
+		{"interval: sometimes\n", `max: bad interval "sometimes", use a duration like 5m or a cron expression`},
+		{"wait:\n  timeout: soon\n", `max: bad wait timeout "soon", use a duration like 30s, 5m, 1h or 2d`},
+	}
+
+	for _, test := range tests {
+		var task *Task
+		err := yaml.Unmarshal([]byte(test.in), &task)
+
+		if test.err == "" && err != nil {
+			t.Errorf("Expected: nil for %q, got: %s", test.in, err)
+		}
+
+		if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("Expected: %s, got: %v", test.err, err)
+		}
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	now := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	task := &Task{Interval: "90s"}
+	if next, err := task.NextRun(now); err != nil || !next.Equal(now.Add(90*time.Second)) {
+		t.Errorf("Expected: %s, got: %s, %v", now.Add(90*time.Second), next, err)
+	}
+
+	task = &Task{Interval: "0 * * * *"}
+	if next, err := task.NextRun(now); err != nil || !next.Equal(now.Add(time.Hour)) {
+		t.Errorf("Expected: %s, got: %s, %v", now.Add(time.Hour), next, err)
+	}
+}
@@ -0,0 +1,99 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultWaitTimeout = 30 * time.Second
+	maxWaitBackoff     = 2 * time.Second
+)
+
+// Wait represents a readiness check that is polled before a task runs.
+type Wait struct {
+	HTTP    string `yaml:"http"`
+	TCP     string `yaml:"tcp"`
+	Timeout string `yaml:"timeout"`
+}
+
+// Run polls the tcp address or http url until it's ready or the timeout is reached.
+func (w *Wait) Run(ctx context.Context) error {
+	timeout := defaultWaitTimeout
+
+	if len(w.Timeout) > 0 {
+		d, err := ParseDuration(w.Timeout)
+		if err != nil {
+			return fmt.Errorf("max: bad wait timeout %s", w.Timeout)
+		}
+		timeout = d
+	}
+
+	var check func(context.Context) error
+	var target string
+
+	switch {
+	case len(w.TCP) > 0:
+		check, target = w.checkTCP, w.TCP
+	case len(w.HTTP) > 0:
+		check, target = w.checkHTTP, w.HTTP
+	default:
+		return errors.New("max: wait requires tcp or http")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := 100 * time.Millisecond
+
+	for {
+		if err := check(ctx); err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("max: timeout waiting for %s", target)
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > maxWaitBackoff {
+			backoff = maxWaitBackoff
+		}
+	}
+}
+
+func (w *Wait) checkTCP(ctx context.Context) error {
+	var d net.Dialer
+
+	conn, err := d.DialContext(ctx, "tcp", w.TCP)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+func (w *Wait) checkHTTP(ctx context.Context) error {
+	req, err := http.NewRequest(http.MethodGet, w.HTTP, nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("max: bad status code %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package task
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWaitTCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer l.Close()
+
+	w := &Wait{TCP: l.Addr().String(), Timeout: "1s"}
+
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatalf("Expected error to be nil, got: %s", err)
+	}
+}
+
+func TestWaitHTTP(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls++; calls < 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+	}))
+
+	defer server.Close()
+
+	w := &Wait{HTTP: server.URL, Timeout: "2s"}
+
+	if err := w.Run(context.Background()); err != nil {
+		t.Fatalf("Expected error to be nil, got: %s", err)
+	}
+
+	if calls != 2 {
+		t.Fatalf("Expected two calls, got: %d", calls)
+	}
+}
+
+func TestWaitTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := l.Addr().String()
+	l.Close()
+
+	w := &Wait{TCP: addr, Timeout: "200ms"}
+
+	if err := w.Run(context.Background()); err == nil {
+		t.Fatal("Expected timeout error")
+	}
+}